@@ -0,0 +1,91 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/Haizza1/go-block/script"
+)
+
+var (
+	ErrDataNotFound = errors.New("no block anchors the data")
+	ErrInvalidData  = errors.New("data outputs carry at most 80 bytes and no value")
+	ErrTooManyData  = errors.New("a transaction carries a single data output")
+	ErrNoInputs     = errors.New("the transaction spends no coins, pay a fee")
+)
+
+// NewDataOutput will create an output that carries the data, it holds no
+// value and can never be spent
+func NewDataOutput(data []byte) (*TxOutput, error) {
+	locking, err := script.NullData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxOutput{Value: 0, Script: locking}, nil
+}
+
+// IsUnspendable will check if the output can never be spent, it never
+// enters the unspent set
+func (out *TxOutput) IsUnspendable() bool {
+	return script.IsUnspendable(out.Script)
+}
+
+// checkDataOutputs will check the payload and the value of the data
+// outputs of the transaction
+func (tx *Transaction) checkDataOutputs() error {
+	count := 0
+	for _, out := range tx.Outputs {
+		if !out.IsUnspendable() {
+			continue
+		}
+
+		data, ok := script.ExtractNullData(out.Script)
+		if !ok || len(data) > script.MaxDataSize || out.Value != 0 {
+			return ErrInvalidData
+		}
+
+		count++
+	}
+
+	if count > 1 {
+		return ErrTooManyData
+	}
+
+	return nil
+}
+
+// Anchor is the place in the chain where some data was stored
+type Anchor struct {
+	Block       *Block       // represents the block that holds the data
+	Transaction *Transaction // represents the transaction that carries the data
+}
+
+// FindData will search the blockchain for the data output that carries
+// the given data, the oldest one wins
+func (chain *BlockChain) FindData(data []byte) (*Anchor, error) {
+	var anchor *Anchor
+
+	iter := chain.Iterator()
+	for {
+		block := iter.Next()
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Outputs {
+				payload, ok := script.ExtractNullData(out.Script)
+				if ok && bytes.Equal(payload, data) {
+					anchor = &Anchor{Block: block, Transaction: tx}
+				}
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	if anchor == nil {
+		return nil, ErrDataNotFound
+	}
+
+	return anchor, nil
+}
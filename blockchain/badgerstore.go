@@ -0,0 +1,83 @@
+package blockchain
+
+import (
+	"github.com/dgraph-io/badger/v3"
+)
+
+// badgerStore keeps the chain in a badger database
+type badgerStore struct {
+	db *badger.DB
+}
+
+// badgerTxn is a badger transaction seen as a key value transaction
+type badgerTxn struct {
+	txn *badger.Txn
+}
+
+// NewBadgerStore will create a chain store over an open badger database,
+// closing the store closes the database
+func NewBadgerStore(db *badger.DB) ChainStore {
+	return &badgerStore{db}
+}
+
+// View will run fn inside a read only badger transaction
+func (s *badgerStore) View(fn func(txn StoreTxn) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		return fn(storeTxn{badgerTxn{txn}})
+	})
+}
+
+// Update will run fn inside a read write badger transaction
+func (s *badgerStore) Update(fn func(txn StoreTxn) error) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return fn(storeTxn{badgerTxn{txn}})
+	})
+}
+
+// ClearUnspent will drop every key of the utxo set without the limits of a transaction
+func (s *badgerStore) ClearUnspent() error {
+	return s.db.DropPrefix(utxoPrefix)
+}
+
+// Close will close the badger database
+func (s *badgerStore) Close() error {
+	return s.db.Close()
+}
+
+func (t badgerTxn) get(key []byte) ([]byte, error) {
+	item, err := t.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return item.ValueCopy(nil)
+}
+
+func (t badgerTxn) set(key, value []byte) error {
+	return t.txn.Set(key, value)
+}
+
+func (t badgerTxn) delete(key []byte) error {
+	return t.txn.Delete(key)
+}
+
+func (t badgerTxn) iterate(prefix []byte, fn func(key, value []byte) error) error {
+	it := t.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item.KeyCopy(nil), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
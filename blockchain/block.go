@@ -2,11 +2,21 @@ package blockchain
 
 import (
 	"bytes"
-	"encoding/gob"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log"
 	"runtime"
 	"time"
+
+	"github.com/Haizza1/go-block/merkle"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/wire"
+)
+
+var (
+	ErrMerkleRoot = errors.New("the merkle root does not match the transactions of the block")
+	ErrDifficulty = errors.New("the block is easier than the proof of work limit")
 )
 
 type Block struct {
@@ -16,23 +26,37 @@ type Block struct {
 	PrevHash     []byte         // represents last block hash
 	Nonce        int            // represents the diffyculty
 	Heigth       int            // represents the heigth of the current block
+	Bits         uint32         // represents the difficulty, the leading zero bits of the hash
 }
 
 // HashTransactions will allow to use a hashing mechanism
-// to provide a unique reperesentation of all the transactions
+// to provide a unique reperesentation of all the transactions,
+// it is the merkle root of their ids
 func (b *Block) HashTransactions() []byte {
 	var tsxHashes [][]byte
 
 	for _, tx := range b.Transactions {
-		tsxHashes = append(tsxHashes, tx.Serialize())
+		tsxHashes = append(tsxHashes, tx.Hash())
+	}
+
+	return merkle.Root(tsxHashes)
+}
+
+// HashWitnesses will return the merkle root of the witness ids of the
+// transactions, so the header also commits to their signatures
+func (b *Block) HashWitnesses() []byte {
+	var witnessHashes [][]byte
+
+	for _, tx := range b.Transactions {
+		witnessHashes = append(witnessHashes, tx.WitnessHash())
 	}
 
-	tree := NewMerkletree(tsxHashes)
-	return tree.RootNode.Data
+	return merkle.Root(witnessHashes)
 }
 
-// CreateBlock will generate a new Block instance with a pointer
-func CreateBlock(tsx []*Transaction, prevHash []byte, heigth int) *Block {
+// CreateBlock will generate a new Block instance with a pointer, mined
+// with the given difficulty
+func CreateBlock(tsx []*Transaction, prevHash []byte, heigth int, bits uint32) *Block {
 	block := &Block{
 		TimeStamp:    time.Now().Unix(),
 		Hash:         []byte{},
@@ -40,6 +64,7 @@ func CreateBlock(tsx []*Transaction, prevHash []byte, heigth int) *Block {
 		PrevHash:     prevHash,
 		Nonce:        0,
 		Heigth:       heigth,
+		Bits:         bits,
 	}
 
 	pow := NewProof(block)
@@ -49,30 +74,104 @@ func CreateBlock(tsx []*Transaction, prevHash []byte, heigth int) *Block {
 	return block
 }
 
-// Genesis will create the first block in the blockchain
-func Genesis(coinbase *Transaction) *Block {
-	return CreateBlock([]*Transaction{coinbase}, []byte{}, 0)
+// Header will encode the header of the block with the given nonce, the
+// hash of the block is the sha256 of its header
+func (b *Block) Header(nonce int) []byte {
+	w := wire.NewWriter()
+	w.Uint32(wire.BlockVersion)
+	w.VarBytes(b.PrevHash)
+	w.VarBytes(b.HashTransactions())
+	w.VarBytes(b.HashWitnesses())
+	w.Int64(b.TimeStamp)
+	w.Uint32(uint32(b.Heigth))
+	w.Uint32(b.Bits)
+	w.Uint64(uint64(nonce))
+	return w.Bytes()
 }
 
-// Serialize will serializer the block struct in to bytes
+// Serialize will serializer the block struct with the canonical encoding
+// of the wire package, the header followed by the transactions
 func (b *Block) Serialize() []byte {
-	var res bytes.Buffer
-	encoder := gob.NewEncoder(&res)
+	w := wire.NewWriter()
+	w.Raw(b.Header(b.Nonce))
 
-	err := encoder.Encode(b)
-	CheckError(err)
-	return res.Bytes()
+	w.VarInt(uint64(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		tx.encode(w, true)
+	}
+
+	return w.Bytes()
 }
 
 // Deserialize will deserialize a chunk of data into a Block struct
 func Deserialize(data []byte) *Block {
-	var block Block
-
-	decoder := gob.NewDecoder(bytes.NewReader(data))
-	err := decoder.Decode(&block)
+	block, err := ParseBlock(data)
 	CheckError(err)
 
-	return &block
+	return block
+}
+
+// ParseBlock will decode a canonical block, check that the header commits
+// to its transactions and compute its hash
+func ParseBlock(data []byte) (*Block, error) {
+	r := wire.NewReader(data)
+	block, merkleRoot, witnessRoot := readHeader(r)
+
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		tx := decodeTransaction(r)
+		block.Transactions = append(block.Transactions, &tx)
+	}
+
+	if err := r.Done(); err != nil {
+		return nil, err
+	}
+
+	if block.Bits < params.Active.PowLimitBits {
+		return nil, ErrDifficulty
+	}
+
+	if !bytes.Equal(merkleRoot, block.HashTransactions()) || !bytes.Equal(witnessRoot, block.HashWitnesses()) {
+		return nil, ErrMerkleRoot
+	}
+
+	hash := sha256.Sum256(block.Header(block.Nonce))
+	block.Hash = hash[:]
+	return block, nil
+}
+
+// readHeader will read the fields of a block header and return the block
+// without transactions and the merkle roots
+func readHeader(r *wire.Reader) (*Block, []byte, []byte) {
+	block := &Block{}
+	r.Version(wire.BlockVersion)
+	block.PrevHash = r.VarBytes()
+	merkleRoot := r.VarBytes()
+	witnessRoot := r.VarBytes()
+	block.TimeStamp = r.Int64()
+	block.Heigth = int(r.Uint32())
+	block.Bits = r.Uint32()
+	block.Nonce = int(r.Uint64())
+
+	return block, merkleRoot, witnessRoot
+}
+
+// parseHeader will decode only the header of an encoded block, the result
+// has no transactions and its hash is the hash of the decoded header
+func parseHeader(data []byte) (*Block, error) {
+	r := wire.NewReader(data)
+	block, _, _ := readHeader(r)
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	if block.Bits < params.Active.PowLimitBits {
+		return nil, ErrDifficulty
+	}
+
+	hash := sha256.Sum256(data[:r.Pos()])
+	block.Hash = hash[:]
+	return block, nil
 }
 
 // CheckError will check if there is any error and then gracefuly shutdown the system
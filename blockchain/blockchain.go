@@ -5,27 +5,43 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"os"
 	"runtime"
 
+	"github.com/Haizza1/go-block/events"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
 	"github.com/dgraph-io/badger/v3"
 	"github.com/pkg/errors"
 )
 
 const (
-	dbPath      = "./tmp/blocks_%s"
-	genesisData = "First Transaction from genesis"
+	dbFile     = "blocks_%s"
+	networkKey = "network"
+	genesisKey = "genesis"
+)
+
+// dbPath will return the path of the database of the node in the directory
+// of the active network
+func dbPath(nodeId string) string {
+	return params.Path(fmt.Sprintf(dbFile, nodeId))
+}
+
+var (
+	ErrWrongNetwork = errors.New("the blockchain belongs to another network")
+	ErrNotRegtest   = errors.New("blocks are only generated on demand on regtest")
 )
 
 type BlockChain struct {
-	LastHash []byte     // represents the last hash of the current block
-	Database *badger.DB // represents the db where the blocks will be store
+	LastHash []byte      // represents the last hash of the current block
+	Store    ChainStore  // represents the store where the blocks are kept
+	Events   *events.Bus // represents where the changes of the best chain are published, nil publishes nothing
 }
 
 type BlockChainIterator struct {
 	CurrentHash []byte // represents the current hash
-	Database    *badger.DB
+	Store       ChainStore
 }
 
 //DBexists will check if a badger db already exists in the db path
@@ -37,35 +53,40 @@ func DBexists(path string) bool {
 	return true
 }
 
+// ChainExists will check if the node already has a blockchain
+func ChainExists(nodeId string) bool {
+	return DBexists(dbPath(nodeId))
+}
+
 // ContinueBlockchain will continue the blockchain with the last hashed block
 func ContinueBlockChain(nodeId string) *BlockChain {
-	path := fmt.Sprintf(dbPath, nodeId)
+	path := dbPath(nodeId)
 	if !DBexists(path) {
 		fmt.Println("No existing blockchain found, go and create one!")
 		runtime.Goexit()
 	}
 
-	var lastHash []byte
 	db, err := badger.Open(badger.DefaultOptions(path))
 	CheckError(err)
 
-	err = db.Update(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte("lh"))
-		CheckError(err)
-
-		lastHash, err = item.ValueCopy(nil)
-		return err
-	})
+	migrated, err := migrate(db)
+	CheckError(err)
 
+	blockChain, err := LoadBlockChain(NewBadgerStore(db))
 	CheckError(err)
-	blockChain := &BlockChain{lastHash, db}
+
+	if migrated != nil {
+		UTXOSet{blockChain}.Reindex()
+		fmt.Println("Migration finished, the unspent outputs were reindexed")
+	}
+
 	return blockChain
 }
 
-// InitBLockChain will start the blockchain
+// InitBLockChain will start the blockchain with the genesis block of the
+// network, when an address is given the first block pays it the reward
 func InitBLockChain(address, nodeId string) *BlockChain {
-	var lastHash []byte
-	path := fmt.Sprintf(dbPath, nodeId)
+	path := dbPath(nodeId)
 
 	if DBexists(path) {
 		fmt.Println("Blockchain already exists")
@@ -75,90 +96,225 @@ func InitBLockChain(address, nodeId string) *BlockChain {
 	db, err := badger.Open(badger.DefaultOptions(path))
 	CheckError(err)
 
-	err = db.Update(func(txn *badger.Txn) error {
-		cbtx := CoinbaseTx(address, genesisData)
-		genesis := Genesis(cbtx)
-		fmt.Println("Genesis Created")
+	blockChain, err := NewBlockChain(NewBadgerStore(db))
+	CheckError(err)
 
-		err := txn.Set(genesis.Hash, genesis.Serialize())
-		CheckError(err)
+	fmt.Println("Genesis Created")
+	if address != "" {
+		blockChain.MineBlock([]*Transaction{CoinbaseTx(address, "")})
+	}
 
-		err = txn.Set([]byte("lh"), genesis.Hash)
-		lastHash = genesis.Hash
-		return err
+	return blockChain
+}
+
+// NewBlockChain will start a blockchain in an empty store with the genesis
+// block of the active network
+func NewBlockChain(store ChainStore) (*BlockChain, error) {
+	genesis, err := GenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Update(func(txn StoreTxn) error {
+		if _, err := txn.Tip(); err != ErrNotFound {
+			return errors.New("the store already has a blockchain")
+		}
+
+		if err := txn.PutBlock(genesis); err != nil {
+			return err
+		}
+
+		if err := txn.SetTip(genesis.Hash); err != nil {
+			return err
+		}
+
+		if err := txn.SetMeta(networkKey, []byte(params.Active.Name)); err != nil {
+			return err
+		}
+
+		if err := txn.SetMeta(genesisKey, genesis.Hash); err != nil {
+			return err
+		}
+
+		return setFormat(txn)
 	})
 
-	CheckError(err)
-	blockChain := &BlockChain{lastHash, db}
-	return blockChain
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockChain{LastHash: genesis.Hash, Store: store}, nil
 }
 
-// add block will add the block to the db and check if
-// the heigth is the grater in the blockchain
-func (chain *BlockChain) AddBlock(block *Block) {
-	err := chain.Database.Update(func(txn *badger.Txn) error {
-		if _, err := txn.Get(block.Hash); err != nil {
-			return nil // the block is already store
+// LoadBlockChain will continue the blockchain kept in the store, it must
+// belong to the active network
+func LoadBlockChain(store ChainStore) (*BlockChain, error) {
+	var lastHash []byte
+	err := store.View(func(txn StoreTxn) error {
+		name, err := txn.Meta(networkKey)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		// the chains created before the networks have no name and are mainnet
+		if err == ErrNotFound {
+			name = []byte(params.Mainnet.Name)
+		}
+
+		if string(name) != params.Active.Name {
+			return fmt.Errorf("%w: it was created for %s", ErrWrongNetwork, name)
 		}
 
-		blockData := block.Serialize()
-		if err := txn.Set(block.Hash, blockData); err != nil {
+		// the chains created before the chain parameters have their own genesis
+		genesis, err := txn.Meta(genesisKey)
+		if err == nil && hex.EncodeToString(genesis) != params.Active.Genesis.Hash {
+			return ErrGenesisHash
+		} else if err != nil && err != ErrNotFound {
 			return err
 		}
 
-		item, err := txn.Get([]byte("lh"))
-		if err != nil {
+		lastHash, err = txn.Tip()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the chains stored before the height index get it built once
+	err = store.Update(func(txn StoreTxn) error {
+		return txn.SetTip(lastHash)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockChain{LastHash: lastHash, Store: store}, nil
+}
+
+// add block will check the block and add it to the db, it becomes the
+// tip when its heigth is the grater in the blockchain. The transactions
+// of a block that extends the tip are verified, a block of another
+// branch is only kept until its branch grows longer, then the whole
+// branch is verified before it replaces the best chain
+func (chain *BlockChain) AddBlock(block *Block) error {
+	if err := chain.CheckBlock(block); err != nil {
+		return err
+	}
+
+	if bytes.Equal(block.PrevHash, chain.LastHash) {
+		if err := chain.checkTransactions(block); err != nil {
 			return err
 		}
+	} else if err := chain.checkBranch(block); err != nil {
+		return err
+	}
+
+	var oldTip []byte
+	err := chain.Store.Update(func(txn StoreTxn) error {
+		if stored, err := txn.HasBlock(block.Hash); err != nil || stored {
+			return err // the block is already store
+		}
 
-		lastHash, _ := item.ValueCopy(nil)
+		if err := txn.PutBlock(block); err != nil {
+			return err
+		}
 
-		item, err = txn.Get(lastHash)
+		lastHash, err := txn.Tip()
 		if err != nil {
 			return err
 		}
 
-		lastBlockData, _ := item.ValueCopy(nil)
-		lastBlock := Deserialize(lastBlockData)
+		lastBlock, err := txn.Header(lastHash)
+		if err != nil {
+			return err
+		}
 
 		if block.Heigth > lastBlock.Heigth {
-			err = txn.Set([]byte("lh"), block.Hash)
-			if err != nil {
+			if err := txn.SetTip(block.Hash); err != nil {
 				return err
 			}
 
 			chain.LastHash = block.Hash
+			oldTip = lastHash
 		}
 
 		return nil
 	})
 
-	CheckError(err)
+	if err != nil {
+		return err
+	}
+
+	if oldTip != nil {
+		chain.notifyTip(oldTip, block)
+	}
+
+	return nil
+}
+
+// HasBlock will check if the block with the given hash is stored
+func (chain *BlockChain) HasBlock(blockHash []byte) bool {
+	var stored bool
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		stored, err = txn.HasBlock(blockHash)
+		return err
+	})
+
+	return err == nil && stored
 }
 
 // Get block will retrieve the block from the db if exists
 func (chain *BlockChain) GetBlock(blockHash []byte) (Block, error) {
 	var block Block
 
-	err := chain.Database.View(func(txn *badger.Txn) error {
-		if item, err := txn.Get(blockHash); err != nil {
+	err := chain.Store.View(func(txn StoreTxn) error {
+		stored, err := txn.Block(blockHash)
+		if err == ErrNotFound {
 			return errors.New("Block is not found")
-		} else {
-			blockData, err := item.ValueCopy(nil)
-			if err != nil {
-				return err
-			}
+		} else if err != nil {
+			return err
+		}
+
+		block = *stored
+		return nil
+	})
 
-			block = *Deserialize(blockData)
+	return block, err
+}
+
+// GetHeader will retrieve the block from the db without its transactions
+func (chain *BlockChain) GetHeader(blockHash []byte) (Block, error) {
+	var block Block
+
+	err := chain.Store.View(func(txn StoreTxn) error {
+		stored, err := txn.Header(blockHash)
+		if err == ErrNotFound {
+			return errors.New("Block is not found")
+		} else if err != nil {
+			return err
 		}
+
+		block = *stored
 		return nil
 	})
 
-	if err != nil {
-		return block, err
-	}
+	return block, err
+}
 
-	return block, nil
+// BlockHash will return the hash of the block of the best chain at the
+// given height, ErrNotFound when the height is past the tip
+func (chain *BlockChain) BlockHash(height int) ([]byte, error) {
+	var hash []byte
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		hash, err = txn.BlockHash(height)
+		return err
+	})
+
+	return hash, err
 }
 
 // Get block hashes will retrieve a 2 dimensional array
@@ -180,108 +336,161 @@ func (chain *BlockChain) GetBlockHashes() [][]byte {
 
 // Get BestHeigth will retrieve the larger block heigth
 func (chain *BlockChain) GetBestHeigth() int {
-	var lastBlock Block
+	height, err := chain.BestHeight()
+	CheckError(err)
 
-	err := chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte("lh"))
-		if err != nil {
-			return err
-		}
-		lasthash, _ := item.ValueCopy(nil)
+	return height
+}
+
+// BestHeight will return the height of the tip of the chain
+func (chain *BlockChain) BestHeight() (int, error) {
+	var lastBlock *Block
 
-		item, err = txn.Get(lasthash)
+	err := chain.Store.View(func(txn StoreTxn) error {
+		lastHash, err := txn.Tip()
 		if err != nil {
 			return err
 		}
 
-		lastBlockData, _ := item.ValueCopy(nil)
-		lastBlock = *Deserialize(lastBlockData)
-		return nil
+		lastBlock, err = txn.Header(lastHash)
+		return err
 	})
 
-	CheckError(err)
-	return lastBlock.Heigth
+	if err != nil {
+		return 0, err
+	}
+
+	return lastBlock.Heigth, nil
 }
 
 // MineBlock will add a block to the block chain
 func (chain *BlockChain) MineBlock(transactions []*Transaction) *Block {
+	block, err := chain.mineBlock(transactions)
+	CheckError(err)
+
+	return block
+}
+
+// mineBlock will mine a block with the transactions on top of the tip
+// and store it as the new tip
+func (chain *BlockChain) mineBlock(transactions []*Transaction) (*Block, error) {
 	var lastHash []byte
-	var lastHeigth int
+	var lastBlock *Block
 
-	defer HandlePanic()
-	for _, tx := range transactions {
-		if !chain.VerifyTransaction(tx) {
-			log.Panic("Invalid transaction")
-		}
+	if !chain.VerifyTransactions(transactions) {
+		return nil, ErrInvalidTx
 	}
 
-	err := chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte("lh"))
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		lastHash, err = txn.Tip()
 		if err != nil {
 			return err
 		}
 
-		lastHash, err = item.ValueCopy(nil)
-		if err != nil {
-			return err
+		lastBlock, err = txn.Header(lastHash)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastHeigth := lastBlock.Heigth
+	bits, err := chain.NextBits(lastBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	medianTime := chain.MedianTimePast(lastHash)
+	for _, tx := range transactions {
+		if err := chain.CheckLocks(tx, lastHeigth+1, medianTime); err != nil {
+			return nil, fmt.Errorf("transaction %x: %w", tx.ID, err)
 		}
+	}
 
-		item, err = txn.Get(lastHash)
-		if err != nil {
+	newBlock := CreateBlock(transactions, lastHash, lastHeigth+1, bits)
+
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		if err := txn.PutBlock(newBlock); err != nil {
 			return err
 		}
 
-		lastBlockData, err := item.ValueCopy(nil)
-		lastBlock := Deserialize(lastBlockData)
-		lastHeigth = lastBlock.Heigth
-
+		err := txn.SetTip(newBlock.Hash)
+		chain.LastHash = newBlock.Hash
 		return err
 	})
 
-	CheckError(err)
-	newBlock := CreateBlock(transactions, lastHash, lastHeigth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	chain.notifyTip(lastHash, newBlock)
+	return newBlock, nil
+}
 
-	err = chain.Database.Update(func(txn *badger.Txn) error {
-		err := txn.Set(newBlock.Hash, newBlock.Serialize())
+// Generate will mine n blocks that only pay the reward to the address and
+// update the unspent set with them. It is only allowed on regtest, where
+// the proof of work is trivial
+func (chain *BlockChain) Generate(n int, address string) ([]*Block, error) {
+	if params.Active.Name != params.Regtest.Name {
+		return nil, ErrNotRegtest
+	}
+
+	if !wallet.ValidateAddress(address) {
+		return nil, fmt.Errorf("%s: %w", address, ErrInvalidAddress)
+	}
+
+	UTXOSet := UTXOSet{chain}
+	var blocks []*Block
+	for i := 0; i < n; i++ {
+		block, err := chain.mineBlock([]*Transaction{CoinbaseTx(address, "")})
 		if err != nil {
-			return err
+			return blocks, err
 		}
 
-		err = txn.Set([]byte("lh"), newBlock.Hash)
-		chain.LastHash = newBlock.Hash
-		return err
-	})
+		if err := UTXOSet.update(block); err != nil {
+			return blocks, err
+		}
 
-	CheckError(err)
-	return newBlock
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
 }
 
 // Iterator will return a new block chain iterator instance
 // whit the blockchain data
 func (chain *BlockChain) Iterator() *BlockChainIterator {
-	iter := &BlockChainIterator{chain.LastHash, chain.Database}
+	iter := &BlockChainIterator{chain.LastHash, chain.Store}
 	return iter
 }
 
 // Next will return the next block on the list, until the genesis
 func (iter *BlockChainIterator) Next() *Block {
-	var block *Block
+	block, err := iter.NextBlock()
+	CheckError(err)
 
-	err := iter.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(iter.CurrentHash)
-		if err != nil {
-			return err
-		}
+	return block
+}
 
-		endcodedBlock, err := item.ValueCopy(nil)
-		block = Deserialize(endcodedBlock)
+// NextBlock will return the next block on the list, or the error of the
+// store when it can not be read
+func (iter *BlockChainIterator) NextBlock() (*Block, error) {
+	var block *Block
+
+	err := iter.Store.View(func(txn StoreTxn) error {
+		var err error
+		block, err = txn.Block(iter.CurrentHash)
 		return err
 	})
 
-	CheckError(err)
+	if err != nil {
+		return nil, err
+	}
 
 	iter.CurrentHash = block.PrevHash
-	return block
+	return block, nil
 }
 
 // FindUnspentTransactions will find all unspent transactions assing to one address.
@@ -301,6 +510,10 @@ func (chain *BlockChain) FindUnspentTransactions() map[string]TxOutputs {
 
 		Outputs:
 			for outIdx, out := range tx.Outputs {
+				if out.IsUnspendable() {
+					continue
+				}
+
 				if spentTxos[txID] != nil {
 					for _, spentOut := range spentTxos[txID] {
 						if spentOut == outIdx {
@@ -310,9 +523,8 @@ func (chain *BlockChain) FindUnspentTransactions() map[string]TxOutputs {
 				}
 
 				outs := unspentTxos[txID]
-				outs.Outputs = append(outs.Outputs, out)
+				outs.Add(outIdx, out)
 				unspentTxos[txID] = outs
-
 			}
 
 			if !tx.IsCoinBase() {
@@ -331,13 +543,46 @@ func (chain *BlockChain) FindUnspentTransactions() map[string]TxOutputs {
 	return unspentTxos
 }
 
+// UsedPubKeyHashes will collect the hex encoded public key hash of every
+// output in the blockchain, an address that appears here has been used
+func (chain *BlockChain) UsedPubKeyHashes() map[string]bool {
+	used := make(map[string]bool)
+	iter := chain.Iterator()
+
+	for {
+		block := iter.Next()
+
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Outputs {
+				if pubKeyHash := out.PubKeyHash(); pubKeyHash != nil {
+					used[hex.EncodeToString(pubKeyHash)] = true
+				}
+
+				// the schnorr address of a key counts as a use of the key
+				if pubKey, ok := script.ExtractSchnorrKey(out.Script); ok {
+					used[hex.EncodeToString(wallet.PublicKeyHash(pubKey))] = true
+				}
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break // this is the genesis
+		}
+	}
+
+	return used
+}
+
 // FindTransaction will check in the blockchain if the given transaction ID exists
 // if exits its return else we return a error
 func (chain *BlockChain) FindTransaction(ID []byte) (Transaction, error) {
 	iter := chain.Iterator()
 
 	for {
-		block := iter.Next()
+		block, err := iter.NextBlock()
+		if err != nil {
+			return Transaction{}, err
+		}
 
 		for _, tx := range block.Transactions {
 			if bytes.Equal(tx.ID, ID) {
@@ -355,29 +600,53 @@ func (chain *BlockChain) FindTransaction(ID []byte) (Transaction, error) {
 
 // SignTransaction will sign the transaction with the user private key
 func (chain *BlockChain) SingTransaction(tx *Transaction, privKey ecdsa.PrivateKey) {
-	prevTxs := make(map[string]Transaction)
+	prevTxs := chain.prevTransactions(tx)
+	tx.Sign(privKey, prevTxs)
+}
 
-	for _, in := range tx.Inputs {
-		prevTx, err := chain.FindTransaction(in.ID)
-		CheckError(err)
-		prevTxs[hex.EncodeToString(prevTx.ID)] = prevTx
+// TransactionFees will sum the fees of the given transactions, the miner
+// claims them in the coinbase of the block. An invalid transaction claims
+// nothing, the block that holds it is rejected anyway
+func (chain *BlockChain) TransactionFees(txs []*Transaction) int {
+	fees := 0
+	for _, tx := range txs {
+		if tx.IsCoinBase() {
+			continue
+		}
+
+		prevTxs, err := chain.findPrevTransactions(tx)
+		if err != nil {
+			continue
+		}
+
+		if fee, err := tx.Fee(prevTxs); err == nil {
+			fees += fee
+		}
 	}
 
-	tx.Sign(privKey, prevTxs)
+	return fees
 }
 
-// VerifyTransaction will check if the given transaction is valid
-func (chain *BlockChain) VerifyTransaction(tx *Transaction) bool {
-	if tx.IsCoinBase() {
-		return true
-	}
+// prevTransactions will find the transactions spent by the inputs of the given one
+func (chain *BlockChain) prevTransactions(tx *Transaction) map[string]Transaction {
+	prevTxs, err := chain.findPrevTransactions(tx)
+	CheckError(err)
+
+	return prevTxs
+}
 
+// findPrevTransactions will find the transactions spent by the inputs of
+// the given one, an input that spends an unknown transaction is an error
+func (chain *BlockChain) findPrevTransactions(tx *Transaction) (map[string]Transaction, error) {
 	prevTxs := make(map[string]Transaction)
-	for _, in := range tx.Inputs {
+	for inId, in := range tx.Inputs {
 		prevTx, err := chain.FindTransaction(in.ID)
-		CheckError(err)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", inId, err)
+		}
+
 		prevTxs[hex.EncodeToString(prevTx.ID)] = prevTx
 	}
 
-	return tx.Verify(prevTxs)
+	return prevTxs, nil
 }
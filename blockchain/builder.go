@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+var (
+	ErrNoPayments     = errors.New("the transaction has no payments")
+	ErrInvalidAmount  = errors.New("the amount of every payment must be positive")
+	ErrInvalidAddress = errors.New("the address is not valid")
+)
+
+type Payment struct {
+	Address string `json:"address"` // represents the address that receives the coins
+	Amount  int    `json:"amount"`  // represents the amount of coins to send
+}
+
+// TxBuilder builds a transaction that pays any number of recipients from
+// a single address, the coins and the fee are picked by the send options
+type TxBuilder struct {
+	Wallet        *wallet.Wallet // represents the wallet that signs, nil leaves the transaction unsigned
+	From          string         // represents the address that pays
+	UTXOSet       *UTXOSet       // represents the set the coins are picked from
+	Options       SendOptions    // represents the coin selection and fee options
+	Payments      []Payment      // represents the outputs of the transaction
+	ChangeAddress string         // represents where the change goes, empty uses the paying address
+	Data          []byte         // represents the payload of the data output, nil adds none
+}
+
+// NewTxBuilder will create a builder for the given wallet
+func NewTxBuilder(w *wallet.Wallet, utxo *UTXOSet, opts SendOptions) *TxBuilder {
+	from := fmt.Sprintf("%s", w.Address())
+	return &TxBuilder{Wallet: w, From: from, UTXOSet: utxo, Options: opts}
+}
+
+// NewAddressTxBuilder will create a builder that spends the coins of any
+// address, pay to script hash included, the result must be built partial
+func NewAddressTxBuilder(from string, utxo *UTXOSet, opts SendOptions) *TxBuilder {
+	return &TxBuilder{From: from, UTXOSet: utxo, Options: opts}
+}
+
+// AddPayment will add an output that pays the amount to the address
+func (b *TxBuilder) AddPayment(address string, amount int) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	if int64(b.Total()) > math.MaxInt64-int64(amount) {
+		return ErrValueOverflow
+	}
+
+	if !wallet.ValidateAddress(address) {
+		return fmt.Errorf("%s: %w", address, ErrInvalidAddress)
+	}
+
+	b.Payments = append(b.Payments, Payment{Address: address, Amount: amount})
+	return nil
+}
+
+// AddData will add an unspendable output that carries the data
+func (b *TxBuilder) AddData(data []byte) error {
+	if len(data) > script.MaxDataSize {
+		return script.ErrDataTooLarge
+	}
+
+	b.Data = data
+	return nil
+}
+
+// Total will return the amount paid to all the recipients
+func (b *TxBuilder) Total() int {
+	total := 0
+	for _, payment := range b.Payments {
+		total += payment.Amount
+	}
+
+	return total
+}
+
+// Build will select the coins, create the outputs and the change and
+// sign the transaction. The selection is returned to report the fee
+func (b *TxBuilder) Build() (*Transaction, *Selection, error) {
+	tx, sel, err := b.build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prevTxs, err := b.UTXOSet.BlockChain.findPrevTransactions(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx.Sign(b.Wallet.PrivateKey, prevTxs)
+	return tx, sel, nil
+}
+
+// BuildPartial will build the transaction without signing it, together
+// with the outputs it spends so every holder can sign it later
+func (b *TxBuilder) BuildPartial() (*PartialTx, *Selection, error) {
+	tx, sel, err := b.build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var prevOuts []TxOutput
+	for _, coin := range sel.Coins {
+		prevOuts = append(prevOuts, coin.Output)
+	}
+
+	return NewPartialTx(tx, prevOuts), sel, nil
+}
+
+// build will select the coins and create the unsigned transaction
+func (b *TxBuilder) build() (*Transaction, *Selection, error) {
+	if len(b.Payments) == 0 && b.Data == nil {
+		return nil, nil, ErrNoPayments
+	}
+
+	locking, err := LockingScript(b.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", b.From, ErrInvalidAddress)
+	}
+
+	coins, err := b.UTXOSet.FindSpendableCoins(locking)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coins = ExcludeCoins(coins, b.Options.Exclude)
+
+	selector := b.Options.Selector
+	if selector == nil {
+		selector = DefaultSelector()
+	}
+
+	outputCount := len(b.Payments)
+	if b.Data != nil {
+		outputCount++
+	}
+
+	target := Target{Amount: b.Total(), Outputs: outputCount, Fee: b.Options.Fee}
+	sel, err := selector.Select(coins, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(sel.Coins) == 0 {
+		return nil, nil, ErrNoInputs
+	}
+
+	sequence := b.Options.Sequence
+	if sequence == 0 {
+		sequence = SequenceFinal
+	}
+
+	var inputs []TxInput
+	for _, coin := range sel.Coins {
+		inputs = append(inputs, TxInput{ID: coin.TxID, Out: coin.Index, Script: nil, Sequence: sequence})
+	}
+
+	var outputs []TxOutput
+	for _, payment := range b.Payments {
+		outputs = append(outputs, *NewTXOutput(payment.Amount, payment.Address))
+	}
+
+	if b.Data != nil {
+		data, err := NewDataOutput(b.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		outputs = append(outputs, *data)
+	}
+
+	if sel.Change > 0 {
+		change := b.ChangeAddress
+		if change == "" {
+			change = b.From
+		}
+
+		outputs = append(outputs, *NewTXOutput(sel.Change, change))
+	}
+
+	tx := Transaction{ID: nil, Inputs: inputs, Outputs: outputs, LockTime: b.Options.LockTime}
+	tx.ID = tx.Hash()
+	return &tx, sel, nil
+}
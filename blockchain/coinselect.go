@@ -0,0 +1,383 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+coin selection
+
+a CoinSelector picks which unspent outputs pay for a transaction. Every
+strategy works over the same inputs: the candidate coins and a Target
+with the amount to pay, the number of payment outputs and the fee policy.
+The fee of a transaction grows with its inputs and outputs, so the
+selectors account for it while picking.
+*/
+
+// bnbMaxTries bounds the branch and bound search
+const bnbMaxTries = 100000
+
+var (
+	ErrInsufficientFunds = errors.New("not enough funds")
+	ErrNoExactMatch      = errors.New("no combination of coins matches the amount without change")
+	ErrWouldMixCoins     = errors.New("the amount can not be paid without merging coins from unrelated transactions")
+	ErrInvalidOutPoint   = errors.New("the outpoint must have the form <TXID>:<INDEX>")
+)
+
+type OutPoint struct {
+	TxID  []byte // represents the transaction that holds the output
+	Index int    // represents the index of the output in the transaction
+}
+
+type Coin struct {
+	OutPoint
+	Output TxOutput // represents the unspent output itself
+}
+
+type FeePolicy struct {
+	Base      int // represents the fixed fee of every transaction
+	PerInput  int // represents the fee of every input
+	PerOutput int // represents the fee of every output, change included
+}
+
+type Target struct {
+	Amount  int       // represents the amount paid to the recipients
+	Outputs int       // represents the number of payment outputs, change excluded
+	Fee     FeePolicy // represents how the fee of the transaction is computed
+}
+
+type Selection struct {
+	Coins  []Coin // represents the coins to spend
+	Total  int    // represents the value of the selected coins
+	Fee    int    // represents the fee left for the miner
+	Change int    // represents the value returned to the sender, 0 for no change output
+}
+
+type CoinSelector interface {
+	// Select will pick coins from the given ones to pay the target
+	Select(coins []Coin, target Target) (*Selection, error)
+}
+
+// String will return the <TXID>:<INDEX> form of the outpoint
+func (op OutPoint) String() string {
+	return fmt.Sprintf("%x:%d", op.TxID, op.Index)
+}
+
+// ParseOutPoint will parse an outpoint in the <TXID>:<INDEX> form
+func ParseOutPoint(str string) (OutPoint, error) {
+	parts := strings.Split(strings.TrimSpace(str), ":")
+	if len(parts) != 2 {
+		return OutPoint{}, ErrInvalidOutPoint
+	}
+
+	txID, err := hex.DecodeString(parts[0])
+	if err != nil || len(txID) == 0 {
+		return OutPoint{}, ErrInvalidOutPoint
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil || index < 0 {
+		return OutPoint{}, ErrInvalidOutPoint
+	}
+
+	return OutPoint{TxID: txID, Index: index}, nil
+}
+
+// Fee will return the fee of a transaction with the given inputs and outputs
+func (f FeePolicy) Fee(inputs, outputs int) int {
+	return f.Base + f.PerInput*inputs + f.PerOutput*outputs
+}
+
+// needed will return the value the given number of inputs must cover
+func (t Target) needed(inputs int) int {
+	return t.Amount + t.Fee.Fee(inputs, t.Outputs)
+}
+
+// newSelection will compute the fee and the change of spending the given
+// coins. Change that does not pay for its own output is left as fee
+func newSelection(coins []Coin, target Target) (*Selection, error) {
+	total := 0
+	for _, coin := range coins {
+		total += coin.Output.Value
+	}
+
+	if total < target.needed(len(coins)) {
+		return nil, ErrInsufficientFunds
+	}
+
+	sel := &Selection{Coins: coins, Total: total}
+	change := total - target.Amount - target.Fee.Fee(len(coins), target.Outputs+1)
+	if change > 0 {
+		sel.Change = change
+	}
+
+	sel.Fee = total - target.Amount - sel.Change
+	return sel, nil
+}
+
+// sortByValue will sort the coins from the largest to the smallest, ties
+// are broken by outpoint so the order is deterministic
+func sortByValue(coins []Coin) []Coin {
+	sorted := append([]Coin{}, coins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Output.Value != sorted[j].Output.Value {
+			return sorted[i].Output.Value > sorted[j].Output.Value
+		}
+
+		if c := bytes.Compare(sorted[i].TxID, sorted[j].TxID); c != 0 {
+			return c < 0
+		}
+
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	return sorted
+}
+
+// LargestFirst spends the largest coins first, it produces transactions
+// with few inputs but tends to leave the small coins behind
+type LargestFirst struct{}
+
+// Select will take coins from the largest until the target is covered
+func (LargestFirst) Select(coins []Coin, target Target) (*Selection, error) {
+	var picked []Coin
+	total := 0
+
+	for _, coin := range sortByValue(coins) {
+		picked = append(picked, coin)
+		total += coin.Output.Value
+
+		if total >= target.needed(len(picked)) {
+			return newSelection(picked, target)
+		}
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+// BranchAndBound searches for a set of coins that pays the target without
+// a change output. The excess over the target may not be larger than the
+// cost of the change output, which is left as fee instead
+type BranchAndBound struct {
+	MaxTries int // represents the bound of the search, 0 uses the default
+}
+
+// Select will search the combination of coins with the least excess
+func (b BranchAndBound) Select(coins []Coin, target Target) (*Selection, error) {
+	maxTries := b.MaxTries
+	if maxTries <= 0 {
+		maxTries = bnbMaxTries
+	}
+
+	// every coin is valued after paying for its own input
+	var pool []Coin
+	var values []int
+	available := 0
+	for _, coin := range sortByValue(coins) {
+		if value := coin.Output.Value - target.Fee.PerInput; value > 0 {
+			pool = append(pool, coin)
+			values = append(values, value)
+			available += value
+		}
+	}
+
+	goal := target.Amount + target.Fee.Fee(0, target.Outputs)
+	window := target.Fee.PerOutput
+	if available < goal {
+		return nil, ErrInsufficientFunds
+	}
+
+	var best []bool
+	bestExcess := -1
+	current := make([]bool, len(pool))
+	tries := 0
+
+	var search func(depth, value, remaining int) bool
+	search = func(depth, value, remaining int) bool {
+		tries++
+		if tries > maxTries || value > goal+window || value+remaining < goal {
+			return false
+		}
+
+		if value >= goal {
+			if excess := value - goal; bestExcess < 0 || excess < bestExcess {
+				bestExcess = excess
+				best = append([]bool{}, current...)
+			}
+
+			return bestExcess == 0
+		}
+
+		if depth == len(pool) {
+			return false
+		}
+
+		remaining -= values[depth]
+		current[depth] = true
+		if search(depth+1, value+values[depth], remaining) {
+			return true
+		}
+
+		current[depth] = false
+		return search(depth+1, value, remaining)
+	}
+
+	search(0, 0, available)
+	if best == nil {
+		return nil, ErrNoExactMatch
+	}
+
+	var picked []Coin
+	for i, used := range best {
+		if used {
+			picked = append(picked, pool[i])
+		}
+	}
+
+	return newSelection(picked, target)
+}
+
+// RandomImprove picks random coins until the target is covered and then
+// keeps adding random coins while the change gets closer to the amount
+// paid, so the wallet keeps coins of useful sizes around
+type RandomImprove struct {
+	Rand      *rand.Rand // represents the source of randomness, nil uses the clock
+	MaxInputs int        // represents the limit of inputs, 0 means no limit
+}
+
+// Select will pick random coins and then improve the selection
+func (r RandomImprove) Select(coins []Coin, target Target) (*Selection, error) {
+	rnd := r.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	shuffled := append([]Coin{}, coins...)
+	rnd.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	var picked []Coin
+	total := 0
+	next := 0
+
+	for ; next < len(shuffled) && total < target.needed(len(picked)); next++ {
+		picked = append(picked, shuffled[next])
+		total += shuffled[next].Output.Value
+	}
+
+	if total < target.needed(len(picked)) {
+		return nil, ErrInsufficientFunds
+	}
+
+	ideal := 2*target.Amount + target.Fee.Fee(len(picked), target.Outputs+1)
+	upper := 3*target.Amount + target.Fee.Fee(len(picked), target.Outputs+1)
+
+	for ; next < len(shuffled); next++ {
+		if r.MaxInputs > 0 && len(picked) >= r.MaxInputs {
+			break
+		}
+
+		value := shuffled[next].Output.Value - target.Fee.PerInput
+		if value <= 0 || total+value > upper || abs(ideal-(total+value)) >= abs(ideal-total) {
+			continue
+		}
+
+		picked = append(picked, shuffled[next])
+		total += shuffled[next].Output.Value
+	}
+
+	return newSelection(picked, target)
+}
+
+// NoMixing only spends coins that were received in the same transaction,
+// so a payment never links funds from unrelated senders. The group with
+// the smallest value that can pay the target is used
+type NoMixing struct {
+	Selector CoinSelector // represents the strategy used inside each group
+}
+
+// Select will try each group of related coins on its own
+func (n NoMixing) Select(coins []Coin, target Target) (*Selection, error) {
+	groups := make(map[string][]Coin)
+	totals := make(map[string]int)
+	var keys []string
+
+	for _, coin := range coins {
+		key := hex.EncodeToString(coin.TxID)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[key] = append(groups[key], coin)
+		totals[key] += coin.Output.Value
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return totals[keys[i]] < totals[keys[j]] })
+
+	for _, key := range keys {
+		if sel, err := n.Selector.Select(groups[key], target); err == nil {
+			return sel, nil
+		}
+	}
+
+	return nil, ErrWouldMixCoins
+}
+
+// Fallback tries each selector in order and returns the first selection found
+type Fallback []CoinSelector
+
+// Select will return the selection of the first selector that succeeds
+func (f Fallback) Select(coins []Coin, target Target) (*Selection, error) {
+	err := ErrInsufficientFunds
+	for _, selector := range f {
+		var sel *Selection
+		if sel, err = selector.Select(coins, target); err == nil {
+			return sel, nil
+		}
+	}
+
+	return nil, err
+}
+
+// DefaultSelector looks for a change free selection first and falls
+// back to random improve
+func DefaultSelector() CoinSelector {
+	return Fallback{BranchAndBound{}, RandomImprove{}}
+}
+
+// SelectorByName will return the strategy with the given name
+func SelectorByName(name string) (CoinSelector, error) {
+	switch name {
+	case "", "default":
+		return DefaultSelector(), nil
+
+	case "largest":
+		return LargestFirst{}, nil
+
+	case "bnb":
+		return BranchAndBound{}, nil
+
+	case "random":
+		return RandomImprove{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown coin selection strategy %q", name)
+}
+
+// abs will return the absolute value of the given number
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,187 @@
+package blockchain
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var testPolicy = FeePolicy{Base: 10, PerInput: 5, PerOutput: 3}
+
+// newCoin will create a coin of the given value in the given transaction
+func newCoin(tx byte, index, value int) Coin {
+	return Coin{
+		OutPoint: OutPoint{TxID: []byte{tx}, Index: index},
+		Output:   TxOutput{Value: value},
+	}
+}
+
+// values will return the values of the given coins in order
+func values(coins []Coin) []int {
+	var vals []int
+	for _, coin := range coins {
+		vals = append(vals, coin.Output.Value)
+	}
+
+	return vals
+}
+
+// checkSelection will check the selection spends each coin once, pays the
+// target and its fee covers the inputs and outputs it has
+func checkSelection(t *testing.T, sel *Selection, target Target) {
+	t.Helper()
+
+	seen := make(map[string]bool)
+	total := 0
+	for _, coin := range sel.Coins {
+		if seen[coin.String()] {
+			t.Fatalf("coin %s selected twice", coin)
+		}
+
+		seen[coin.String()] = true
+		total += coin.Output.Value
+	}
+
+	if total != sel.Total {
+		t.Fatalf("got total %d, the coins add up to %d", sel.Total, total)
+	}
+
+	if sel.Total != target.Amount+sel.Fee+sel.Change {
+		t.Fatalf("the total %d is not the amount %d plus the fee %d and the change %d", sel.Total, target.Amount, sel.Fee, sel.Change)
+	}
+
+	outputs := target.Outputs
+	if sel.Change > 0 {
+		outputs++
+	}
+
+	if needed := target.Fee.Fee(len(sel.Coins), outputs); sel.Fee < needed {
+		t.Fatalf("got fee %d, the transaction needs %d", sel.Fee, needed)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	coins := []Coin{newCoin(1, 0, 20), newCoin(2, 0, 100), newCoin(3, 0, 10), newCoin(4, 0, 50), newCoin(5, 0, 30)}
+	related := []Coin{newCoin(1, 0, 60), newCoin(1, 1, 60), newCoin(2, 0, 100)}
+
+	tests := []struct {
+		name     string
+		selector CoinSelector
+		coins    []Coin
+		amount   int
+		values   []int
+		fee      int
+		change   int
+		err      error
+	}{
+		{"largest first", LargestFirst{}, coins, 120, []int{100, 50}, 26, 4, nil},
+		{"largest first one coin", LargestFirst{}, coins, 58, []int{100}, 21, 21, nil},
+		{"largest first insufficient", LargestFirst{}, coins, 200, nil, 0, 0, ErrInsufficientFunds},
+		{"largest first no coins", LargestFirst{}, nil, 1, nil, 0, 0, ErrInsufficientFunds},
+		{"branch and bound exact", BranchAndBound{}, coins, 57, []int{50, 30}, 23, 0, nil},
+		// the excess is less than a change output, it is left as fee
+		{"branch and bound excess", BranchAndBound{}, coins, 55, []int{50, 30}, 25, 0, nil},
+		{"branch and bound no match", BranchAndBound{}, coins, 58, nil, 0, 0, ErrNoExactMatch},
+		{"branch and bound out of tries", BranchAndBound{MaxTries: 1}, coins, 57, nil, 0, 0, ErrNoExactMatch},
+		{"branch and bound insufficient", BranchAndBound{}, coins, 200, nil, 0, 0, ErrInsufficientFunds},
+		{"fallback", Fallback{BranchAndBound{}, LargestFirst{}}, coins, 58, []int{100}, 21, 21, nil},
+		{"fallback first", Fallback{BranchAndBound{}, LargestFirst{}}, coins, 57, []int{50, 30}, 23, 0, nil},
+		{"fallback empty", Fallback{}, coins, 1, nil, 0, 0, ErrInsufficientFunds},
+		{"no mixing", NoMixing{Selector: LargestFirst{}}, related, 90, []int{60, 60}, 26, 4, nil},
+		{"no mixing smallest group", NoMixing{Selector: LargestFirst{}}, related, 50, []int{100}, 21, 29, nil},
+		{"no mixing would mix", NoMixing{Selector: LargestFirst{}}, related, 100, nil, 0, 0, ErrWouldMixCoins},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := Target{Amount: test.amount, Outputs: 1, Fee: testPolicy}
+			sel, err := test.selector.Select(test.coins, target)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			checkSelection(t, sel, target)
+			if !reflect.DeepEqual(values(sel.Coins), test.values) {
+				t.Fatalf("got coins %v, want %v", values(sel.Coins), test.values)
+			}
+
+			if sel.Fee != test.fee || sel.Change != test.change {
+				t.Fatalf("got fee %d and change %d, want %d and %d", sel.Fee, sel.Change, test.fee, test.change)
+			}
+		})
+	}
+}
+
+func TestRandomImprove(t *testing.T) {
+	var coins []Coin
+	for i := 0; i < 20; i++ {
+		coins = append(coins, newCoin(byte(i), 0, 10*(i+1)))
+	}
+
+	target := Target{Amount: 150, Outputs: 1, Fee: testPolicy}
+	for seed := int64(0); seed < 50; seed++ {
+		sel, err := RandomImprove{Rand: rand.New(rand.NewSource(seed))}.Select(coins, target)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		checkSelection(t, sel, target)
+	}
+
+	if _, err := (RandomImprove{}).Select(coins[:2], target); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("got error %v, want %v", err, ErrInsufficientFunds)
+	}
+
+	// any coin pays the target, a second one moves the change closer to it
+	large := []Coin{newCoin(1, 0, 200), newCoin(2, 0, 200), newCoin(3, 0, 200)}
+	for maxInputs, want := range []int{2, 1} {
+		sel, err := RandomImprove{Rand: rand.New(rand.NewSource(1)), MaxInputs: maxInputs}.Select(large, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sel.Coins) != want {
+			t.Fatalf("got %d inputs with a limit of %d, want %d", len(sel.Coins), maxInputs, want)
+		}
+	}
+}
+
+func TestParseOutPoint(t *testing.T) {
+	tests := []struct {
+		str      string
+		outPoint OutPoint
+		err      error
+	}{
+		{"0a0b:1", OutPoint{TxID: []byte{0x0a, 0x0b}, Index: 1}, nil},
+		{" 0a0b:0\n", OutPoint{TxID: []byte{0x0a, 0x0b}, Index: 0}, nil},
+		{"0a0b", OutPoint{}, ErrInvalidOutPoint},
+		{":1", OutPoint{}, ErrInvalidOutPoint},
+		{"zz:1", OutPoint{}, ErrInvalidOutPoint},
+		{"0a0b:-1", OutPoint{}, ErrInvalidOutPoint},
+		{"0a0b:1:2", OutPoint{}, ErrInvalidOutPoint},
+	}
+
+	for _, test := range tests {
+		outPoint, err := ParseOutPoint(test.str)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%q: got error %v, want %v", test.str, err, test.err)
+		}
+
+		if !reflect.DeepEqual(outPoint, test.outPoint) {
+			t.Fatalf("%q: got %s, want %s", test.str, outPoint, test.outPoint)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if back, err := ParseOutPoint(outPoint.String()); err != nil || !reflect.DeepEqual(back, outPoint) {
+			t.Fatalf("%q: got %s back from %s", test.str, back, outPoint)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+var (
+	ErrGenesisHash = errors.New("the genesis block does not match the chain parameters")
+	ErrCheckpoint  = errors.New("the block does not match the checkpoint of its height")
+	ErrBits        = errors.New("the block does not have the expected difficulty")
+	ErrProofOfWork = errors.New("the hash of the block does not meet its difficulty")
+	ErrImmature    = errors.New("the input spends a coinbase that has not matured")
+	ErrOrphanBlock = errors.New("the parent of the block is not known")
+	ErrBlockHeight = errors.New("the height of the block does not follow its parent")
+	ErrCoinbase    = errors.New("the coinbase claims more than the subsidy and the fees")
+)
+
+// genesisTemplate will build the genesis block of the spec with the nonce
+// of the spec, without checking its proof of work
+func genesisTemplate(spec params.GenesisSpec) (*Block, error) {
+	out, err := NewDataOutput(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Address != "" {
+		if !wallet.ValidateAddress(spec.Address) {
+			return nil, fmt.Errorf("%s: %w", spec.Address, ErrInvalidAddress)
+		}
+
+		out = NewTXOutput(params.Active.Subsidy, spec.Address)
+	}
+
+	txin := TxInput{ID: []byte{}, Out: -1, Script: script.NewBuilder().AddData([]byte(spec.Message)).Script(), Sequence: SequenceFinal}
+	coinbase := &Transaction{Inputs: []TxInput{txin}, Outputs: []TxOutput{*out}}
+	coinbase.ID = coinbase.Hash()
+
+	block := &Block{
+		TimeStamp:    spec.Timestamp,
+		Transactions: []*Transaction{coinbase},
+		PrevHash:     []byte{},
+		Nonce:        spec.Nonce,
+		Heigth:       0,
+		Bits:         params.Active.PowLimitBits,
+	}
+
+	hash := sha256.Sum256(block.Header(block.Nonce))
+	block.Hash = hash[:]
+	return block, nil
+}
+
+// GenesisBlock will build the genesis block of the active network, every
+// node of the network gets the same block
+func GenesisBlock() (*Block, error) {
+	spec := params.Active.Genesis
+	block, err := genesisTemplate(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if hex.EncodeToString(block.Hash) != spec.Hash || !NewProof(block).Validate() {
+		return nil, ErrGenesisHash
+	}
+
+	return block, nil
+}
+
+// MineGenesis will solve the proof of work of the genesis block of the
+// spec for the active network, and return the spec with its nonce and hash
+func MineGenesis(spec params.GenesisSpec) (params.GenesisSpec, *Block, error) {
+	block, err := genesisTemplate(spec)
+	if err != nil {
+		return spec, nil, err
+	}
+
+	block.Nonce, block.Hash = NewProof(block).Run()
+	spec.Nonce = block.Nonce
+	spec.Hash = hex.EncodeToString(block.Hash)
+	return spec, block, nil
+}
+
+// NextBits will return the difficulty of the block after the given one.
+// Every RetargetInterval blocks it gets one bit harder when the blocks
+// came more than twice as fast as the target spacing and one bit easier
+// when they came more than twice as slow, never easier than the limit
+func (chain *BlockChain) NextBits(prev *Block) (uint32, error) {
+	p := params.Active
+	if p.NoRetargeting {
+		return p.PowLimitBits, nil
+	}
+
+	if (prev.Heigth+1)%p.RetargetInterval != 0 {
+		return prev.Bits, nil
+	}
+
+	first := *prev
+	for i := 1; i < p.RetargetInterval; i++ {
+		header, err := chain.GetHeader(first.PrevHash)
+		if err != nil {
+			return 0, err
+		}
+
+		first = header
+	}
+
+	bits := prev.Bits
+	timespan := prev.TimeStamp - first.TimeStamp
+	wanted := int64(p.RetargetInterval) * p.TargetSpacing
+
+	if timespan < wanted/2 {
+		bits++
+	} else if timespan > wanted*2 && bits > p.PowLimitBits {
+		bits--
+	}
+
+	return bits, nil
+}
+
+// CheckBlock will check the proof of work of a block received from a
+// peer, the checkpoint of its height and, for every block but the
+// genesis, that its parent is known and that it has the height and the
+// difficulty that follow the parent
+func (chain *BlockChain) CheckBlock(block *Block) error {
+	if !NewProof(block).Validate() {
+		return ErrProofOfWork
+	}
+
+	if checkpoint, ok := params.Active.Checkpoints[block.Heigth]; ok && checkpoint != hex.EncodeToString(block.Hash) {
+		return ErrCheckpoint
+	}
+
+	if len(block.PrevHash) == 0 {
+		if hex.EncodeToString(block.Hash) != params.Active.Genesis.Hash {
+			return ErrGenesisHash
+		}
+
+		return nil
+	}
+
+	parent, err := chain.GetHeader(block.PrevHash)
+	if err != nil {
+		return ErrOrphanBlock
+	}
+
+	if block.Heigth != parent.Heigth+1 {
+		return ErrBlockHeight
+	}
+
+	bits, err := chain.NextBits(&parent)
+	if err != nil {
+		return err
+	}
+
+	if block.Bits != bits {
+		return ErrBits
+	}
+
+	return nil
+}
+
+// checkTransactions will check the transactions of a block that extends
+// the tip: their inputs, their locks and the value of the coinbase, which
+// can only claim the subsidy and the fees
+func (chain *BlockChain) checkTransactions(block *Block) error {
+	if !chain.VerifyTransactions(block.Transactions) {
+		return ErrInvalidTx
+	}
+
+	medianTime := chain.MedianTimePast(block.PrevHash)
+	var claimed int64
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinBase() {
+			if err := chain.CheckLocks(tx, block.Heigth, medianTime); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, out := range tx.Outputs {
+			var err error
+			if claimed, err = addValue(claimed, out.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if claimed > int64(params.Active.Subsidy)+int64(chain.TransactionFees(block.Transactions)) {
+		return ErrCoinbase
+	}
+
+	return nil
+}
+
+// checkBranch will check the transactions of the blocks of another branch
+// when the given block makes it longer than the best chain. Every block
+// from the fork up is checked against the chain its parent ends, so the
+// outputs and the locks are the ones of the branch
+func (chain *BlockChain) checkBranch(block *Block) error {
+	best, err := chain.BestHeight()
+	if err != nil || block.Heigth <= best {
+		return err
+	}
+
+	branch := []*Block{block}
+	for {
+		parent, err := chain.GetBlock(branch[len(branch)-1].PrevHash)
+		if err != nil {
+			return err
+		}
+
+		hash, err := chain.BlockHash(parent.Heigth)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(hash, parent.Hash) {
+			break // this is the fork
+		}
+
+		branch = append(branch, &parent)
+	}
+
+	for i := len(branch) - 1; i >= 0; i-- {
+		view := &BlockChain{LastHash: branch[i].PrevHash, Store: chain.Store}
+		if err := view.checkTransactions(branch[i]); err != nil {
+			return fmt.Errorf("block %x of the branch: %w", branch[i].Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// checkMaturity will check that the inputs of the transaction do not spend
+// a coinbase with fewer confirmations than the maturity of the network in
+// a block at the given height
+func (chain *BlockChain) checkMaturity(tx *Transaction, height int) error {
+	maturity := params.Active.CoinbaseMaturity
+	if maturity == 0 || tx.IsCoinBase() {
+		return nil
+	}
+
+	for inId, in := range tx.Inputs {
+		block, err := chain.TxBlock(in.ID)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", inId, err)
+		}
+
+		for _, prevTx := range block.Transactions {
+			if bytes.Equal(prevTx.ID, in.ID) && prevTx.IsCoinBase() && block.Heigth+maturity > height {
+				return fmt.Errorf("input %d: %w", inId, ErrImmature)
+			}
+		}
+	}
+
+	return nil
+}
+
+// immatureCoinbases will return the hex ids of the coinbases that can not
+// be spent in the next block yet
+func (chain *BlockChain) immatureCoinbases() (map[string]bool, error) {
+	immature := make(map[string]bool)
+	maturity := params.Active.CoinbaseMaturity
+	if maturity == 0 {
+		return immature, nil
+	}
+
+	iter := chain.Iterator()
+	next := -1
+	for {
+		block, err := iter.NextBlock()
+		if err != nil {
+			return nil, err
+		}
+		if next < 0 {
+			next = block.Heigth + 1
+		}
+
+		if block.Heigth+maturity <= next {
+			break
+		}
+
+		for _, tx := range block.Transactions {
+			if tx.IsCoinBase() {
+				immature[hex.EncodeToString(tx.ID)] = true
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break // this is the genesis
+		}
+	}
+
+	return immature, nil
+}
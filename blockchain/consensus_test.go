@@ -0,0 +1,205 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// newTestChain will start a regtest chain in memory, its proof of work is
+// trivial
+func newTestChain(t *testing.T) *BlockChain {
+	t.Helper()
+
+	active := params.Active
+	params.Active = &params.Regtest
+	t.Cleanup(func() { params.Active = active })
+
+	chain, err := NewBlockChain(NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain
+}
+
+func TestAddBlock(t *testing.T) {
+	miner := newTestChain(t)
+	address := string(wallet.MakeWallet().Address())
+	blocks, err := miner.Generate(3, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := NewBlockChain(NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.AddBlock(blocks[1]); !errors.Is(err, ErrOrphanBlock) {
+		t.Fatalf("got error %v for an orphan, want %v", err, ErrOrphanBlock)
+	}
+
+	for _, block := range blocks {
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("block %d: %v", block.Heigth, err)
+		}
+	}
+
+	if !bytes.Equal(chain.LastHash, miner.LastHash) {
+		t.Fatalf("got tip %x, want %x", chain.LastHash, miner.LastHash)
+	}
+}
+
+func TestAddBlockRejects(t *testing.T) {
+	chain := newTestChain(t)
+	address := string(wallet.MakeWallet().Address())
+	genesis := chain.LastHash
+	bits := params.Active.PowLimitBits
+
+	tests := []struct {
+		name  string
+		block *Block
+		err   error
+	}{
+		{"another genesis", CreateBlock([]*Transaction{CoinbaseTx(address, "")}, []byte{}, 0, bits), ErrCheckpoint},
+		{"wrong height", CreateBlock([]*Transaction{CoinbaseTx(address, "")}, genesis, 2, bits), ErrBlockHeight},
+		{"wrong difficulty", CreateBlock([]*Transaction{CoinbaseTx(address, "")}, genesis, 1, bits+1), ErrBits},
+		{"coinbase claims fees", CreateBlock([]*Transaction{CoinbaseTxWithFees(address, "", 1)}, genesis, 1, bits), ErrCoinbase},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := chain.AddBlock(test.block); !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if !bytes.Equal(chain.LastHash, genesis) {
+				t.Fatal("the rejected block became the tip")
+			}
+		})
+	}
+
+	unsolved := CreateBlock([]*Transaction{CoinbaseTx(address, "")}, genesis, 1, bits)
+	for NewProof(unsolved).Validate() {
+		unsolved.Nonce++
+	}
+
+	if err := chain.AddBlock(unsolved); !errors.Is(err, ErrProofOfWork) {
+		t.Fatalf("got error %v for an unsolved block, want %v", err, ErrProofOfWork)
+	}
+
+	tip, err := chain.Generate(1, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid block of a branch is kept while the branch is not longer
+	invalid := CreateBlock([]*Transaction{CoinbaseTxWithFees(address, "a", 1000000)}, genesis, 1, bits)
+	if err := chain.AddBlock(invalid); err != nil {
+		t.Fatalf("got error %v for a block of a shorter branch", err)
+	}
+
+	longer := CreateBlock([]*Transaction{CoinbaseTx(address, "b")}, invalid.Hash, 2, bits)
+	if err := chain.AddBlock(longer); !errors.Is(err, ErrCoinbase) {
+		t.Fatalf("got error %v for a longer branch with an invalid block, want %v", err, ErrCoinbase)
+	}
+
+	if !bytes.Equal(chain.LastHash, tip[0].Hash) {
+		t.Fatal("the invalid branch became the best chain")
+	}
+}
+
+func TestBlockHash(t *testing.T) {
+	chain := newTestChain(t)
+	short, err := chain.Generate(2, string(wallet.MakeWallet().Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	miner, err := NewBlockChain(NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long, err := miner.Generate(3, string(wallet.MakeWallet().Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(blocks []*Block) {
+		t.Helper()
+		for _, block := range blocks {
+			hash, err := chain.BlockHash(block.Heigth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(hash, block.Hash) {
+				t.Fatalf("height %d: got %x, want %x", block.Heigth, hash, block.Hash)
+			}
+		}
+
+		if _, err := chain.BlockHash(len(blocks) + 1); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got error %v past the tip, want %v", err, ErrNotFound)
+		}
+	}
+
+	check(short)
+
+	// the longer branch replaces the indexed blocks when it becomes the tip
+	for _, block := range long {
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("block %d: %v", block.Heigth, err)
+		}
+	}
+
+	check(long)
+}
+
+func TestAddBlockDoubleSpend(t *testing.T) {
+	chain := newTestChain(t)
+	w := wallet.MakeWallet()
+	address := string(w.Address())
+	bits := params.Active.PowLimitBits
+
+	blocks, err := chain.Generate(params.Active.CoinbaseMaturity, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spend := func(value int) *Transaction {
+		coinbase := blocks[0].Transactions[0]
+		tx := &Transaction{
+			Inputs:  []TxInput{{ID: coinbase.ID, Out: 0, Sequence: SequenceFinal}},
+			Outputs: []TxOutput{*NewTXOutput(value, address)},
+		}
+
+		tx.ID = tx.Hash()
+		chain.SingTransaction(tx, w.PrivateKey)
+		return tx
+	}
+
+	first := spend(params.Active.Subsidy)
+	if !chain.VerifyTransaction(first) {
+		t.Fatal("the spend of a mature coinbase does not verify")
+	}
+
+	block := CreateBlock([]*Transaction{first, CoinbaseTx(address, "")}, chain.LastHash, len(blocks)+1, bits)
+	if err := chain.AddBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	second := spend(params.Active.Subsidy - 1)
+	if chain.VerifyTransaction(second) {
+		t.Fatal("the second spend of the coinbase verifies")
+	}
+
+	block = CreateBlock([]*Transaction{second, CoinbaseTx(address, "")}, chain.LastHash, len(blocks)+2, bits)
+	if err := chain.AddBlock(block); !errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("got error %v for a block that spends a spent output, want %v", err, ErrInvalidTx)
+	}
+}
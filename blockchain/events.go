@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/Haizza1/go-block/events"
+)
+
+// TxAddresses will return the addresses paid by the outputs of the
+// transaction and the ones of the outputs it spends that are still in the
+// unspent set
+func (chain *BlockChain) TxAddresses(tx *Transaction) []string {
+	seen := make(map[string]bool)
+	var addresses []string
+	add := func(out TxOutput) {
+		if address := out.Address(); address != "" && !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	if !tx.IsCoinBase() {
+		UTXOSet := UTXOSet{chain}
+		for _, in := range tx.Inputs {
+			if out, ok, err := UTXOSet.UnspentOutput(OutPoint{TxID: in.ID, Index: in.Out}); err == nil && ok {
+				add(out)
+			}
+		}
+	}
+
+	for _, out := range tx.Outputs {
+		add(out)
+	}
+
+	return addresses
+}
+
+// blockEvent will build the event of a block with the addresses of its
+// transactions
+func (chain *BlockChain) blockEvent(topic events.Topic, block *Block) events.Event {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		for _, address := range chain.TxAddresses(tx) {
+			if !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	return events.Event{Topic: topic, Hash: hex.EncodeToString(block.Hash), Height: block.Heigth, Addresses: addresses}
+}
+
+// notifyTip will publish the blocks that left and joined the best chain
+// when its tip moved from oldTip to the new block, the disconnected ones
+// from the old tip down and the connected ones up to the new tip
+func (chain *BlockChain) notifyTip(oldTip []byte, tip *Block) {
+	if chain.Events == nil {
+		return
+	}
+
+	connected := []*Block{tip}
+	old, err := chain.GetBlock(oldTip)
+	if err != nil {
+		return
+	}
+
+	side := tip
+	for !bytes.Equal(side.PrevHash, old.Hash) {
+		if len(side.PrevHash) == 0 {
+			return
+		}
+
+		if old.Heigth >= side.Heigth-1 {
+			chain.Events.Publish(chain.blockEvent(events.BlockDisconnected, &old))
+			if old, err = chain.GetBlock(old.PrevHash); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		prev, err := chain.GetBlock(side.PrevHash)
+		if err != nil {
+			return
+		}
+
+		side = &prev
+		connected = append(connected, side)
+	}
+
+	for i := len(connected) - 1; i >= 0; i-- {
+		chain.Events.Publish(chain.blockEvent(events.BlockConnected, connected[i]))
+	}
+}
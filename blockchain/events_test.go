@@ -0,0 +1,71 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/Haizza1/go-block/events"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// TestReorgEvents adds a longer branch to a chain through AddBlock, the
+// blocks of the old branch are disconnected from its tip down and the
+// ones of the new branch connected up to its tip
+func TestReorgEvents(t *testing.T) {
+	chain := newTestChain(t)
+	old, err := chain.Generate(2, string(wallet.MakeWallet().Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	miner, err := NewBlockChain(NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch, err := miner.Generate(3, string(wallet.MakeWallet().Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.Events = events.NewBus()
+	sub := chain.Events.Subscribe(events.Filter{})
+	defer sub.Close()
+
+	// the branch is as long as the chain, it does not move the tip yet
+	for _, block := range branch[:2] {
+		if err := chain.AddBlock(block); err != nil {
+			t.Fatalf("block %d: %v", block.Heigth, err)
+		}
+	}
+
+	if len(sub.C) != 0 {
+		t.Fatalf("got %d events before the branch is longer", len(sub.C))
+	}
+
+	if err := chain.AddBlock(branch[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		topic events.Topic
+		block *Block
+	}{
+		{events.BlockDisconnected, old[1]},
+		{events.BlockDisconnected, old[0]},
+		{events.BlockConnected, branch[0]},
+		{events.BlockConnected, branch[1]},
+		{events.BlockConnected, branch[2]},
+	}
+
+	if len(sub.C) != len(want) {
+		t.Fatalf("got %d events, want %d", len(sub.C), len(want))
+	}
+
+	for _, w := range want {
+		e := <-sub.C
+		if e.Topic != w.topic || e.Hash != hex.EncodeToString(w.block.Hash) || e.Height != w.block.Heigth {
+			t.Fatalf("got %s of %s at %d, want %s of %x at %d", e.Topic, e.Hash, e.Height, w.topic, w.block.Hash, w.block.Heigth)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+var (
+	ErrNotContract     = errors.New("the script is not a hash time locked contract")
+	ErrNoContractCoins = errors.New("the contract has no unspent coins")
+	ErrWrongSecret     = errors.New("the secret does not match the hash of the contract")
+	ErrSecretNotFound  = errors.New("the contract has not been redeemed")
+	ErrFeeTooHigh      = errors.New("the fee is higher than the value of the contract")
+)
+
+// NewSecret will generate the secret of a swap and its hash
+func NewSecret() ([]byte, []byte) {
+	secret := make([]byte, script.SecretSize)
+	_, err := rand.Read(secret)
+	CheckError(err)
+
+	hash := sha256.Sum256(secret)
+	return secret, hash[:]
+}
+
+// ContractAddress will return the pay to script hash address of the contract
+func ContractAddress(contract script.HTLC) string {
+	return string(wallet.ScriptAddress(contract.Script()))
+}
+
+// NewContractSpend will create the partial transaction that moves every
+// coin of the contract to the address. With the secret the recipient
+// redeems it, without it the sender refunds it once the lock time passed
+func NewContractSpend(utxo *UTXOSet, redeem, secret []byte, to string, fee int) (*PartialTx, error) {
+	contract, ok := script.ExtractHTLC(redeem)
+	if !ok {
+		return nil, ErrNotContract
+	}
+
+	if secret != nil {
+		hash := sha256.Sum256(secret)
+		if !bytes.Equal(hash[:], contract.SecretHash) {
+			return nil, ErrWrongSecret
+		}
+	}
+
+	if !wallet.ValidateAddress(to) {
+		return nil, fmt.Errorf("%s: %w", to, ErrInvalidAddress)
+	}
+
+	coins := utxo.SpendableCoins(script.PayToScriptHash(wallet.PublicKeyHash(redeem)))
+	if len(coins) == 0 {
+		return nil, ErrNoContractCoins
+	}
+
+	tx := Transaction{}
+	if secret == nil {
+		tx.LockTime = contract.LockTime
+	}
+
+	total := 0
+	var prevOuts []TxOutput
+	for _, coin := range coins {
+		tx.Inputs = append(tx.Inputs, TxInput{ID: coin.TxID, Out: coin.Index, Sequence: SequenceFinal})
+		prevOuts = append(prevOuts, coin.Output)
+		total += coin.Output.Value
+	}
+
+	if fee >= total {
+		return nil, ErrFeeTooHigh
+	}
+
+	tx.Outputs = append(tx.Outputs, *NewTXOutput(total-fee, to))
+	tx.ID = tx.Hash()
+
+	ptx := NewPartialTx(&tx, prevOuts)
+	ptx.AddRedeemScript(redeem)
+	for i := range ptx.Inputs {
+		ptx.Inputs[i].Secret = secret
+	}
+
+	return ptx, nil
+}
+
+// FindContractSecret will search the blockchain for the transaction that
+// redeemed the contract and return the secret it revealed
+func (chain *BlockChain) FindContractSecret(redeem []byte) ([]byte, error) {
+	iter := chain.Iterator()
+	for {
+		block := iter.Next()
+		for _, tx := range block.Transactions {
+			for _, in := range tx.Inputs {
+				if secret, ok := script.ExtractHTLCSecret(in.Script, redeem); ok {
+					return secret, nil
+				}
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	return nil, ErrSecretNotFound
+}
@@ -0,0 +1,181 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	LockTimeThreshold    = 500000000          // lock times below are heights, the rest unix times
+	SequenceFinal        = uint32(0xffffffff) // the input has no relative lock
+	SequenceLockDisabled = uint32(1 << 31)    // the relative lock of the input is off
+	SequenceLockTime     = uint32(1 << 22)    // the relative lock counts time instead of blocks
+	SequenceLockMask     = uint32(0x0000ffff) // the bits of the relative lock value
+	SequenceGranularity  = 9                  // relative time locks count units of 512 seconds
+	medianTimeBlocks     = 11                 // blocks used for the median time past
+)
+
+var (
+	ErrNonFinal     = errors.New("the lock time of the transaction has not been reached")
+	ErrSequenceLock = errors.New("the relative lock of an input has not been reached")
+)
+
+// RelativeBlocks will return the sequence that locks an input until the
+// output it spends has the given number of confirmations
+func RelativeBlocks(blocks int) uint32 {
+	return uint32(blocks) & SequenceLockMask
+}
+
+// RelativeSeconds will return the sequence that locks an input until the
+// given seconds passed since the output it spends was mined, rounded up
+// to units of 512 seconds
+func RelativeSeconds(seconds int64) uint32 {
+	units := (seconds + (1 << SequenceGranularity) - 1) >> SequenceGranularity
+	return SequenceLockTime | uint32(units)&SequenceLockMask
+}
+
+// IsFinal will check if the transaction may be included in a block at
+// the given height whose median time past is the given one
+func (tx *Transaction) IsFinal(height int, medianTime int64) bool {
+	if tx.LockTime == 0 {
+		return true
+	}
+
+	if tx.LockTime < LockTimeThreshold {
+		return tx.LockTime < int64(height)
+	}
+
+	return tx.LockTime < medianTime
+}
+
+// MedianTimePast will return the median timestamp of the block with the
+// given hash and the blocks before it, block times can go backwards but
+// the median never does
+func (chain *BlockChain) MedianTimePast(hash []byte) int64 {
+	var times []int64
+	for len(hash) > 0 && len(times) < medianTimeBlocks {
+		block, err := chain.GetHeader(hash)
+		if err != nil {
+			break
+		}
+
+		times = append(times, block.TimeStamp)
+		hash = block.PrevHash
+	}
+
+	if len(times) == 0 {
+		return 0
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// CheckLocks will check the lock time, the relative locks and the
+// maturity of the coinbases spent by the transaction for a block at the
+// given height and median time past
+func (chain *BlockChain) CheckLocks(tx *Transaction, height int, medianTime int64) error {
+	if tx.IsCoinBase() {
+		return nil
+	}
+
+	if err := chain.checkMaturity(tx, height); err != nil {
+		return err
+	}
+
+	if !tx.IsFinal(height, medianTime) {
+		return ErrNonFinal
+	}
+
+	for inId, in := range tx.Inputs {
+		value := int64(in.Sequence & SequenceLockMask)
+		if in.Sequence&SequenceLockDisabled != 0 || value == 0 {
+			continue
+		}
+
+		block, err := chain.TxBlock(in.ID)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", inId, err)
+		}
+
+		if in.Sequence&SequenceLockTime != 0 {
+			coinTime := block.TimeStamp
+			if len(block.PrevHash) > 0 {
+				coinTime = chain.MedianTimePast(block.PrevHash)
+			}
+
+			if coinTime+value<<SequenceGranularity > medianTime {
+				return fmt.Errorf("input %d: %w", inId, ErrSequenceLock)
+			}
+		} else if block.Heigth+int(value) > height {
+			return fmt.Errorf("input %d: %w", inId, ErrSequenceLock)
+		}
+	}
+
+	return nil
+}
+
+// CheckTxLocks will check if the transaction may be included in the next block
+func (chain *BlockChain) CheckTxLocks(tx *Transaction) error {
+	tip, err := chain.GetHeader(chain.LastHash)
+	if err != nil {
+		return err
+	}
+
+	return chain.CheckLocks(tx, tip.Heigth+1, chain.MedianTimePast(tip.Hash))
+}
+
+// TxBlock will find the block that holds the transaction with the given id
+func (chain *BlockChain) TxBlock(ID []byte) (*Block, error) {
+	iter := chain.Iterator()
+	for {
+		block, err := iter.NextBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range block.Transactions {
+			if bytes.Equal(tx.ID, ID) {
+				return block, nil
+			}
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+	}
+
+	return nil, errors.New("the output is not confirmed")
+}
+
+// CheckLockTime will check that the lock time of the transaction is of
+// the same kind and reached the lock time required by the script
+func (c *txSigChecker) CheckLockTime(lockTime int64) bool {
+	if (lockTime < LockTimeThreshold) != (c.tx.LockTime < LockTimeThreshold) {
+		return false
+	}
+
+	return lockTime <= c.tx.LockTime
+}
+
+// CheckSequence will check that the relative lock of the input is of the
+// same kind and reached the relative lock required by the script
+func (c *txSigChecker) CheckSequence(sequence int64) bool {
+	required := uint32(sequence)
+	if required&SequenceLockDisabled != 0 {
+		return true
+	}
+
+	actual := c.tx.Inputs[c.inId].Sequence
+	if actual&SequenceLockDisabled != 0 {
+		return false
+	}
+
+	if required&SequenceLockTime != actual&SequenceLockTime {
+		return false
+	}
+
+	return required&SequenceLockMask <= actual&SequenceLockMask
+}
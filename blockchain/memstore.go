@@ -0,0 +1,139 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrReadOnly = errors.New("store: the transaction is read only")
+
+// memoryStore keeps the chain in memory, it is lost when the process
+// exits. It is meant for tests and short lived chains
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// memoryTxn reads the store and keeps its writes aside until the update
+// finishes, a nil value marks a deleted key
+type memoryTxn struct {
+	store    *memoryStore
+	writes   map[string][]byte
+	readOnly bool
+}
+
+// NewMemoryStore will create an empty chain store held in memory
+func NewMemoryStore() ChainStore {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+// View will run fn while holding the store for reading
+func (s *memoryStore) View(fn func(txn StoreTxn) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(storeTxn{&memoryTxn{store: s, readOnly: true}})
+}
+
+// Update will run fn while holding the store for writing and apply its
+// writes when it succeeds
+func (s *memoryStore) Update(fn func(txn StoreTxn) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txn := &memoryTxn{store: s, writes: make(map[string][]byte)}
+	if err := fn(storeTxn{txn}); err != nil {
+		return err
+	}
+
+	for key, value := range txn.writes {
+		if value == nil {
+			delete(s.data, key)
+		} else {
+			s.data[key] = value
+		}
+	}
+
+	return nil
+}
+
+// ClearUnspent will remove every key of the utxo set
+func (s *memoryStore) ClearUnspent() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.data {
+		if bytes.HasPrefix([]byte(key), utxoPrefix) {
+			delete(s.data, key)
+		}
+	}
+
+	return nil
+}
+
+// Close will drop the data of the store
+func (s *memoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string][]byte)
+	return nil
+}
+
+func (t *memoryTxn) get(key []byte) ([]byte, error) {
+	value, ok := t.writes[string(key)]
+	if !ok {
+		value, ok = t.store.data[string(key)]
+	}
+
+	if !ok || value == nil {
+		return nil, ErrNotFound
+	}
+
+	return append([]byte{}, value...), nil
+}
+
+func (t *memoryTxn) set(key, value []byte) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
+	t.writes[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (t *memoryTxn) delete(key []byte) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
+	t.writes[string(key)] = nil
+	return nil
+}
+
+func (t *memoryTxn) iterate(prefix []byte, fn func(key, value []byte) error) error {
+	var keys []string
+	for key := range t.store.data {
+		if _, ok := t.writes[key]; !ok && bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	for key, value := range t.writes {
+		if value != nil && bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, _ := t.get([]byte(key))
+		if err := fn([]byte(key), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wire"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// formatKey holds the version of the encoding of the stored blocks, the
+// databases created before the canonical encoding do not have it
+var formatKey = []byte("format")
+
+// dbFormat is the current format: 0x01 hashed the whole transaction into
+// its id, 0x02 leaves the signatures out of it
+const dbFormat = byte(0x02)
+
+// legacyBits is the difficulty every block had before it was a chain parameter
+const legacyBits = uint32(12)
+
+var ErrNewerFormat = errors.New("the database was written by a newer version")
+
+// setFormat will mark the store as stored with the current encoding
+func setFormat(txn StoreTxn) error {
+	return txn.SetMeta(string(formatKey), []byte{dbFormat})
+}
+
+// gobBlock, gobTx, gobInput and gobOutput have the field names of the
+// blocks stored with encoding/gob, which decodes by name. The first
+// versions locked the outputs with a public key hash and unlocked the
+// inputs with a signature and a key, the later ones with scripts
+type gobBlock struct {
+	TimeStamp    int64
+	Hash         []byte
+	Transactions []*gobTx
+	PrevHash     []byte
+	Nonce        int
+	Heigth       int
+}
+
+type gobTx struct {
+	ID       []byte
+	Inputs   []gobInput
+	Outputs  []gobOutput
+	LockTime int64
+}
+
+type gobInput struct {
+	ID        []byte
+	Out       int
+	Signature []byte
+	PubKey    []byte
+	Script    []byte
+	Sequence  uint32
+}
+
+type gobOutput struct {
+	Value      int
+	PubKeyHash []byte
+	Script     []byte
+}
+
+// legacyDeserialize will decode a block stored with an older format, the
+// transactions keep the ids they had in that format
+func legacyDeserialize(format byte, data []byte) (*Block, error) {
+	if format == 0x01 {
+		return parseBlockV1(data)
+	}
+
+	var stored gobBlock
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+		return nil, err
+	}
+
+	return stored.block(), nil
+}
+
+// block will turn the gob block into a block with scripts, the public key
+// hashes become pay to public key hash scripts, the signatures and keys
+// their unlocking scripts and the data of a coinbase its push
+func (b *gobBlock) block() *Block {
+	block := &Block{TimeStamp: b.TimeStamp, Hash: b.Hash, PrevHash: b.PrevHash, Nonce: b.Nonce, Heigth: b.Heigth}
+	for _, stored := range b.Transactions {
+		tx := &Transaction{ID: stored.ID, LockTime: stored.LockTime}
+		for _, in := range stored.Inputs {
+			input := TxInput{ID: in.ID, Out: in.Out, Script: in.Script, Sequence: in.Sequence}
+			if len(in.Script) == 0 && len(in.ID) == 0 && in.Out == -1 {
+				input.Script = script.NewBuilder().AddData(in.PubKey).Script()
+				input.Sequence = SequenceFinal
+			} else if len(in.Script) == 0 && (in.Signature != nil || in.PubKey != nil) {
+				input.Script = script.PayToPubKeyHashUnlock(in.Signature, in.PubKey)
+			}
+
+			tx.Inputs = append(tx.Inputs, input)
+		}
+
+		for _, out := range stored.Outputs {
+			output := TxOutput{Value: out.Value, Script: out.Script}
+			if len(out.Script) == 0 && out.PubKeyHash != nil {
+				output.Script = script.PayToPubKeyHash(out.PubKeyHash)
+			}
+
+			tx.Outputs = append(tx.Outputs, output)
+		}
+
+		block.Transactions = append(block.Transactions, tx)
+	}
+
+	return block
+}
+
+// parseBlockV1 will decode a block of the first canonical format, its
+// header has no witness root and the ids hashed the whole transaction
+func parseBlockV1(data []byte) (*Block, error) {
+	block := &Block{}
+	r := wire.NewReader(data)
+
+	r.Version(0x01)
+	block.PrevHash = r.VarBytes()
+	r.VarBytes() // the merkle root is computed again
+	block.TimeStamp = r.Int64()
+	block.Heigth = int(r.Uint32())
+	r.Uint32()
+	block.Nonce = int(r.Uint64())
+
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		tx := decodeTransaction(r)
+		tx.ID = tx.WitnessHash()
+		block.Transactions = append(block.Transactions, &tx)
+	}
+
+	return block, r.Done()
+}
+
+// dbFormatOf will return the format of the stored blocks, zero for gob
+func dbFormatOf(db *badger.DB) (byte, error) {
+	format := byte(0)
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(formatKey)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err == nil && len(value) > 0 {
+			format = value[0]
+		}
+		return err
+	})
+
+	return format, err
+}
+
+// legacyChain will read the blocks of an older database from the genesis to
+// the last block, together with every key that is not part of the utxo set
+func legacyChain(db *badger.DB, format byte) ([]*Block, [][]byte, error) {
+	var blocks []*Block
+	var keys [][]byte
+
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(tipKey)
+		if err != nil {
+			return err
+		}
+
+		hash, err := item.ValueCopy(nil)
+		for err == nil && len(hash) > 0 {
+			if item, err = txn.Get(hash); err != nil {
+				return err
+			}
+
+			data, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			block, err := legacyDeserialize(format, data)
+			if err != nil {
+				return err
+			}
+
+			blocks = append([]*Block{block}, blocks...)
+			hash = block.PrevHash
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			if !bytes.HasPrefix(key, utxoPrefix) && !bytes.Equal(key, tipKey) && !bytes.Equal(key, formatKey) {
+				keys = append(keys, key)
+			}
+		}
+
+		return err
+	})
+
+	return blocks, keys, err
+}
+
+// migrate will rewrite a database stored with encoding/gob or an older
+// format into the current one and return the new last hash, nil when the
+// database already uses it. The transaction ids and the block hashes
+// change with the format, so every input is pointed to the new ids and
+// every block is mined again on top of the new hash of its parent.
+// Signatures made over the old format are kept as they are, they are not
+// verified again
+func migrate(db *badger.DB) ([]byte, error) {
+	format, err := dbFormatOf(db)
+	if err != nil || format == dbFormat {
+		return nil, err
+	}
+
+	if format > dbFormat {
+		return nil, ErrNewerFormat
+	}
+
+	blocks, oldKeys, err := legacyChain(db, format)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Migrating %d blocks to the current encoding\n", len(blocks))
+	ids := make(map[string][]byte)
+	newKeys := make(map[string]bool)
+	prevHash := []byte{}
+
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			if !tx.IsCoinBase() {
+				for i, in := range tx.Inputs {
+					if id, ok := ids[hex.EncodeToString(in.ID)]; ok {
+						tx.Inputs[i].ID = id
+					}
+				}
+			}
+
+			oldID := hex.EncodeToString(tx.ID)
+			tx.ID = tx.Hash()
+			ids[oldID] = tx.ID
+		}
+
+		block.PrevHash = prevHash
+		block.Bits = legacyBits
+		nonce, hash := NewProof(block).Run()
+		block.Nonce = nonce
+		block.Hash = hash
+
+		err := db.Update(func(txn *badger.Txn) error {
+			return txn.Set(block.Hash, block.Serialize())
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		newKeys[string(block.Hash)] = true
+		prevHash = block.Hash
+	}
+
+	// the new chain is complete, switch to it and drop the old blocks
+	err = db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(tipKey, prevHash); err != nil {
+			return err
+		}
+
+		for _, key := range oldKeys {
+			if !newKeys[string(key)] {
+				if err := txn.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+
+		return txn.Set(formatKey, []byte{dbFormat})
+	})
+
+	return prevHash, err
+}
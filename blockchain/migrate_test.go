@@ -0,0 +1,350 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wire"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// baselineBlock, baselineTx, baselineInput and baselineOutput are the
+// blocks of the first version, stored with encoding/gob
+type baselineBlock struct {
+	TimeStamp    int64
+	Hash         []byte
+	Transactions []*baselineTx
+	PrevHash     []byte
+	Nonce        int
+	Heigth       int
+}
+
+type baselineTx struct {
+	ID      []byte
+	Inputs  []baselineInput
+	Outputs []baselineOutput
+}
+
+type baselineInput struct {
+	ID        []byte
+	Out       int
+	Signature []byte
+	PubKey    []byte
+}
+
+type baselineOutput struct {
+	Value      int
+	PubKeyHash []byte
+}
+
+// gobEncode will encode the value with encoding/gob
+func gobEncode(t *testing.T, value interface{}) []byte {
+	t.Helper()
+
+	var buff bytes.Buffer
+	if err := gob.NewEncoder(&buff).Encode(value); err != nil {
+		t.Fatal(err)
+	}
+
+	return buff.Bytes()
+}
+
+func TestLegacyDeserializeBaseline(t *testing.T) {
+	pubKeyHash := bytes.Repeat([]byte{1}, 20)
+	sig := bytes.Repeat([]byte{2}, 64)
+	pubKey := bytes.Repeat([]byte{3}, 64)
+
+	stored := baselineBlock{
+		TimeStamp: 1600000000,
+		Hash:      []byte("block"),
+		PrevHash:  []byte("parent"),
+		Nonce:     7,
+		Heigth:    3,
+		Transactions: []*baselineTx{
+			{
+				ID:      []byte("spend"),
+				Inputs:  []baselineInput{{ID: []byte("prev"), Out: 1, Signature: sig, PubKey: pubKey}},
+				Outputs: []baselineOutput{{Value: 15, PubKeyHash: pubKeyHash}},
+			},
+			{
+				ID:      []byte("coinbase"),
+				Inputs:  []baselineInput{{ID: []byte{}, Out: -1, PubKey: []byte("reward")}},
+				Outputs: []baselineOutput{{Value: 20, PubKeyHash: pubKeyHash}},
+			},
+		},
+	}
+
+	block, err := legacyDeserialize(0, gobEncode(t, stored))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Heigth != 3 || block.Nonce != 7 || !bytes.Equal(block.PrevHash, stored.PrevHash) || len(block.Transactions) != 2 {
+		t.Fatalf("got block %+v", block)
+	}
+
+	spend, coinbase := block.Transactions[0], block.Transactions[1]
+	if !bytes.Equal(spend.ID, stored.Transactions[0].ID) {
+		t.Fatalf("got id %q, the old id is kept until the migration", spend.ID)
+	}
+
+	in := spend.Inputs[0]
+	if !bytes.Equal(in.ID, []byte("prev")) || in.Out != 1 || !bytes.Equal(in.Script, script.PayToPubKeyHashUnlock(sig, pubKey)) {
+		t.Fatalf("got input %+v", in)
+	}
+
+	locking := script.PayToPubKeyHash(pubKeyHash)
+	for _, tx := range block.Transactions {
+		if out := tx.Outputs[0]; !bytes.Equal(out.Script, locking) {
+			t.Fatalf("got locking script %x, want %x", out.Script, locking)
+		}
+	}
+
+	if spend.Outputs[0].Value != 15 || coinbase.Outputs[0].Value != 20 {
+		t.Fatalf("got values %d and %d", spend.Outputs[0].Value, coinbase.Outputs[0].Value)
+	}
+
+	if !coinbase.IsCoinBase() || !bytes.Equal(coinbase.Inputs[0].Script, script.NewBuilder().AddData([]byte("reward")).Script()) {
+		t.Fatalf("got coinbase input %+v", coinbase.Inputs[0])
+	}
+}
+
+// the blocks stored with gob after the scripts keep them as they are
+func TestLegacyDeserializeScripts(t *testing.T) {
+	locking := script.PayToPubKeyHash(bytes.Repeat([]byte{1}, 20))
+	unlocking := script.PayToPubKeyHashUnlock([]byte{2}, []byte{3})
+	tx := &Transaction{
+		ID:       []byte("spend"),
+		Inputs:   []TxInput{{ID: []byte("prev"), Out: 0, Script: unlocking, Sequence: RelativeBlocks(5)}},
+		Outputs:  []TxOutput{{Value: 9, Script: locking}},
+		LockTime: 100,
+	}
+
+	stored := struct {
+		TimeStamp    int64
+		Hash         []byte
+		Transactions []*Transaction
+		PrevHash     []byte
+		Nonce        int
+		Heigth       int
+	}{Hash: []byte("block"), PrevHash: []byte("parent"), Heigth: 1, Transactions: []*Transaction{tx}}
+
+	block, err := legacyDeserialize(0, gobEncode(t, stored))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := block.Transactions[0]
+	if !bytes.Equal(got.Inputs[0].Script, unlocking) || got.Inputs[0].Sequence != RelativeBlocks(5) || got.LockTime != 100 {
+		t.Fatalf("got transaction %+v", got)
+	}
+
+	if !bytes.Equal(got.Outputs[0].Script, locking) || got.Outputs[0].Value != 9 {
+		t.Fatalf("got output %+v", got.Outputs[0])
+	}
+}
+
+// migrateChain will store the encoded blocks, from the genesis up, in a
+// database of the given format, migrate it and return the migrated blocks
+// from the genesis up
+func migrateChain(t *testing.T, format byte, hashes, blocks [][]byte) []*Block {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	err = db.Update(func(txn *badger.Txn) error {
+		for i, data := range blocks {
+			if err := txn.Set(hashes[i], data); err != nil {
+				return err
+			}
+		}
+
+		if format > 0 {
+			if err := txn.Set(formatKey, []byte{format}); err != nil {
+				return err
+			}
+		}
+
+		return txn.Set(tipKey, hashes[len(hashes)-1])
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tip, err := migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again, err := migrate(db); err != nil || again != nil {
+		t.Fatalf("got %x and error %v migrating again, want nothing", again, err)
+	}
+
+	var migrated []*Block
+	err = NewBadgerStore(db).View(func(txn StoreTxn) error {
+		for _, hash := range hashes {
+			if ok, err := txn.HasBlock(hash); err != nil || ok {
+				t.Errorf("the old block %x was kept", hash)
+			}
+		}
+
+		for hash := tip; len(hash) > 0; {
+			block, err := txn.Block(hash)
+			if err != nil {
+				return err
+			}
+
+			migrated = append([]*Block{block}, migrated...)
+			hash = block.PrevHash
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrated) != len(blocks) {
+		t.Fatalf("got %d migrated blocks, want %d", len(migrated), len(blocks))
+	}
+
+	for _, block := range migrated {
+		if !NewProof(block).Validate() {
+			t.Fatalf("the migrated block %d does not meet its difficulty", block.Heigth)
+		}
+	}
+
+	return migrated
+}
+
+// checkMigratedSpend will check the coinbase of the first block and the
+// spend of its output in the second one after the migration
+func checkMigratedSpend(t *testing.T, blocks []*Block, unlocking, payee, change []byte) {
+	t.Helper()
+
+	coinbase := blocks[0].Transactions[0]
+	spend := blocks[1].Transactions[0]
+	for _, tx := range []*Transaction{coinbase, spend} {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			t.Fatalf("got id %x, want %x", tx.ID, tx.Hash())
+		}
+	}
+
+	if !bytes.Equal(blocks[1].PrevHash, blocks[0].Hash) {
+		t.Fatal("the second block does not follow the first one")
+	}
+
+	in := spend.Inputs[0]
+	if !bytes.Equal(in.ID, coinbase.ID) || in.Out != 0 || !bytes.Equal(in.Script, unlocking) {
+		t.Fatalf("got input %x:%d with script %x", in.ID, in.Out, in.Script)
+	}
+
+	if out := coinbase.Outputs[0]; out.Value != 20 || !bytes.Equal(out.Script, change) {
+		t.Fatalf("got coinbase output %+v", out)
+	}
+
+	want := []TxOutput{{Value: 15, Script: payee}, {Value: 5, Script: change}}
+	for i, out := range spend.Outputs {
+		if out.Value != want[i].Value || !bytes.Equal(out.Script, want[i].Script) {
+			t.Fatalf("output %d: got %+v, want %+v", i, out, want[i])
+		}
+	}
+}
+
+func TestMigrateGob(t *testing.T) {
+	alice, bob := bytes.Repeat([]byte{1}, 20), bytes.Repeat([]byte{2}, 20)
+	sig, pubKey := bytes.Repeat([]byte{3}, 64), bytes.Repeat([]byte{4}, 64)
+
+	genesis := baselineBlock{
+		Hash:     []byte("genesis"),
+		PrevHash: []byte{},
+		Transactions: []*baselineTx{{
+			ID:      []byte("coinbase"),
+			Inputs:  []baselineInput{{ID: []byte{}, Out: -1, PubKey: []byte("first")}},
+			Outputs: []baselineOutput{{Value: 20, PubKeyHash: alice}},
+		}},
+	}
+
+	next := baselineBlock{
+		Hash:     []byte("next"),
+		PrevHash: genesis.Hash,
+		Heigth:   1,
+		Transactions: []*baselineTx{
+			{
+				ID:      []byte("spend"),
+				Inputs:  []baselineInput{{ID: []byte("coinbase"), Out: 0, Signature: sig, PubKey: pubKey}},
+				Outputs: []baselineOutput{{Value: 15, PubKeyHash: bob}, {Value: 5, PubKeyHash: alice}},
+			},
+			{
+				ID:      []byte("reward"),
+				Inputs:  []baselineInput{{ID: []byte{}, Out: -1, PubKey: []byte("second")}},
+				Outputs: []baselineOutput{{Value: 20, PubKeyHash: alice}},
+			},
+		},
+	}
+
+	blocks := migrateChain(t, 0, [][]byte{genesis.Hash, next.Hash}, [][]byte{gobEncode(t, genesis), gobEncode(t, next)})
+	checkMigratedSpend(t, blocks, script.PayToPubKeyHashUnlock(sig, pubKey), script.PayToPubKeyHash(bob), script.PayToPubKeyHash(alice))
+}
+
+// encodeV1 will encode the block with the first canonical format, its
+// header has no witness root
+func encodeV1(block *Block) []byte {
+	w := wire.NewWriter()
+	w.Uint32(0x01)
+	w.VarBytes(block.PrevHash)
+	w.VarBytes(nil) // the merkle root is not read back
+	w.Int64(block.TimeStamp)
+	w.Uint32(uint32(block.Heigth))
+	w.Uint32(legacyBits)
+	w.Uint64(uint64(block.Nonce))
+
+	w.VarInt(uint64(len(block.Transactions)))
+	for _, tx := range block.Transactions {
+		tx.encode(w, true)
+	}
+
+	return w.Bytes()
+}
+
+func TestMigrateV1(t *testing.T) {
+	alice := script.PayToPubKeyHash(bytes.Repeat([]byte{1}, 20))
+	bob := script.PayToPubKeyHash(bytes.Repeat([]byte{2}, 20))
+	unlocking := script.PayToPubKeyHashUnlock(bytes.Repeat([]byte{3}, 64), bytes.Repeat([]byte{4}, 33))
+
+	coinbase := func(data string) *Transaction {
+		in := TxInput{ID: []byte{}, Out: -1, Script: script.NewBuilder().AddData([]byte(data)).Script(), Sequence: SequenceFinal}
+		return &Transaction{Inputs: []TxInput{in}, Outputs: []TxOutput{{Value: 20, Script: alice}}}
+	}
+
+	// the ids of the first format hashed the whole transaction
+	first := coinbase("first")
+	spend := &Transaction{
+		Inputs:  []TxInput{{ID: first.WitnessHash(), Out: 0, Script: unlocking, Sequence: SequenceFinal}},
+		Outputs: []TxOutput{{Value: 15, Script: bob}, {Value: 5, Script: alice}},
+	}
+
+	// the id of a coinbase keeps its script and does not change, the one
+	// of a spend leaves the unlocking script out
+	respend := &Transaction{
+		Inputs:  []TxInput{{ID: spend.WitnessHash(), Out: 1, Script: unlocking, Sequence: SequenceFinal}},
+		Outputs: []TxOutput{{Value: 5, Script: bob}},
+	}
+
+	genesis := &Block{Hash: []byte("genesis"), PrevHash: []byte{}, Transactions: []*Transaction{first}}
+	next := &Block{Hash: []byte("next"), PrevHash: genesis.Hash, Heigth: 1, Transactions: []*Transaction{spend, respend, coinbase("second")}}
+
+	blocks := migrateChain(t, 0x01, [][]byte{genesis.Hash, next.Hash}, [][]byte{encodeV1(genesis), encodeV1(next)})
+	checkMigratedSpend(t, blocks, unlocking, bob, alice)
+
+	migrated := blocks[1].Transactions
+	if in := migrated[1].Inputs[0]; !bytes.Equal(in.ID, migrated[0].ID) || bytes.Equal(in.ID, spend.WitnessHash()) || in.Out != 1 {
+		t.Fatalf("got input %x:%d, want %x:1", in.ID, in.Out, migrated[0].ID)
+	}
+}
@@ -0,0 +1,658 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/signature"
+	"github.com/Haizza1/go-block/wallet"
+	"github.com/Haizza1/go-block/wire"
+)
+
+var (
+	ErrCannotSign       = errors.New("the key can not sign any input of the transaction")
+	ErrMissingSignature = errors.New("the transaction does not have enough signatures")
+	ErrUnknownScript    = errors.New("the input spends an output of an unknown type")
+	ErrRedeemMismatch   = errors.New("the redeem script does not match the output")
+	ErrNotPartialTx     = errors.New("the data is not a partially signed transaction")
+	ErrPartialVersion   = errors.New("unsupported partially signed transaction version")
+	ErrDifferentTx      = errors.New("the partially signed transactions spend different transactions")
+	ErrMissingNonce     = errors.New("the musig input does not have the nonces of every key")
+	ErrUnknownNonce     = errors.New("the wallet does not have the secret of its musig nonce")
+	ErrSigHashMismatch  = errors.New("the musig partial signatures use different hash types")
+)
+
+// partialMagic starts every serialized partial transaction, followed by
+// the version of the format
+var partialMagic = []byte("gbpt")
+
+const PartialTxVersion = byte(0x03)
+
+// PartialInput holds everything the signers of an input need, so the
+// transaction can be signed by many holders without the blockchain
+type PartialInput struct {
+	PrevOut      TxOutput          // represents the output spent by the input
+	RedeemScript []byte            // represents the redeem script of pay to script hash outputs
+	Secret       []byte            // represents the secret that redeems a hash time locked contract, nil refunds it
+	MuSigKeys    [][]byte          // represents the keys aggregated into the schnorr key of the output, sorted
+	Nonces       map[string][]byte // represents the public musig nonces by hex public key
+	Signatures   map[string][]byte // represents the signatures by hex public key, partial ones for musig
+}
+
+// newPartialInput will create the signing data of an input that spends the output
+func newPartialInput(prevOut TxOutput) PartialInput {
+	return PartialInput{PrevOut: prevOut, Nonces: make(map[string][]byte), Signatures: make(map[string][]byte)}
+}
+
+// PartialTx is an unsigned transaction that collects the signatures of
+// its inputs until it can be finalized
+type PartialTx struct {
+	Tx     Transaction    // represents the transaction without unlocking scripts
+	Inputs []PartialInput // represents the signing data of every input
+}
+
+// NewPartialTx will create a partial transaction, the outputs spent by
+// every input must be given in order
+func NewPartialTx(tx *Transaction, prevOuts []TxOutput) *PartialTx {
+	ptx := &PartialTx{Tx: tx.TrimmedCopy()}
+	for _, prevOut := range prevOuts {
+		ptx.Inputs = append(ptx.Inputs, newPartialInput(prevOut))
+	}
+
+	return ptx
+}
+
+// AddRedeemScript will attach the redeem script to every input that
+// spends its script hash and return how many inputs use it
+func (p *PartialTx) AddRedeemScript(redeem []byte) int {
+	count := 0
+	for i, in := range p.Inputs {
+		scriptHash, ok := script.ExtractScriptHash(in.PrevOut.Script)
+		if ok && bytes.Equal(scriptHash, wallet.PublicKeyHash(redeem)) {
+			p.Inputs[i].RedeemScript = redeem
+			count++
+		}
+	}
+
+	return count
+}
+
+// AddMuSigKeys will attach the keys to every input that spends the schnorr
+// key aggregated from them and return how many inputs use it
+func (p *PartialTx) AddMuSigKeys(pubKeys [][]byte) int {
+	aggregated, err := signature.AggregateKeys(pubKeys)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for i, in := range p.Inputs {
+		key, ok := script.ExtractSchnorrKey(in.PrevOut.Script)
+		if ok && bytes.Equal(key, aggregated) {
+			p.Inputs[i].MuSigKeys = signature.SortKeys(pubKeys)
+			count++
+		}
+	}
+
+	return count
+}
+
+// IsMuSig will check if the input spends a schnorr key aggregated with musig
+func (in *PartialInput) IsMuSig() bool {
+	_, ok := script.ExtractSchnorrKey(in.PrevOut.Script)
+	return ok && len(in.MuSigKeys) > 0
+}
+
+// singleKey will return the key of an input signed by one key, the pay
+// to public key hash ones are matched by the hash of the key
+func (in *PartialInput) singleKey(pubKey []byte) ([]byte, bool) {
+	if pubKeyHash, ok := script.ExtractPubKeyHash(in.PrevOut.Script); ok {
+		if bytes.Equal(pubKeyHash, wallet.PublicKeyHash(pubKey)) {
+			return pubKey, true
+		}
+		return nil, true
+	}
+
+	if key, ok := script.ExtractSchnorrKey(in.PrevOut.Script); ok && !in.IsMuSig() {
+		return key, true
+	}
+
+	return nil, false
+}
+
+// signers will return the signatures needed by the redeem script of the
+// input and the public keys that may sign it, in script order. Every
+// musig key must sign
+func (in *PartialInput) signers() (int, [][]byte, bool) {
+	if in.IsMuSig() {
+		return len(in.MuSigKeys), in.MuSigKeys, true
+	}
+
+	if m, pubKeys, ok := script.ExtractMultiSig(in.RedeemScript); ok {
+		return m, pubKeys, true
+	}
+
+	if _, _, pubKey, ok := script.ExtractTimeLock(in.RedeemScript); ok {
+		return 1, [][]byte{pubKey}, true
+	}
+
+	if contract, ok := script.ExtractHTLC(in.RedeemScript); ok {
+		if in.Secret != nil {
+			return 1, [][]byte{contract.Recipient}, true
+		}
+		return 1, [][]byte{contract.Sender}, true
+	}
+
+	return 0, nil, false
+}
+
+// CanSign will check if the public key may sign the input
+func (in *PartialInput) CanSign(pubKey []byte) bool {
+	if key, ok := in.singleKey(pubKey); ok {
+		return bytes.Equal(key, pubKey)
+	}
+
+	_, pubKeys, ok := in.signers()
+	if !ok {
+		return false
+	}
+
+	for _, key := range pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign will add the signature of the key to every input it can sign and
+// return how many inputs were signed, the hash type selects what the
+// signatures commit to. Musig inputs are signed with SignMuSig
+func (p *PartialTx) Sign(privKey ecdsa.PrivateKey, pubKey []byte, hashType SigHashType) (int, error) {
+	signed := 0
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		if !in.CanSign(pubKey) || in.IsMuSig() {
+			continue
+		}
+
+		sigHash, err := p.Tx.SigHash(i, in.PrevOut, hashType)
+		if err != nil {
+			return signed, err
+		}
+
+		scheme := signature.ECDSA
+		if _, ok := script.ExtractSchnorrKey(in.PrevOut.Script); ok {
+			scheme = signature.Schnorr
+		}
+
+		in.Signatures[hex.EncodeToString(pubKey)] = signHash(scheme, privKey, sigHash, hashType)
+		signed++
+	}
+
+	if signed == 0 {
+		return 0, ErrCannotSign
+	}
+
+	return signed, nil
+}
+
+// AddNonces will create the musig nonces of the key for every musig input
+// it signs that has none yet, the first round of musig. Every secret nonce
+// is handed to keep and return how many nonces were added
+func (p *PartialTx) AddNonces(pubKey []byte, keep func(public, secret []byte)) (int, error) {
+	added := 0
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		key := hex.EncodeToString(pubKey)
+		if !in.IsMuSig() || !in.CanSign(pubKey) || in.Nonces[key] != nil {
+			continue
+		}
+
+		secret, public, err := signature.NewNonce()
+		if err != nil {
+			return added, err
+		}
+
+		keep(public, secret)
+		in.Nonces[key] = public
+		added++
+	}
+
+	return added, nil
+}
+
+// aggregatedNonce will add up the nonces of every musig key
+func (in *PartialInput) aggregatedNonce() ([]byte, error) {
+	var nonces [][]byte
+	for _, pubKey := range in.MuSigKeys {
+		nonce := in.Nonces[hex.EncodeToString(pubKey)]
+		if nonce == nil {
+			return nil, ErrMissingNonce
+		}
+		nonces = append(nonces, nonce)
+	}
+
+	return signature.AggregateNonces(nonces)
+}
+
+// SignMuSig will add the partial signature of the key to every musig
+// input it can sign, the second round of musig. Every key must have added
+// its nonce, secretNonce returns the secret of the nonce of the key and
+// must never return it again
+func (p *PartialTx) SignMuSig(privKey ecdsa.PrivateKey, pubKey []byte, hashType SigHashType, secretNonce func(public []byte) ([]byte, bool)) (int, error) {
+	signed := 0
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		key := hex.EncodeToString(pubKey)
+		if !in.IsMuSig() || !in.CanSign(pubKey) || in.Signatures[key] != nil {
+			continue
+		}
+
+		aggNonce, err := in.aggregatedNonce()
+		if err != nil {
+			return signed, err
+		}
+
+		secret, ok := secretNonce(in.Nonces[key])
+		if !ok {
+			return signed, ErrUnknownNonce
+		}
+
+		sigHash, err := p.Tx.SigHash(i, in.PrevOut, hashType)
+		if err != nil {
+			return signed, err
+		}
+
+		partial, err := signature.PartialSign(&privKey, secret, aggNonce, in.MuSigKeys, sigHash)
+		if err != nil {
+			return signed, err
+		}
+
+		in.Signatures[key] = append(partial, byte(hashType))
+		signed++
+	}
+
+	if signed == 0 {
+		return 0, ErrCannotSign
+	}
+
+	return signed, nil
+}
+
+// Missing will return how many signatures the input still needs
+func (in *PartialInput) Missing() int {
+	if _, ok := in.singleKey(nil); ok {
+		if len(in.Signatures) > 0 {
+			return 0
+		}
+		return 1
+	}
+
+	m, pubKeys, ok := in.signers()
+	if !ok {
+		return 1
+	}
+
+	count := 0
+	for _, pubKey := range pubKeys {
+		if in.Signatures[hex.EncodeToString(pubKey)] != nil {
+			count++
+		}
+	}
+
+	if count >= m {
+		return 0
+	}
+
+	return m - count
+}
+
+// Fee will return the value of the spent outputs that the outputs of
+// the transaction do not claim
+func (p *PartialTx) Fee() int {
+	fee := 0
+	for _, in := range p.Inputs {
+		fee += in.PrevOut.Value
+	}
+
+	for _, out := range p.Tx.Outputs {
+		fee -= out.Value
+	}
+
+	return fee
+}
+
+// AddInput will add an unsigned input that spends the given output
+func (p *PartialTx) AddInput(in TxInput, prevOut TxOutput) {
+	in.Script = nil
+	p.Tx.Inputs = append(p.Tx.Inputs, in)
+	p.Inputs = append(p.Inputs, newPartialInput(prevOut))
+}
+
+// anyoneCanPay will check if every signature of the transaction allows
+// other inputs to be added
+func (p *PartialTx) anyoneCanPay() bool {
+	for _, in := range p.Inputs {
+		for _, signature := range in.Signatures {
+			if hashType, ok := SignatureHashType(signature); !ok || !hashType.AnyoneCanPay() {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasInput will check if the transaction already spends the outpoint of the input
+func (p *PartialTx) hasInput(in TxInput) bool {
+	for _, own := range p.Tx.Inputs {
+		if bytes.Equal(own.ID, in.ID) && own.Out == in.Out {
+			return true
+		}
+	}
+
+	return false
+}
+
+// merge will append the inputs of a transaction with the same outputs,
+// every signature of both must be signed with anyone can pay
+func (p *PartialTx) merge(other *PartialTx) error {
+	sameOutputs := len(p.Tx.Outputs) == len(other.Tx.Outputs) && p.Tx.LockTime == other.Tx.LockTime
+	for i := 0; sameOutputs && i < len(p.Tx.Outputs); i++ {
+		sameOutputs = p.Tx.Outputs[i].Value == other.Tx.Outputs[i].Value && bytes.Equal(p.Tx.Outputs[i].Script, other.Tx.Outputs[i].Script)
+	}
+
+	if !sameOutputs || !p.anyoneCanPay() || !other.anyoneCanPay() {
+		return ErrDifferentTx
+	}
+
+	for i, in := range other.Tx.Inputs {
+		if p.hasInput(in) {
+			return ErrDifferentTx
+		}
+
+		p.Tx.Inputs = append(p.Tx.Inputs, in)
+		p.Inputs = append(p.Inputs, other.Inputs[i])
+	}
+
+	return nil
+}
+
+// Combine will merge the signatures and redeem scripts of other partial
+// transactions of the same unsigned transaction. Transactions with the
+// same outputs but other inputs are merged into one when all their
+// signatures use anyone can pay, like the pledges of a crowdfunding
+func (p *PartialTx) Combine(others ...*PartialTx) error {
+	for _, other := range others {
+		if !bytes.Equal(p.Tx.Serialize(), other.Tx.Serialize()) || len(p.Inputs) != len(other.Inputs) {
+			if err := p.merge(other); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i, in := range other.Inputs {
+			if p.Inputs[i].RedeemScript == nil {
+				p.Inputs[i].RedeemScript = in.RedeemScript
+			}
+
+			if p.Inputs[i].Secret == nil {
+				p.Inputs[i].Secret = in.Secret
+			}
+
+			if p.Inputs[i].MuSigKeys == nil {
+				p.Inputs[i].MuSigKeys = in.MuSigKeys
+			}
+
+			for pubKey, nonce := range in.Nonces {
+				p.Inputs[i].Nonces[pubKey] = nonce
+			}
+
+			for pubKey, signature := range in.Signatures {
+				p.Inputs[i].Signatures[pubKey] = signature
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsComplete will check if every input has enough signatures
+func (p *PartialTx) IsComplete() bool {
+	for i := range p.Inputs {
+		if p.Inputs[i].Missing() > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// muSigSignature will add up the partial signatures of the musig input
+// into the schnorr signature of the aggregated key
+func (in *PartialInput) muSigSignature(tx *Transaction, inId int) ([]byte, error) {
+	var partials [][]byte
+	var hashType SigHashType
+	for _, pubKey := range in.MuSigKeys {
+		partial, partialType, ok := splitSignature(in.Signatures[hex.EncodeToString(pubKey)])
+		if !ok {
+			return nil, ErrMissingSignature
+		}
+
+		if hashType != 0 && partialType != hashType {
+			return nil, ErrSigHashMismatch
+		}
+
+		hashType = partialType
+		partials = append(partials, partial)
+	}
+
+	aggNonce, err := in.aggregatedNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	sigHash, err := tx.SigHash(inId, in.PrevOut, hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signature.AggregateSignatures(aggNonce, in.MuSigKeys, sigHash, partials)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sig, byte(hashType)), nil
+}
+
+// unlockingScript will build the unlocking script of the input from the
+// collected signatures
+func (in *PartialInput) unlockingScript(tx *Transaction, inId int) ([]byte, error) {
+	if in.Missing() > 0 {
+		return nil, ErrMissingSignature
+	}
+
+	if in.IsMuSig() {
+		sig, err := in.muSigSignature(tx, inId)
+		if err != nil {
+			return nil, err
+		}
+
+		return script.PayToSchnorrKeyUnlock(sig), nil
+	}
+
+	if _, ok := script.ExtractSchnorrKey(in.PrevOut.Script); ok {
+		for _, sig := range in.Signatures {
+			return script.PayToSchnorrKeyUnlock(sig), nil
+		}
+	}
+
+	if _, ok := script.ExtractPubKeyHash(in.PrevOut.Script); ok {
+		for key, signature := range in.Signatures {
+			pubKey, err := hex.DecodeString(key)
+			if err != nil {
+				return nil, err
+			}
+
+			return script.PayToPubKeyHashUnlock(signature, pubKey), nil
+		}
+	}
+
+	scriptHash, ok := script.ExtractScriptHash(in.PrevOut.Script)
+	if !ok {
+		return nil, ErrUnknownScript
+	}
+
+	if !bytes.Equal(scriptHash, wallet.PublicKeyHash(in.RedeemScript)) {
+		return nil, ErrRedeemMismatch
+	}
+
+	// the engine expects the signatures in the order of the public keys
+	m, pubKeys, _ := in.signers()
+	var signatures [][]byte
+	for _, pubKey := range pubKeys {
+		signature := in.Signatures[hex.EncodeToString(pubKey)]
+		if signature != nil && len(signatures) < m {
+			signatures = append(signatures, signature)
+		}
+	}
+
+	if _, ok := script.ExtractHTLC(in.RedeemScript); ok {
+		return script.HTLCUnlock(signatures[0], in.Secret, in.RedeemScript), nil
+	}
+
+	return script.PayToScriptHashUnlock(signatures, in.RedeemScript), nil
+}
+
+// Finalize will return the transaction with the unlocking scripts of
+// every input, all the signatures must be present
+func (p *PartialTx) Finalize() (*Transaction, error) {
+	tx := p.Tx.TrimmedCopy()
+	for i := range p.Inputs {
+		unlocking, err := p.Inputs[i].unlockingScript(&p.Tx, i)
+		if err != nil {
+			return nil, err
+		}
+
+		tx.Inputs[i].Script = unlocking
+	}
+
+	tx.ID = tx.Hash()
+	return &tx, nil
+}
+
+// Serialize will serialize the partial transaction into bytes, the
+// magic and the version come first. The rest uses the canonical encoding
+// of the wire package: the unsigned transaction and for every input the
+// spent output, the redeem script, the secret, the signatures sorted by
+// public key, the musig keys and the musig nonces sorted by public key
+func (p *PartialTx) Serialize() []byte {
+	w := wire.NewWriter()
+	w.Raw(partialMagic)
+	w.Raw([]byte{PartialTxVersion})
+	p.Tx.encode(w, true)
+
+	for _, in := range p.Inputs {
+		w.Int64(int64(in.PrevOut.Value))
+		w.VarBytes(in.PrevOut.Script)
+		w.VarBytes(in.RedeemScript)
+		w.VarBytes(in.Secret)
+		writeByKey(w, in.Signatures)
+
+		w.VarInt(uint64(len(in.MuSigKeys)))
+		for _, pubKey := range in.MuSigKeys {
+			w.VarBytes(pubKey)
+		}
+		writeByKey(w, in.Nonces)
+	}
+
+	return w.Bytes()
+}
+
+// writeByKey will write a map by hex public key sorted by key, each entry
+// is the public key followed by the value
+func writeByKey(w *wire.Writer, values map[string][]byte) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w.VarInt(uint64(len(keys)))
+	for _, key := range keys {
+		pubKey, err := hex.DecodeString(key)
+		CheckError(err)
+		w.VarBytes(pubKey)
+		w.VarBytes(values[key])
+	}
+}
+
+// readByKey will read a map written by writeByKey
+func readByKey(r *wire.Reader, values map[string][]byte) {
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		pubKey := r.VarBytes()
+		values[hex.EncodeToString(pubKey)] = r.VarBytes()
+	}
+}
+
+// String will encode the partial transaction in base64 so it can be
+// moved between machines as text
+func (p *PartialTx) String() string {
+	return base64.StdEncoding.EncodeToString(p.Serialize())
+}
+
+// DecodePartialTx will decode a base64 partial transaction
+func DecodePartialTx(text string) (*PartialTx, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, ErrNotPartialTx
+	}
+
+	return DeserializePartialTx(data)
+}
+
+// DeserializePartialTx will deserialize the bytes into a partial transaction
+func DeserializePartialTx(data []byte) (*PartialTx, error) {
+	if len(data) <= len(partialMagic) || !bytes.HasPrefix(data, partialMagic) {
+		return nil, ErrNotPartialTx
+	}
+
+	if data[len(partialMagic)] != PartialTxVersion {
+		return nil, ErrPartialVersion
+	}
+
+	var ptx PartialTx
+	r := wire.NewReader(data[len(partialMagic)+1:])
+	ptx.Tx = decodeTransaction(r)
+
+	for i := 0; i < len(ptx.Tx.Inputs) && r.Err() == nil; i++ {
+		value := r.Int64()
+		in := newPartialInput(TxOutput{Value: int(value), Script: r.VarBytes()})
+		in.RedeemScript = r.VarBytes()
+		in.Secret = r.VarBytes()
+		readByKey(r, in.Signatures)
+
+		keys := r.Count()
+		for j := 0; j < keys && r.Err() == nil; j++ {
+			in.MuSigKeys = append(in.MuSigKeys, r.VarBytes())
+		}
+		readByKey(r, in.Nonces)
+
+		ptx.Inputs = append(ptx.Inputs, in)
+	}
+
+	if err := r.Done(); err != nil {
+		return nil, err
+	}
+
+	return &ptx, nil
+}
@@ -18,11 +18,9 @@ steps:
 	4. check the hash of the see if it meets a set of requirements
 
 requirements:
-	The fist few bytes must constains 0s
+	The fist bits of the hash, as many as the bits of the block, must be 0s
 */
 
-const difficulty = 12
-
 type ProofOfWork struct {
 	Block  *Block
 	Target *big.Int
@@ -31,25 +29,15 @@ type ProofOfWork struct {
 // NewProof will create a new Proof of work instance
 func NewProof(b *Block) *ProofOfWork {
 	target := big.NewInt(1)
-	target.Lsh(target, uint(256-difficulty))
+	target.Lsh(target, uint(256-b.Bits))
 	pow := &ProofOfWork{b, target}
 	return pow
 }
 
-// IinitData will create a new byte slice
-// from the block data and return it
+// IinitData will return the header of the block with
+// the given nonce, wich is the data that gets hashed
 func (pow *ProofOfWork) InitData(nonce int) []byte {
-	data := bytes.Join(
-		[][]byte{
-			pow.Block.PrevHash,
-			pow.Block.HashTransactions(),
-			ToHex(int64(nonce)),
-			ToHex(int64(difficulty)),
-		},
-		[]byte{},
-	)
-
-	return data
+	return pow.Block.Header(nonce)
 }
 
 // Run will create a hash from the data + countter
@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// SigCacheSize is the number of verified inputs the signature cache keeps
+const SigCacheSize = 50000
+
+// SigCache remembers the inputs whose scripts and signatures were already
+// verified, so the transactions checked when they enter the mempool are
+// not checked again when their block arrives. An input is keyed by the
+// witness id of its transaction and its index: the txid leaves the
+// unlocking scripts out, so another signature would share it
+type SigCache struct {
+	mu      sync.RWMutex
+	entries map[string]bool
+	size    int
+}
+
+// sigCache is the cache shared by every chain of the process
+var sigCache = NewSigCache(SigCacheSize)
+
+// NewSigCache will create a cache that keeps up to size inputs
+func NewSigCache(size int) *SigCache {
+	return &SigCache{entries: make(map[string]bool), size: size}
+}
+
+// sigCacheKey will return the key of the input of the transaction
+func sigCacheKey(wtxid []byte, inId int) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(wtxid), inId)
+}
+
+// Contains will check if the input was verified
+func (c *SigCache) Contains(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.entries[key]
+}
+
+// Add will remember a verified input, a full cache forgets a random one
+func (c *SigCache) Add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+
+	for old := range c.entries {
+		if len(c.entries) < c.size {
+			break
+		}
+		delete(c.entries, old)
+	}
+
+	c.entries[key] = true
+}
@@ -0,0 +1,230 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Haizza1/go-block/signature"
+	"github.com/Haizza1/go-block/wire"
+)
+
+// SigHashType selects the parts of the transaction a signature commits
+// to, it is appended to every signature
+type SigHashType byte
+
+const (
+	SigHashAll          SigHashType = 0x01 // commits to every input and output
+	SigHashNone         SigHashType = 0x02 // commits to the inputs only, anyone may set the outputs
+	SigHashSingle       SigHashType = 0x03 // commits to the inputs and the output at the index of the input
+	SigHashAnyoneCanPay SigHashType = 0x80 // combined with the others, commits to the signed input only
+)
+
+var (
+	ErrInvalidSigHash = errors.New("the signature hash type is not valid")
+	ErrSigHashSingle  = errors.New("sighash single needs an output at the index of the input")
+)
+
+// ParseSigHashType will parse names like ALL, NONE, SINGLE and
+// ALL|ANYONECANPAY, an empty name is ALL. Only one of the base types may
+// be named, their bits overlap
+func ParseSigHashType(name string) (SigHashType, error) {
+	var base, anyoneCanPay SigHashType
+	for _, part := range strings.Split(strings.ToUpper(name), "|") {
+		var named SigHashType
+		switch strings.TrimSpace(part) {
+		case "", "ALL":
+			named = SigHashAll
+		case "NONE":
+			named = SigHashNone
+		case "SINGLE":
+			named = SigHashSingle
+		case "ANYONECANPAY":
+			anyoneCanPay = SigHashAnyoneCanPay
+			continue
+		default:
+			return 0, ErrInvalidSigHash
+		}
+
+		if base != 0 && base != named {
+			return 0, ErrInvalidSigHash
+		}
+
+		base = named
+	}
+
+	if base == 0 {
+		base = SigHashAll
+	}
+
+	hashType := base | anyoneCanPay
+
+	if !hashType.IsValid() {
+		return 0, ErrInvalidSigHash
+	}
+
+	return hashType, nil
+}
+
+// IsValid will check that the type is one of the three base types,
+// optionally with anyone can pay
+func (t SigHashType) IsValid() bool {
+	base := t &^ SigHashAnyoneCanPay
+	return base == SigHashAll || base == SigHashNone || base == SigHashSingle
+}
+
+// AnyoneCanPay will check if other inputs can be added without breaking the signature
+func (t SigHashType) AnyoneCanPay() bool {
+	return t&SigHashAnyoneCanPay != 0
+}
+
+// String will return the name of the type, like ALL|ANYONECANPAY
+func (t SigHashType) String() string {
+	names := map[SigHashType]string{SigHashAll: "ALL", SigHashNone: "NONE", SigHashSingle: "SINGLE"}
+	name, ok := names[t&^SigHashAnyoneCanPay]
+	if !ok {
+		return fmt.Sprintf("%#x", byte(t))
+	}
+
+	if t.AnyoneCanPay() {
+		name += "|ANYONECANPAY"
+	}
+
+	return name
+}
+
+// SigHash will return the message signed by the given input: the hash of
+// the transaction without unlocking scripts where the signed input holds
+// the locking script of the output it spends, followed by the hash type.
+// The hash type removes the parts the signature does not commit to, the
+// sequence of the other inputs is zeroed with NONE and SINGLE so they can
+// be replaced
+func (tx *Transaction) SigHash(inId int, prevOut TxOutput, hashType SigHashType) ([]byte, error) {
+	if !hashType.IsValid() {
+		return nil, ErrInvalidSigHash
+	}
+
+	txCopy := tx.TrimmedCopy()
+	txCopy.Inputs[inId].Script = prevOut.Script
+
+	switch hashType &^ SigHashAnyoneCanPay {
+	case SigHashNone:
+		txCopy.Outputs = nil
+		zeroSequences(txCopy.Inputs, inId)
+
+	case SigHashSingle:
+		if inId >= len(txCopy.Outputs) {
+			return nil, ErrSigHashSingle
+		}
+
+		txCopy.Outputs = txCopy.Outputs[:inId+1]
+		for i := 0; i < inId; i++ {
+			txCopy.Outputs[i] = TxOutput{Value: -1}
+		}
+		zeroSequences(txCopy.Inputs, inId)
+	}
+
+	if hashType.AnyoneCanPay() {
+		txCopy.Inputs = txCopy.Inputs[inId : inId+1]
+	}
+
+	w := wire.NewWriter()
+	txCopy.encode(w, true)
+	w.Uint32(uint32(hashType))
+
+	hash := sha256.Sum256(w.Bytes())
+	return hash[:], nil
+}
+
+// zeroSequences will zero the sequence of every input but the signed one
+func zeroSequences(inputs []TxInput, inId int) {
+	for i := range inputs {
+		if i != inId {
+			inputs[i].Sequence = 0
+		}
+	}
+}
+
+// SignatureLength is the size of an encoded ecdsa signature: r and s
+// padded to 32 bytes each, big endian, followed by the hash type byte.
+// A schnorr signature is the nonce point and s followed by the hash type
+const (
+	SignatureLength        = signature.ECDSASize + 1
+	SchnorrSignatureLength = signature.SchnorrSize + 1
+)
+
+// signHash will sign the hash of an input with the private key using the
+// given scheme and append the hash type
+func signHash(scheme signature.Scheme, privKey ecdsa.PrivateKey, sigHash []byte, hashType SigHashType) []byte {
+	sig, err := scheme.Sign(&privKey, sigHash)
+	CheckError(err)
+
+	return append(sig, byte(hashType))
+}
+
+// splitSignature will split an encoded signature into the signature of
+// the scheme and a valid hash type
+func splitSignature(sig []byte) ([]byte, SigHashType, bool) {
+	if len(sig) == 0 {
+		return nil, 0, false
+	}
+
+	hashType := SigHashType(sig[len(sig)-1])
+	return sig[:len(sig)-1], hashType, hashType.IsValid()
+}
+
+// SignatureHashType will return the hash type of an encoded signature,
+// its last byte
+func SignatureHashType(sig []byte) (SigHashType, bool) {
+	_, hashType, ok := splitSignature(sig)
+	return hashType, ok
+}
+
+// txSigChecker checks the signatures of one input of a transaction for
+// the script engine, the schnorr signatures go to the batch when there is one
+type txSigChecker struct {
+	tx      *Transaction     // represents the transaction being verified
+	inId    int              // represents the input being verified
+	prevOut TxOutput         // represents the output spent by the input
+	batch   *signature.Batch // represents the batch of the block, nil verifies right away
+}
+
+// CheckSig will verify the ecdsa signature of the input with the given
+// public key and the hash type of the signature, high s values and non
+// standard encodings are rejected
+func (c *txSigChecker) CheckSig(sig, pubKey []byte) bool {
+	raw, hashType, ok := splitSignature(sig)
+	if !ok || len(raw) != signature.ECDSASize {
+		return false
+	}
+
+	sigHash, err := c.tx.SigHash(c.inId, c.prevOut, hashType)
+	if err != nil {
+		return false
+	}
+
+	return signature.ECDSA.Verify(pubKey, sigHash, raw)
+}
+
+// CheckSchnorr will verify the schnorr signature of the input with the
+// key of a version 1 output. In a batch the signature is only parsed and
+// queued, the batch reports if it was valid
+func (c *txSigChecker) CheckSchnorr(sig, pubKey []byte) bool {
+	raw, hashType, ok := splitSignature(sig)
+	if !ok || len(raw) != signature.SchnorrSize {
+		return false
+	}
+
+	sigHash, err := c.tx.SigHash(c.inId, c.prevOut, hashType)
+	if err != nil {
+		return false
+	}
+
+	if c.batch != nil {
+		return c.batch.Add(pubKey, sigHash, raw) == nil
+	}
+
+	return signature.Schnorr.Verify(pubKey, sigHash, raw)
+}
@@ -0,0 +1,145 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseSigHashType(t *testing.T) {
+	tests := []struct {
+		name     string
+		hashType SigHashType
+		err      error
+	}{
+		{"", SigHashAll, nil},
+		{"all", SigHashAll, nil},
+		{"NONE", SigHashNone, nil},
+		{"SINGLE", SigHashSingle, nil},
+		{"ALL|ANYONECANPAY", SigHashAll | SigHashAnyoneCanPay, nil},
+		{"ANYONECANPAY", SigHashAll | SigHashAnyoneCanPay, nil},
+		{"single | anyonecanpay", SigHashSingle | SigHashAnyoneCanPay, nil},
+		{"ALL|NONE", 0, ErrInvalidSigHash},
+		{"EVERYTHING", 0, ErrInvalidSigHash},
+	}
+
+	for _, test := range tests {
+		hashType, err := ParseSigHashType(test.name)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%q: got error %v, want %v", test.name, err, test.err)
+		}
+
+		if hashType != test.hashType {
+			t.Fatalf("%q: got %s, want %s", test.name, hashType, test.hashType)
+		}
+	}
+}
+
+// sigHashTx has two inputs and two outputs, the signed input is the first
+func sigHashTx() *Transaction {
+	return &Transaction{
+		Inputs: []TxInput{
+			{ID: []byte{1}, Out: 0, Script: []byte{0xaa}, Sequence: 5},
+			{ID: []byte{2}, Out: 1, Script: []byte{0xbb}, Sequence: 6},
+		},
+		Outputs: []TxOutput{
+			{Value: 10, Script: []byte{0x01}},
+			{Value: 20, Script: []byte{0x02}},
+		},
+	}
+}
+
+// the sighash of every type must change exactly when a part it commits to
+// changes
+func TestSigHashCommitments(t *testing.T) {
+	prevOut := TxOutput{Value: 30, Script: []byte{0x51}}
+	types := []SigHashType{
+		SigHashAll,
+		SigHashNone,
+		SigHashSingle,
+		SigHashAll | SigHashAnyoneCanPay,
+		SigHashNone | SigHashAnyoneCanPay,
+		SigHashSingle | SigHashAnyoneCanPay,
+	}
+
+	tests := []struct {
+		name    string
+		change  func(tx *Transaction)
+		commits []bool // represents if the change breaks the signature, by type
+	}{
+		{"unlocking scripts", func(tx *Transaction) {
+			tx.Inputs[0].Script = []byte{0xcc}
+			tx.Inputs[1].Script = []byte{0xdd}
+		}, []bool{false, false, false, false, false, false}},
+		{"own output", func(tx *Transaction) { tx.Outputs[0].Value++ }, []bool{true, false, true, true, false, true}},
+		{"other output", func(tx *Transaction) { tx.Outputs[1].Value++ }, []bool{true, false, false, true, false, false}},
+		{"added output", func(tx *Transaction) { tx.Outputs = append(tx.Outputs, TxOutput{Value: 1}) }, []bool{true, false, false, true, false, false}},
+		{"own sequence", func(tx *Transaction) { tx.Inputs[0].Sequence++ }, []bool{true, true, true, true, true, true}},
+		{"other sequence", func(tx *Transaction) { tx.Inputs[1].Sequence++ }, []bool{true, false, false, false, false, false}},
+		{"other outpoint", func(tx *Transaction) { tx.Inputs[1].Out++ }, []bool{true, true, true, false, false, false}},
+		{"added input", func(tx *Transaction) { tx.Inputs = append(tx.Inputs, TxInput{ID: []byte{3}}) }, []bool{true, true, true, false, false, false}},
+		{"lock time", func(tx *Transaction) { tx.LockTime++ }, []bool{true, true, true, true, true, true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, hashType := range types {
+				before, err := sigHashTx().SigHash(0, prevOut, hashType)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				tx := sigHashTx()
+				test.change(tx)
+				after, err := tx.SigHash(0, prevOut, hashType)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if changed := !bytes.Equal(before, after); changed != test.commits[i] {
+					t.Errorf("%s: got changed %v, want %v", hashType, changed, test.commits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSigHashCommitsToType(t *testing.T) {
+	prevOut := TxOutput{Value: 30, Script: []byte{0x51}}
+	all, err := sigHashTx().SigHash(0, prevOut, SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anyone, err := sigHashTx().SigHash(0, prevOut, SigHashAll|SigHashAnyoneCanPay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(all, anyone) {
+		t.Fatal("the sighash does not commit to the hash type")
+	}
+
+	other, err := sigHashTx().SigHash(0, TxOutput{Value: 30, Script: []byte{0x52}}, SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(all, other) {
+		t.Fatal("the sighash does not commit to the spent script")
+	}
+}
+
+func TestSigHashErrors(t *testing.T) {
+	prevOut := TxOutput{Value: 30, Script: []byte{0x51}}
+	tx := sigHashTx()
+	tx.Outputs = tx.Outputs[:1]
+
+	if _, err := tx.SigHash(1, prevOut, SigHashSingle); !errors.Is(err, ErrSigHashSingle) {
+		t.Fatalf("got error %v for single without an output, want %v", err, ErrSigHashSingle)
+	}
+
+	if _, err := tx.SigHash(0, prevOut, SigHashType(0x04)); !errors.Is(err, ErrInvalidSigHash) {
+		t.Fatalf("got error %v for an unknown type, want %v", err, ErrInvalidSigHash)
+	}
+}
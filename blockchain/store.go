@@ -0,0 +1,205 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrNotFound = errors.New("store: the key was not found")
+
+// the layout of the keys, shared by every backend: a block is stored
+// under its hash, the unspent outputs of a transaction under the prefix
+// followed by its id, the hash of the best block of a height under the
+// prefix followed by the height and the metadata under its name
+var (
+	tipKey       = []byte("lh")
+	utxoPrefix   = []byte("utxo-")
+	heightPrefix = []byte("height-")
+)
+
+// ChainStore keeps the blocks of a chain, the hash of its tip, its set of
+// unspent outputs and the metadata of the store. Every read and write
+// happens inside View or Update, the writes of an Update are applied all
+// together when it returns nil and dropped otherwise
+type ChainStore interface {
+	// View will run fn with a read only transaction
+	View(fn func(txn StoreTxn) error) error
+	// Update will run fn with a read write transaction
+	Update(fn func(txn StoreTxn) error) error
+	// ClearUnspent will remove the whole unspent set, it may be too large for one transaction
+	ClearUnspent() error
+	// Close will release the store
+	Close() error
+}
+
+// StoreTxn reads and writes the data of a chain store, the reads of an
+// Update see its own writes
+type StoreTxn interface {
+	// Block will return the block of the hash, ErrNotFound when it is not stored
+	Block(hash []byte) (*Block, error)
+	// Header will return the block of the hash without its transactions
+	Header(hash []byte) (*Block, error)
+	// HasBlock will check if the block of the hash is stored
+	HasBlock(hash []byte) (bool, error)
+	// PutBlock will store the block under its hash
+	PutBlock(block *Block) error
+	// Tip will return the hash of the last block of the chain
+	Tip() ([]byte, error)
+	// SetTip will make the block of the hash the last block of the chain
+	SetTip(hash []byte) error
+	// BlockHash will return the hash of the block of the best chain at the height, ErrNotFound past the tip
+	BlockHash(height int) ([]byte, error)
+	// Unspent will return the unspent outputs of the transaction, ErrNotFound when it has none
+	Unspent(txID []byte) (TxOutputs, error)
+	// PutUnspent will replace the unspent outputs of the transaction
+	PutUnspent(txID []byte, outs TxOutputs) error
+	// DeleteUnspent will remove the unspent outputs of the transaction
+	DeleteUnspent(txID []byte) error
+	// ForEachUnspent will call fn for every transaction with unspent outputs, in id order
+	ForEachUnspent(fn func(txID []byte, outs TxOutputs) error) error
+	// Meta will return the metadata of the given name, ErrNotFound when it is not set
+	Meta(name string) ([]byte, error)
+	// SetMeta will set the metadata of the given name
+	SetMeta(name string, value []byte) error
+}
+
+// kvTxn is the transaction of an ordered key value engine, the backends
+// only implement it and share the layout of the keys through storeTxn
+type kvTxn interface {
+	get(key []byte) ([]byte, error)
+	set(key, value []byte) error
+	delete(key []byte) error
+	iterate(prefix []byte, fn func(key, value []byte) error) error
+}
+
+// storeTxn implements StoreTxn over a key value transaction
+type storeTxn struct {
+	kv kvTxn
+}
+
+// Block will decode the stored block of the hash
+func (t storeTxn) Block(hash []byte) (*Block, error) {
+	data, err := t.kv.get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseBlock(data)
+}
+
+// Header will decode only the header of the stored block of the hash
+func (t storeTxn) Header(hash []byte) (*Block, error) {
+	data, err := t.kv.get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHeader(data)
+}
+
+// HasBlock will check if the block of the hash is stored
+func (t storeTxn) HasBlock(hash []byte) (bool, error) {
+	_, err := t.kv.get(hash)
+	if err == ErrNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// PutBlock will store the encoded block under its hash
+func (t storeTxn) PutBlock(block *Block) error {
+	return t.kv.set(block.Hash, block.Serialize())
+}
+
+// Tip will return the hash of the last block
+func (t storeTxn) Tip() ([]byte, error) {
+	return t.kv.get(tipKey)
+}
+
+// SetTip will set the hash of the last block and index the blocks of its
+// branch by height, down to the first block the index already has
+func (t storeTxn) SetTip(hash []byte) error {
+	if err := t.kv.set(tipKey, hash); err != nil {
+		return err
+	}
+
+	for len(hash) > 0 {
+		header, err := t.Header(hash)
+		if err != nil {
+			return err
+		}
+
+		key := heightKey(header.Heigth)
+		indexed, err := t.kv.get(key)
+		if err == nil && bytes.Equal(indexed, hash) {
+			return nil
+		} else if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		if err := t.kv.set(key, hash); err != nil {
+			return err
+		}
+
+		hash = header.PrevHash
+	}
+
+	return nil
+}
+
+// BlockHash will return the indexed hash of the height
+func (t storeTxn) BlockHash(height int) ([]byte, error) {
+	return t.kv.get(heightKey(height))
+}
+
+// Unspent will decode the unspent outputs of the transaction
+func (t storeTxn) Unspent(txID []byte) (TxOutputs, error) {
+	data, err := t.kv.get(unspentKey(txID))
+	if err != nil {
+		return TxOutputs{}, err
+	}
+
+	return DeserializeOutputs(data), nil
+}
+
+// PutUnspent will store the unspent outputs of the transaction
+func (t storeTxn) PutUnspent(txID []byte, outs TxOutputs) error {
+	return t.kv.set(unspentKey(txID), outs.Serialize())
+}
+
+// DeleteUnspent will remove the unspent outputs of the transaction
+func (t storeTxn) DeleteUnspent(txID []byte) error {
+	return t.kv.delete(unspentKey(txID))
+}
+
+// ForEachUnspent will decode every entry of the unspent set
+func (t storeTxn) ForEachUnspent(fn func(txID []byte, outs TxOutputs) error) error {
+	return t.kv.iterate(utxoPrefix, func(key, value []byte) error {
+		return fn(bytes.TrimPrefix(key, utxoPrefix), DeserializeOutputs(value))
+	})
+}
+
+// Meta will return the metadata of the given name
+func (t storeTxn) Meta(name string) ([]byte, error) {
+	return t.kv.get([]byte(name))
+}
+
+// SetMeta will set the metadata of the given name
+func (t storeTxn) SetMeta(name string, value []byte) error {
+	return t.kv.set([]byte(name), value)
+}
+
+// heightKey will return the key of the best block of the height
+func heightKey(height int) []byte {
+	key := make([]byte, len(heightPrefix)+4)
+	copy(key, heightPrefix)
+	binary.BigEndian.PutUint32(key[len(heightPrefix):], uint32(height))
+	return key
+}
+
+// unspentKey will return the key of the unspent outputs of the transaction
+func unspentKey(txID []byte) []byte {
+	return append(append([]byte{}, utxoPrefix...), txID...)
+}
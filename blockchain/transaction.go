@@ -3,63 +3,144 @@ package blockchain
 import (
 	"bytes"
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
-	"encoding/gob"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
-	"math/big"
+	"math"
 	"runtime"
 	"strings"
 	"sync"
 
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/signature"
 	"github.com/Haizza1/go-block/wallet"
+	"github.com/Haizza1/go-block/wire"
+)
+
+var (
+	ErrNegativeValue  = errors.New("the value of an output can not be negative")
+	ErrValueOverflow  = errors.New("the values of the transaction overflow")
+	ErrDuplicateInput = errors.New("the transaction spends the same output twice")
+	ErrUnknownOutput  = errors.New("the input spends an unknown output")
+	ErrNegativeFee    = errors.New("the outputs claim more than the inputs")
 )
 
 type Transaction struct {
-	ID      []byte     // represents the id of the transaction
-	Inputs  []TxInput  // represents the inputs of the transaction
-	Outputs []TxOutput // represents the outputs of the transaction
+	ID       []byte     // represents the id of the transaction
+	Inputs   []TxInput  // represents the inputs of the transaction
+	Outputs  []TxOutput // represents the outputs of the transaction
+	LockTime int64      // represents the height or unix time before which the transaction can not be mined
 }
 
-// Serialze will serialize the transaction struct into bytes
+// Serialze will serialize the transaction with the canonical encoding
+// of the wire package, the id is not part of it
 func (tx *Transaction) Serialize() []byte {
-	var encoded bytes.Buffer
+	w := wire.NewWriter()
+	tx.encode(w, true)
+	return w.Bytes()
+}
 
-	enc := gob.NewEncoder(&encoded)
-	err := enc.Encode(tx)
-	CheckError(err)
+// encode will write the canonical encoding of the transaction, without
+// the witness the unlocking scripts of the inputs are written empty
+func (tx *Transaction) encode(w *wire.Writer, witness bool) {
+	w.Uint32(wire.TxVersion)
+	coinbase := tx.IsCoinBase()
 
-	return encoded.Bytes()
+	w.VarInt(uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		w.VarBytes(in.ID)
+		w.Uint32(uint32(in.Out)) // the -1 of the coinbase becomes 0xffffffff
+		if witness || coinbase {
+			w.VarBytes(in.Script)
+		} else {
+			w.VarBytes(nil)
+		}
+		w.Uint32(in.Sequence)
+	}
+
+	w.VarInt(uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		w.Int64(int64(out.Value))
+		w.VarBytes(out.Script)
+	}
+
+	w.Int64(tx.LockTime)
 }
 
 // Deseraialze transaction will take the chunk of bytes and
 // decoded them into a transaction struct
 func DeserializeTransaction(data []byte) Transaction {
-	var transaction Transaction
+	r := wire.NewReader(data)
+	tx := decodeTransaction(r)
+	CheckError(r.Done())
 
-	decoder := gob.NewDecoder(bytes.NewReader(data))
-	err := decoder.Decode(&transaction)
-	CheckError(err)
+	return tx
+}
 
-	return transaction
+// decodeTransaction will read a canonical transaction and compute its id
+func decodeTransaction(r *wire.Reader) Transaction {
+	var tx Transaction
+	r.Version(wire.TxVersion)
+
+	inputs := r.Count()
+	for i := 0; i < inputs && r.Err() == nil; i++ {
+		in := TxInput{ID: r.VarBytes()}
+		if out := r.Uint32(); out == math.MaxUint32 {
+			in.Out = -1
+		} else {
+			in.Out = int(out)
+		}
+
+		in.Script = r.VarBytes()
+		in.Sequence = r.Uint32()
+		tx.Inputs = append(tx.Inputs, in)
+	}
+
+	outputs := r.Count()
+	for i := 0; i < outputs && r.Err() == nil; i++ {
+		value := r.Int64()
+		tx.Outputs = append(tx.Outputs, TxOutput{Value: int(value), Script: r.VarBytes()})
+	}
+
+	tx.LockTime = r.Int64()
+	if r.Err() == nil {
+		tx.ID = tx.Hash()
+	}
+
+	return tx
 }
 
-// Hash will create a new hash with the transaction data
+// Hash will return the id of the transaction, the hash of its data
+// without the unlocking scripts. The script of the coinbase is kept, it
+// makes the id of every coinbase unique
 func (tx *Transaction) Hash() []byte {
-	var hash [32]byte
+	w := wire.NewWriter()
+	tx.encode(w, false)
+
+	hash := sha256.Sum256(w.Bytes())
+	return hash[:]
+}
 
-	txCopy := *tx
-	txCopy.ID = []byte{}
-	hash = sha256.Sum256(txCopy.Serialize())
+// WitnessHash will return the witness id of the transaction, the hash of
+// all its data including the signatures of the unlocking scripts
+func (tx *Transaction) WitnessHash() []byte {
+	hash := sha256.Sum256(tx.Serialize())
 	return hash[:]
 }
 
 // CoinbasTx will generate the coinbase transaction
 // wich is the first transaction in the chain
 func CoinbaseTx(to, data string) *Transaction {
+	return CoinbaseTxWithFees(to, data, 0)
+}
+
+// CoinbaseTxWithFees will generate a coinbase transaction that also
+// claims the fees of the transactions in the block
+func CoinbaseTxWithFees(to, data string, fees int) *Transaction {
 	if data == "" {
 		randData := make([]byte, 24)
 		_, err := rand.Read(randData)
@@ -67,8 +148,8 @@ func CoinbaseTx(to, data string) *Transaction {
 		data = fmt.Sprintf("%x", randData)
 	}
 
-	txin := TxInput{ID: []byte{}, Out: -1, Signature: nil, PubKey: []byte(data)}
-	txout := NewTXOutput(20, to)
+	txin := TxInput{ID: []byte{}, Out: -1, Script: script.NewBuilder().AddData([]byte(data)).Script(), Sequence: SequenceFinal}
+	txout := NewTXOutput(params.Active.Subsidy+fees, to)
 
 	tx := Transaction{
 		ID:      nil,
@@ -80,40 +161,57 @@ func CoinbaseTx(to, data string) *Transaction {
 	return &tx
 }
 
+type SendOptions struct {
+	Selector CoinSelector // represents the coin selection strategy, nil uses the default
+	Fee      FeePolicy    // represents the fee paid to the miner
+	Exclude  []OutPoint   // represents the coins that must not be spent, like locked ones
+	LockTime int64        // represents the height or unix time before which the transaction can not be mined
+	Sequence uint32       // represents the relative lock of every input, zero leaves the inputs unlocked
+}
+
 // NewTransaction will create a new transacion and validate if the user has enough
 // coins to make the transaction
 func NewTransaction(w *wallet.Wallet, to string, amount int, utxo *UTXOSet) *Transaction {
+	return NewTransactionWithOptions(w, to, amount, utxo, SendOptions{})
+}
+
+// NewTransactionWithOptions will create a new transaction picking the coins
+// with the strategy and the fee policy of the given options
+func NewTransactionWithOptions(w *wallet.Wallet, to string, amount int, utxo *UTXOSet, opts SendOptions) *Transaction {
 	defer HandlePanic()
-	var inputs []TxInput
-	var outputs []TxOutput
 
-	pubKeyHash := wallet.PublicKeyHash(w.PublicKey)
+	builder := NewTxBuilder(w, utxo, opts)
+	if err := builder.AddPayment(to, amount); err != nil {
+		log.Panic("Error: ", err)
+	}
 
-	acc, validOutputs := utxo.FindSpendableOutputs(pubKeyHash, amount)
-	if acc < amount {
-		log.Panic("Error: not enough funds")
+	tx, _, err := builder.Build()
+	if err != nil {
+		log.Panic("Error: ", err)
 	}
 
-	for txid, outs := range validOutputs {
-		txID, err := hex.DecodeString(txid)
-		CheckError(err)
+	return tx
+}
 
-		for _, out := range outs {
-			input := TxInput{ID: txID, Out: out, Signature: nil, PubKey: w.PublicKey}
-			inputs = append(inputs, input)
-		}
+// ExcludeCoins will remove the coins of the given outpoints
+func ExcludeCoins(coins []Coin, exclude []OutPoint) []Coin {
+	if len(exclude) == 0 {
+		return coins
 	}
 
-	from := fmt.Sprintf("%s", w.Address())
-	outputs = append(outputs, *NewTXOutput(amount, to))
-	if acc > amount {
-		outputs = append(outputs, *NewTXOutput(acc-amount, from))
+	excluded := make(map[string]bool)
+	for _, outPoint := range exclude {
+		excluded[outPoint.String()] = true
 	}
 
-	tx := Transaction{ID: nil, Inputs: inputs, Outputs: outputs}
-	tx.ID = tx.Hash()
-	utxo.BlockChain.SingTransaction(&tx, w.PrivateKey)
-	return &tx
+	var kept []Coin
+	for _, coin := range coins {
+		if !excluded[coin.OutPoint.String()] {
+			kept = append(kept, coin)
+		}
+	}
+
+	return kept
 }
 
 // IsCoinbase will determine if the current transaction is a coinbase
@@ -122,7 +220,10 @@ func (tx *Transaction) IsCoinBase() bool {
 	return len(tx.Inputs) == 1 && len(tx.Inputs[0].ID) == 0 && tx.Inputs[0].Out == -1
 }
 
-// Sign will allow to sign and verify the transactions
+// Sign will allow to sign and verify the transactions, every input gets
+// the pay to public key hash unlocking script of the given key signed
+// with SigHashAll. Inputs that spend the schnorr output of the key get
+// its schnorr signature instead
 func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prexTxs map[string]Transaction) {
 	if tx.IsCoinBase() {
 		return
@@ -135,23 +236,81 @@ func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prexTxs map[string]Transac
 		}
 	}
 
-	txCopy := tx.TrimmedCopy()
-
-	for inId, in := range txCopy.Inputs {
-		prevTx := prexTxs[hex.EncodeToString(in.ID)]
-		txCopy.Inputs[inId].Signature = nil
-		txCopy.Inputs[inId].PubKey = prevTx.Outputs[in.Out].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Inputs[inId].PubKey = nil
+	pubKey := wallet.MarshalPubKey(&privKey.PublicKey)
 
-		r, s, err := ecdsa.Sign(rand.Reader, &privKey, txCopy.ID)
+	for inId, in := range tx.Inputs {
+		prevOut := prexTxs[hex.EncodeToString(in.ID)].Outputs[in.Out]
+		sigHash, err := tx.SigHash(inId, prevOut, SigHashAll)
 		CheckError(err)
-		signature := append(r.Bytes(), s.Bytes()...)
-		tx.Inputs[inId].Signature = signature
+
+		if key, ok := script.ExtractSchnorrKey(prevOut.Script); ok && bytes.Equal(key, pubKey) {
+			sig := signHash(signature.Schnorr, privKey, sigHash, SigHashAll)
+			tx.Inputs[inId].Script = script.PayToSchnorrKeyUnlock(sig)
+			continue
+		}
+
+		sig := signHash(signature.ECDSA, privKey, sigHash, SigHashAll)
+		tx.Inputs[inId].Script = script.PayToPubKeyHashUnlock(sig, pubKey)
 	}
 }
 
+// Fee will return the value of the inputs that is not claimed by the
+// outputs, the previous transactions of every input must be given. The
+// values can not be negative or overflow, every input must spend its own
+// existing output and the outputs can not claim more than the inputs
+func (tx *Transaction) Fee(prevTxs map[string]Transaction) (int, error) {
+	if tx.IsCoinBase() {
+		return 0, nil
+	}
+
+	var inputs, outputs int64
+	var err error
+	spent := make(map[string]bool)
+	for inId, in := range tx.Inputs {
+		outPoint := OutPoint{TxID: in.ID, Index: in.Out}.String()
+		if spent[outPoint] {
+			return 0, fmt.Errorf("input %d: %w", inId, ErrDuplicateInput)
+		}
+		spent[outPoint] = true
+
+		prevTx, ok := prevTxs[hex.EncodeToString(in.ID)]
+		if !ok || in.Out < 0 || in.Out >= len(prevTx.Outputs) {
+			return 0, fmt.Errorf("input %d: %w", inId, ErrUnknownOutput)
+		}
+
+		if inputs, err = addValue(inputs, prevTx.Outputs[in.Out].Value); err != nil {
+			return 0, fmt.Errorf("input %d: %w", inId, err)
+		}
+	}
+
+	for outId, out := range tx.Outputs {
+		if outputs, err = addValue(outputs, out.Value); err != nil {
+			return 0, fmt.Errorf("output %d: %w", outId, err)
+		}
+	}
+
+	if outputs > inputs {
+		return 0, ErrNegativeFee
+	}
+
+	return int(inputs - outputs), nil
+}
+
+// addValue will add the value of an output to the sum of the values
+func addValue(sum int64, value int) (int64, error) {
+	if value < 0 {
+		return 0, ErrNegativeValue
+	}
+
+	if sum > math.MaxInt64-int64(value) {
+		return 0, ErrValueOverflow
+	}
+
+	return sum + int64(value), nil
+}
+
 // trimmed copy will return a copy of the given transaction
+// without the unlocking scripts
 func (tx *Transaction) TrimmedCopy() Transaction {
 	var inputs []TxInput
 	var outputs []TxOutput
@@ -162,10 +321,10 @@ func (tx *Transaction) TrimmedCopy() Transaction {
 	go func() {
 		for _, in := range tx.Inputs {
 			inputs = append(inputs, TxInput{
-				ID:        in.ID,
-				Out:       in.Out,
-				Signature: nil,
-				PubKey:    nil,
+				ID:       in.ID,
+				Out:      in.Out,
+				Script:   nil,
+				Sequence: in.Sequence,
 			})
 		}
 
@@ -174,19 +333,25 @@ func (tx *Transaction) TrimmedCopy() Transaction {
 
 	go func() {
 		for _, out := range tx.Outputs {
-			outputs = append(outputs, TxOutput{Value: out.Value, PubKeyHash: out.PubKeyHash})
+			outputs = append(outputs, TxOutput{Value: out.Value, Script: out.Script})
 		}
 
 		wg.Done()
 	}()
 
 	wg.Wait()
-	txCopy := Transaction{ID: tx.ID, Inputs: inputs, Outputs: outputs}
+	txCopy := Transaction{ID: tx.ID, Inputs: inputs, Outputs: outputs, LockTime: tx.LockTime}
 	return txCopy
 }
 
 // Verify will check that the current transaction is valid
 func (tx *Transaction) Verify(prevTxs map[string]Transaction) bool {
+	return tx.verify(prevTxs, nil)
+}
+
+// verify will check the inputs of the transaction, the schnorr signatures
+// are only queued when a batch is given and the caller verifies the batch
+func (tx *Transaction) verify(prevTxs map[string]Transaction, batch *signature.Batch) bool {
 	if tx.IsCoinBase() {
 		return true
 	}
@@ -198,30 +363,8 @@ func (tx *Transaction) Verify(prevTxs map[string]Transaction) bool {
 		}
 	}
 
-	txCopy := tx.TrimmedCopy()
-	curve := elliptic.P256()
-
-	for inId, in := range tx.Inputs {
-		prevTx := prevTxs[hex.EncodeToString(in.ID)]
-		txCopy.Inputs[inId].Signature = nil
-		txCopy.Inputs[inId].PubKey = prevTx.Outputs[in.Out].PubKeyHash
-		txCopy.ID = txCopy.Hash()
-		txCopy.Inputs[inId].PubKey = nil
-
-		r := big.Int{}
-		s := big.Int{}
-		sigLen := len(in.Signature)
-		r.SetBytes(in.Signature[:(sigLen / 2)])
-		s.SetBytes(in.Signature[(sigLen / 2):])
-
-		x := big.Int{}
-		y := big.Int{}
-		keyLen := len(in.PubKey)
-		x.SetBytes(in.PubKey[:(keyLen / 2)])
-		y.SetBytes(in.PubKey[(keyLen / 2):])
-
-		rawPubKey := ecdsa.PublicKey{Curve: curve, X: &x, Y: &y}
-		if !ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) {
+	for inId := range tx.Inputs {
+		if err := tx.verifyInput(inId, prevTxs, batch); err != nil {
 			return false
 		}
 	}
@@ -229,23 +372,55 @@ func (tx *Transaction) Verify(prevTxs map[string]Transaction) bool {
 	return true
 }
 
+// VerifyInput will run the unlocking script of the input against the
+// locking script of the output it spends
+func (tx *Transaction) VerifyInput(inId int, prevTxs map[string]Transaction) error {
+	return tx.verifyInput(inId, prevTxs, nil)
+}
+
+// verifyInput will run the scripts of the input with the given batch
+func (tx *Transaction) verifyInput(inId int, prevTxs map[string]Transaction, batch *signature.Batch) error {
+	in := tx.Inputs[inId]
+	prevTx, ok := prevTxs[hex.EncodeToString(in.ID)]
+	if !ok || in.Out < 0 || in.Out >= len(prevTx.Outputs) {
+		return fmt.Errorf("input %d spends an unknown output", inId)
+	}
+
+	prevOut := prevTx.Outputs[in.Out]
+	checker := &txSigChecker{tx: tx, inId: inId, prevOut: prevOut, batch: batch}
+	if err := script.Verify(in.Script, prevOut.Script, checker); err != nil {
+		return fmt.Errorf("input %d: %w", inId, err)
+	}
+
+	return nil
+}
+
 // String will return a string representation of the transaction
 func (tx Transaction) String() string {
 	var lines []string
 
 	lines = append(lines, fmt.Sprintf("--- Transaction %x:", tx.ID))
+	if !tx.IsCoinBase() {
+		lines = append(lines, fmt.Sprintf("     Witness ID: %x", tx.WitnessHash()))
+	}
 	for i, input := range tx.Inputs {
 		lines = append(lines, fmt.Sprintf("     Input %d:", i))
 		lines = append(lines, fmt.Sprintf("       TXID:     %x", input.ID))
 		lines = append(lines, fmt.Sprintf("       Out:       %d", input.Out))
-		lines = append(lines, fmt.Sprintf("       Signature: %x", input.Signature))
-		lines = append(lines, fmt.Sprintf("       PubKey:    %x", input.PubKey))
+		lines = append(lines, fmt.Sprintf("       Script:    %s", script.Disassemble(input.Script)))
+		if input.Sequence != SequenceFinal {
+			lines = append(lines, fmt.Sprintf("       Sequence:  %#x", input.Sequence))
+		}
 	}
 
 	for i, output := range tx.Outputs {
 		lines = append(lines, fmt.Sprintf("     Output %d:", i))
 		lines = append(lines, fmt.Sprintf("       Value:  %d", output.Value))
-		lines = append(lines, fmt.Sprintf("       Script: %x", output.PubKeyHash))
+		lines = append(lines, fmt.Sprintf("       Script: %s", script.Disassemble(output.Script)))
+	}
+
+	if tx.LockTime != 0 {
+		lines = append(lines, fmt.Sprintf("     LockTime: %d", tx.LockTime))
 	}
 
 	return strings.Join(lines, "\n")
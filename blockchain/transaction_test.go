@@ -0,0 +1,60 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFee(t *testing.T) {
+	prevTx := Transaction{ID: []byte{1}, Outputs: []TxOutput{{Value: 10}, {Value: 5}, {Value: math.MaxInt64}}}
+	prevTxs := map[string]Transaction{hex.EncodeToString(prevTx.ID): prevTx}
+
+	tests := []struct {
+		name    string
+		inputs  []int
+		outputs []int
+		fee     int
+		err     error
+	}{
+		{"fee", []int{0, 1}, []int{12}, 3, nil},
+		{"no fee", []int{0}, []int{10}, 0, nil},
+		{"negative fee", []int{0}, []int{11}, 0, ErrNegativeFee},
+		{"negative output", []int{1}, []int{-10, 10}, 0, ErrNegativeValue},
+		{"duplicate input", []int{0, 0}, []int{20}, 0, ErrDuplicateInput},
+		{"index past the outputs", []int{3}, []int{1}, 0, ErrUnknownOutput},
+		{"negative index", []int{-2}, []int{1}, 0, ErrUnknownOutput},
+		{"input overflow", []int{0, 2}, []int{1}, 0, ErrValueOverflow},
+		{"output overflow", []int{0}, []int{math.MaxInt64, 1}, 0, ErrValueOverflow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := Transaction{}
+			for _, out := range test.inputs {
+				tx.Inputs = append(tx.Inputs, TxInput{ID: prevTx.ID, Out: out})
+			}
+
+			for _, value := range test.outputs {
+				tx.Outputs = append(tx.Outputs, TxOutput{Value: value})
+			}
+
+			fee, err := tx.Fee(prevTxs)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if fee != test.fee {
+				t.Fatalf("got fee %d, want %d", fee, test.fee)
+			}
+		})
+	}
+}
+
+func TestFeeUnknownTransaction(t *testing.T) {
+	tx := Transaction{Inputs: []TxInput{{ID: []byte{2}, Out: 0}}, Outputs: []TxOutput{{Value: 1}}}
+	if _, err := tx.Fee(map[string]Transaction{}); !errors.Is(err, ErrUnknownOutput) {
+		t.Fatalf("got error %v, want %v", err, ErrUnknownOutput)
+	}
+}
@@ -2,68 +2,146 @@ package blockchain
 
 import (
 	"bytes"
-	"encoding/gob"
 
+	"github.com/Haizza1/go-block/script"
 	"github.com/Haizza1/go-block/wallet"
+	"github.com/Haizza1/go-block/wire"
 )
 
 type TxOutput struct {
-	Value      int    // represents the value in tokens
-	PubKeyHash []byte // represents the public key
+	Value  int    // represents the value in tokens
+	Script []byte // represents the locking script that guards the value
 }
 
 type TxOutputs struct {
 	Outputs []TxOutput // represents the outputs in the list of outputs
+	Indexes []int      // represents the index of each output in its transaction
 }
 
 type TxInput struct {
-	ID        []byte // represents the transaction that the output is
-	Out       int    // represents the index where the output appears
-	Signature []byte // represents the data wich is use in the output pubkey
-	PubKey    []byte // represents the public used in the transaction
+	ID       []byte // represents the transaction that the output is
+	Out      int    // represents the index where the output appears
+	Script   []byte // represents the unlocking script, arbitrary data for the coinbase
+	Sequence uint32 // represents the relative lock of the input, SequenceFinal has none
 }
 
 // NewTXOuput will generate a new output instance
 func NewTXOutput(value int, address string) *TxOutput {
-	txo := &TxOutput{Value: value, PubKeyHash: nil}
+	txo := &TxOutput{Value: value, Script: nil}
 	txo.Lock([]byte(address))
 
 	return txo
 }
 
-// serialize will serialize the outputs struct into bytes
+// Add will append the output with its index in the transaction
+func (outs *TxOutputs) Add(index int, out TxOutput) {
+	outs.Outputs = append(outs.Outputs, out)
+	outs.Indexes = append(outs.Indexes, index)
+}
+
+// Index will return the index in the transaction of the output at the
+// given position. Sets stored without indexes hold every output in order
+func (outs TxOutputs) Index(pos int) int {
+	if pos < len(outs.Indexes) {
+		return outs.Indexes[pos]
+	}
+
+	return pos
+}
+
+// serialize will serialize the outputs struct with the canonical encoding
+// of the wire package, every output keeps its index in the transaction
 func (outs TxOutputs) Serialize() []byte {
-	var buff bytes.Buffer
-	encode := gob.NewEncoder(&buff)
-	err := encode.Encode(outs)
-	CheckError(err)
-	return buff.Bytes()
+	w := wire.NewWriter()
+	w.VarInt(uint64(len(outs.Outputs)))
+	for pos, out := range outs.Outputs {
+		w.Uint32(uint32(outs.Index(pos)))
+		w.Int64(int64(out.Value))
+		w.VarBytes(out.Script)
+	}
+
+	return w.Bytes()
 }
 
 // Deserialize will deserialize a chunk of bytes into a TxOutputs struct
 func DeserializeOutputs(data []byte) TxOutputs {
 	var outputs TxOutputs
-	decode := gob.NewDecoder(bytes.NewReader(data))
-	err := decode.Decode(&outputs)
-	CheckError(err)
+	r := wire.NewReader(data)
+
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		index := r.Uint32()
+		value := r.Int64()
+		outputs.Add(int(index), TxOutput{Value: int(value), Script: r.VarBytes()})
+	}
+
+	CheckError(r.Done())
 	return outputs
 }
 
-// UsesKey will check if the given publickey in equal
-// to the transaction input pubkey hashed
+// UsesKey will check if the input unlocks with the public key of the given hash
 func (in *TxInput) UsesKey(pubKeyHash []byte) bool {
-	lockingHash := wallet.PublicKeyHash(in.PubKey)
+	_, pubKey, ok := script.ExtractSigPubKey(in.Script)
+	if !ok {
+		return false
+	}
+
+	lockingHash := wallet.PublicKeyHash(pubKey)
 	return bytes.Equal(lockingHash, pubKeyHash)
 }
 
-// Lock will set a hashed pubkey with base58 algorithm to the output
+// Lock will set the locking script of the base58 address to the output
 func (out *TxOutput) Lock(address []byte) {
-	pubKeyHash := wallet.Base58Decode(address)
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
-	out.PubKeyHash = pubKeyHash
+	locking, err := LockingScript(string(address))
+	CheckError(err)
+	out.Script = locking
+}
+
+// LockingScript will return the script that locks coins to the address,
+// pay to public key hash, pay to script hash or pay to schnorr key
+// depending on its version
+func LockingScript(address string) ([]byte, error) {
+	version, hash, err := wallet.DecodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case wallet.ScriptHashVersion:
+		return script.PayToScriptHash(hash), nil
+	case wallet.SchnorrKeyVersion:
+		return script.PayToSchnorrKey(hash), nil
+	}
+
+	return script.PayToPubKeyHash(hash), nil
+}
+
+// PubKeyHash will return the public key hash the output pays to, nil when
+// the output is not locked with a pay to public key hash script
+func (out *TxOutput) PubKeyHash() []byte {
+	pubKeyHash, _ := script.ExtractPubKeyHash(out.Script)
+	return pubKeyHash
+}
+
+// Address will return the address the output pays to, empty when the
+// locking script is not a standard one
+func (out *TxOutput) Address() string {
+	if pubKeyHash, ok := script.ExtractPubKeyHash(out.Script); ok {
+		return string(wallet.EncodeAddress(wallet.PubKeyHashVersion, pubKeyHash))
+	}
+
+	if scriptHash, ok := script.ExtractScriptHash(out.Script); ok {
+		return string(wallet.EncodeAddress(wallet.ScriptHashVersion, scriptHash))
+	}
+
+	if pubKey, ok := script.ExtractSchnorrKey(out.Script); ok {
+		return string(wallet.SchnorrKeyAddress(pubKey))
+	}
+
+	return ""
 }
 
-// IsLocked with key will check is the given hash has been locked
+// IsLocked with key will check is the output pays to the given hash
 func (out *TxOutput) IsLockedWithKey(pubKeyHash []byte) bool {
-	return bytes.Equal(out.PubKeyHash, pubKeyHash)
+	return bytes.Equal(out.PubKeyHash(), pubKeyHash)
 }
@@ -3,16 +3,6 @@ package blockchain
 import (
 	"bytes"
 	"encoding/hex"
-
-	"github.com/dgraph-io/badger/v3"
-)
-
-var (
-	// this variables helps to distinguish
-	// the data atach to the utxos set and
-	// the data atach to the blockcahin itself
-	utxoPrefix = []byte("utxo-")
-	// prefixLength = len(utxoPrefix)
 )
 
 // unspent transaction set
@@ -21,241 +11,198 @@ type UTXOSet struct {
 }
 
 // Find unspent transaction outputs will return all the unspent
-// outputs of the given public key
-func (u UTXOSet) FindUTXO(pubKeyHash []byte) []TxOutput {
-	var Utxo []TxOutput
-	db := u.BlockChain.Database
+// outputs locked with the given script
+func (u UTXOSet) FindUTXO(locking []byte) []TxOutput {
+	Utxo, err := u.FindUnspentOutputs(locking)
+	CheckError(err)
 
-	err := db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	return Utxo
+}
 
-		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
-			item := it.Item()
-			v, err := item.ValueCopy(nil)
-			CheckError(err)
-			outs := DeserializeOutputs(v)
+// FindUnspentOutputs will return all the unspent outputs locked with the
+// given script, or the error of the store
+func (u UTXOSet) FindUnspentOutputs(locking []byte) ([]TxOutput, error) {
+	var Utxo []TxOutput
 
+	err := u.BlockChain.Store.View(func(txn StoreTxn) error {
+		return txn.ForEachUnspent(func(txID []byte, outs TxOutputs) error {
 			for _, out := range outs.Outputs {
-				if out.IsLockedWithKey(pubKeyHash) {
+				if bytes.Equal(out.Script, locking) {
 					Utxo = append(Utxo, out)
 				}
 			}
-		}
 
-		return nil
+			return nil
+		})
 	})
 
+	return Utxo, err
+}
+
+// SpendableCoins will return every unspent output locked with the given
+// script together with the outpoint that spends it, the coinbases that
+// have not matured are left out
+func (u UTXOSet) SpendableCoins(locking []byte) []Coin {
+	coins, err := u.FindSpendableCoins(locking)
 	CheckError(err)
-	return Utxo
+
+	return coins
 }
 
-// Find Spendable outputs will enable create normal transactions wich are not coinbase transactions
-// this function will ensure that the user have the coins to make the transaction. something like
-// the amount of coins that the user have
-func (u UTXOSet) FindSpendableOutputs(pubKeyHash []byte, amount int) (int, map[string][]int) {
-	unspentOuts := make(map[string][]int)
-	accumulated := 0
-	db := u.BlockChain.Database
+// FindSpendableCoins will return the coins of SpendableCoins, or the
+// error of the store
+func (u UTXOSet) FindSpendableCoins(locking []byte) ([]Coin, error) {
+	var coins []Coin
+	immature, err := u.BlockChain.immatureCoinbases()
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.BlockChain.Store.View(func(txn StoreTxn) error {
+		return txn.ForEachUnspent(func(txID []byte, outs TxOutputs) error {
+			if immature[hex.EncodeToString(txID)] {
+				return nil
+			}
+
+			for pos, out := range outs.Outputs {
+				if bytes.Equal(out.Script, locking) {
+					outPoint := OutPoint{TxID: txID, Index: outs.Index(pos)}
+					coins = append(coins, Coin{OutPoint: outPoint, Output: out})
+				}
+			}
 
-	err := db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
+			return nil
+		})
+	})
 
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	return coins, err
+}
 
-		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
-			item := it.Item()
-			key := item.Key()
+// FindOutput will return the output of the outpoint when it is unspent
+func (u UTXOSet) FindOutput(op OutPoint) (TxOutput, bool) {
+	output, found, err := u.UnspentOutput(op)
+	CheckError(err)
 
-			v, err := item.ValueCopy(nil)
-			CheckError(err)
+	return output, found
+}
 
-			k := bytes.TrimPrefix(key, utxoPrefix)
-			txID := hex.EncodeToString(k)
-			outs := DeserializeOutputs(v)
+// UnspentOutput will return the output of the outpoint when it is unspent,
+// or the error of the store
+func (u UTXOSet) UnspentOutput(op OutPoint) (TxOutput, bool, error) {
+	var output TxOutput
+	found := false
 
-			for outIdx, out := range outs.Outputs {
-				if out.IsLockedWithKey(pubKeyHash) && accumulated < amount {
-					accumulated += out.Value
-					unspentOuts[txID] = append(unspentOuts[txID], outIdx)
-				}
+	err := u.BlockChain.Store.View(func(txn StoreTxn) error {
+		outs, err := txn.Unspent(op.TxID)
+		if err == ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		for pos, out := range outs.Outputs {
+			if outs.Index(pos) == op.Index {
+				output, found = out, true
 			}
 		}
 
 		return nil
 	})
 
-	CheckError(err)
-	return accumulated, unspentOuts
+	return output, found, err
 }
 
 // count transactins will count all the transactions of
 // unspent transactions outputs in the blockchain
 func (u UTXOSet) CountTransactions() int {
-	db := u.BlockChain.Database
 	counter := 0
 
-	err := db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
+	err := u.BlockChain.Store.View(func(txn StoreTxn) error {
+		return txn.ForEachUnspent(func(txID []byte, outs TxOutputs) error {
 			counter++
-		}
-
-		return nil
+			return nil
+		})
 	})
 
 	CheckError(err)
 	return counter
 }
 
-// update will update the unspent transactions set in the badger db
+// update will update the unspent transactions set in the store
 func (u *UTXOSet) Update(block *Block) {
-	defer HandlePanic()
+	CheckError(u.update(block))
+}
 
-	db := u.BlockChain.Database
-	err := db.Update(func(txn *badger.Txn) error {
+// update will spend the inputs of the block and add its outputs to the
+// unspent set in one store transaction
+func (u *UTXOSet) update(block *Block) error {
+	return u.BlockChain.Store.Update(func(txn StoreTxn) error {
 		for _, tx := range block.Transactions {
 			if !tx.IsCoinBase() {
 				for _, in := range tx.Inputs {
 					updateOuts := TxOutputs{}
-					inID := append(utxoPrefix, in.ID...)
 
-					item, err := txn.Get(inID)
-					CheckError(err)
-
-					v, err := item.ValueCopy(nil)
-					CheckError(err)
-
-					outs := DeserializeOutputs(v)
+					outs, err := txn.Unspent(in.ID)
+					if err != nil {
+						return err
+					}
 
-					for outIdx, out := range outs.Outputs {
-						if outIdx != in.Out {
-							updateOuts.Outputs = append(updateOuts.Outputs, out)
+					for pos, out := range outs.Outputs {
+						if outs.Index(pos) != in.Out {
+							updateOuts.Add(outs.Index(pos), out)
 						}
 					}
 
 					if len(updateOuts.Outputs) == 0 {
-						if err := txn.Delete(inID); err != nil {
-							panic(err.Error())
+						if err := txn.DeleteUnspent(in.ID); err != nil {
+							return err
 						}
 					} else {
-						if err := txn.Set(inID, updateOuts.Serialize()); err != nil {
-							panic(err.Error())
+						if err := txn.PutUnspent(in.ID, updateOuts); err != nil {
+							return err
 						}
 					}
 				}
 			}
 
 			newOutputs := TxOutputs{}
-			newOutputs.Outputs = append(newOutputs.Outputs, tx.Outputs...)
+			for outIdx, out := range tx.Outputs {
+				if !out.IsUnspendable() {
+					newOutputs.Add(outIdx, out)
+				}
+			}
 
-			txID := append(utxoPrefix, tx.ID...)
-			if err := txn.Set(txID, newOutputs.Serialize()); err != nil {
-				panic(err.Error())
+			if len(newOutputs.Outputs) == 0 {
+				continue
+			}
+
+			if err := txn.PutUnspent(tx.ID, newOutputs); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
-	CheckError(err)
 }
 
-// Reindex will delete all the data with the utxoprefix
-// and the rebuild the set inside of the db
+// Reindex will clear the unspent set of the store
+// and the rebuild it from the blocks
 func (u UTXOSet) Reindex() {
-	db := u.BlockChain.Database
-	u.DeleteByPrefix(utxoPrefix)
+	CheckError(u.BlockChain.Store.ClearUnspent())
 	utxo := u.BlockChain.FindUnspentTransactions()
 
-	err := db.Update(func(txn *badger.Txn) error {
+	err := u.BlockChain.Store.Update(func(txn StoreTxn) error {
 		for txId, outs := range utxo {
 			key, err := hex.DecodeString(txId)
 			if err != nil {
 				return err
 			}
 
-			key = append(utxoPrefix, key...)
-			err = txn.Set(key, outs.Serialize())
+			err = txn.PutUnspent(key, outs)
 			CheckError(err)
 		}
 
-		return badger.ErrNilCallback
+		return nil
 	})
 
 	CheckError(err)
 }
-
-// DeleteByPrefix will delete all the data in badger db with the given prefix
-func (u *UTXOSet) DeleteByPrefix(prefix []byte) {
-
-	// grafullt shutdown the system if we panic
-	defer HandlePanic()
-
-	// delete all given keys
-	deleteKeys := func(keysForDelete [][]byte) error {
-		if err := u.BlockChain.Database.Update(func(txn *badger.Txn) error {
-			for _, key := range keysForDelete {
-				if err := txn.Delete(key); err != nil {
-					return err
-				}
-			}
-
-			return nil
-		}); err != nil {
-			return err
-		}
-
-		return nil
-	}
-
-	// badger max collect size
-	collectSize := 100000
-
-	// read only function to get keys to be deleted
-	u.BlockChain.Database.View(func(txn *badger.Txn) error {
-
-		// set iterator optinos
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		// collect all the keys that will be deleted
-		keysForDelete := make([][]byte, 0, collectSize)
-
-		// counter of the keys collected to ensure we dont pass badger max collect size
-		keysCollected := 0
-
-		// loop until we hit the max badger collect size
-		// if we hit that maximum we delete all the keys collected
-		// and restart the loop setting to 0 the keys for delete
-		// and the keysCollected until we dont hit the max collectedsize
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			key := it.Item().KeyCopy(nil)
-			keysForDelete = append(keysForDelete, key)
-			keysCollected++
-
-			if keysCollected == collectSize {
-				if err := deleteKeys(keysForDelete); err != nil {
-					panic(err)
-				}
-
-				keysForDelete = make([][]byte, 0, collectSize)
-				keysCollected = 0
-			}
-		}
-
-		// remove all the keys that left for delete in the for loop
-		if keysCollected > 0 {
-			if err := deleteKeys(keysForDelete); err != nil {
-				panic(err)
-			}
-		}
-
-		return nil
-	})
-}
@@ -0,0 +1,179 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/Haizza1/go-block/signature"
+)
+
+var (
+	ErrInvalidTx   = errors.New("the transaction is not valid")
+	ErrSpentOutput = errors.New("the input spends an output that is already spent")
+)
+
+// inputCheck is the verification of one input of a transaction
+type inputCheck struct {
+	tx      *Transaction           // represents the transaction being verified
+	inId    int                    // represents the input being verified
+	prevTxs map[string]Transaction // represents the transactions spent by the transaction
+	key     string                 // represents the key of the input in the signature cache
+}
+
+// VerifyTransaction will check if the given transaction is valid
+func (chain *BlockChain) VerifyTransaction(tx *Transaction) bool {
+	return chain.VerifyTransactions([]*Transaction{tx})
+}
+
+// VerifyTransactions will check the transactions of a block. The values
+// of every transaction are checked first, every input must spend an
+// output that is unspent at the tip and no two of them may spend the
+// same output. The inputs are verified by a pool of workers that
+// stops at the first invalid one, the schnorr signatures of all of them
+// are verified together in one batch. Inputs found in the signature
+// cache are skipped and the verified ones are added to it
+func (chain *BlockChain) VerifyTransactions(txs []*Transaction) bool {
+	var checks []inputCheck
+	spent := make(map[string]bool)
+
+	for _, tx := range txs {
+		if tx.IsCoinBase() {
+			continue
+		}
+
+		if tx.checkDataOutputs() != nil {
+			return false
+		}
+
+		prevTxs, err := chain.unspentPrevTransactions(tx)
+		if err != nil {
+			return false
+		}
+
+		if _, err := tx.Fee(prevTxs); err != nil {
+			return false
+		}
+
+		wtxid := tx.WitnessHash()
+		for inId, in := range tx.Inputs {
+			outPoint := OutPoint{TxID: in.ID, Index: in.Out}.String()
+			if spent[outPoint] {
+				return false
+			}
+			spent[outPoint] = true
+
+			key := sigCacheKey(wtxid, inId)
+			if !sigCache.Contains(key) {
+				checks = append(checks, inputCheck{tx: tx, inId: inId, prevTxs: prevTxs, key: key})
+			}
+		}
+	}
+
+	batch := signature.NewBatch()
+	if !verifyInputs(checks, batch) || !batch.Verify() {
+		return false
+	}
+
+	for _, check := range checks {
+		sigCache.Add(check.key)
+	}
+
+	return true
+}
+
+// unspentPrevTransactions will find the transactions spent by the inputs
+// of the given one walking back from the tip. An output spent by a block
+// of the chain is found before the block that created it, so the walk
+// stops once every spent transaction was found
+func (chain *BlockChain) unspentPrevTransactions(tx *Transaction) (map[string]Transaction, error) {
+	inputs := make(map[string]int)
+	missing := make(map[string]bool)
+	for inId, in := range tx.Inputs {
+		inputs[OutPoint{TxID: in.ID, Index: in.Out}.String()] = inId
+		missing[hex.EncodeToString(in.ID)] = true
+	}
+
+	prevTxs := make(map[string]Transaction)
+	iter := chain.Iterator()
+	for len(missing) > 0 && len(iter.CurrentHash) > 0 {
+		block, err := iter.NextBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blockTx := range block.Transactions {
+			if !blockTx.IsCoinBase() {
+				for _, in := range blockTx.Inputs {
+					if inId, ok := inputs[OutPoint{TxID: in.ID, Index: in.Out}.String()]; ok {
+						return nil, fmt.Errorf("input %d: %w", inId, ErrSpentOutput)
+					}
+				}
+			}
+
+			if id := hex.EncodeToString(blockTx.ID); missing[id] {
+				prevTxs[id] = *blockTx
+				delete(missing, id)
+			}
+		}
+	}
+
+	for inId, in := range tx.Inputs {
+		if missing[hex.EncodeToString(in.ID)] {
+			return nil, fmt.Errorf("input %d: %w", inId, ErrUnknownOutput)
+		}
+	}
+
+	return prevTxs, nil
+}
+
+// verifyInputs will run the checks on up to one worker per cpu, the
+// workers stop taking checks once one of them fails
+func verifyInputs(checks []inputCheck, batch *signature.Batch) bool {
+	if len(checks) == 0 {
+		return true
+	}
+
+	workers := runtime.NumCPU()
+	if workers > len(checks) {
+		workers = len(checks)
+	}
+
+	jobs := make(chan inputCheck)
+	failed := make(chan struct{})
+	var once sync.Once
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for check := range jobs {
+				if check.tx.verifyInput(check.inId, check.prevTxs, batch) != nil {
+					once.Do(func() { close(failed) })
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, check := range checks {
+		select {
+		case jobs <- check:
+		case <-failed:
+			break feed
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case <-failed:
+		return false
+	default:
+		return true
+	}
+}
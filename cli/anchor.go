@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/script"
+)
+
+// anchor will store the hash of a document in a data output so the
+// chain proves the document existed at the time of the block
+func (cli *CommandLine) anchor(from, hash, file string, fee int, nodeID string, mineNow bool) {
+	cli.validateAddress(from)
+	data := documentHash(hash, file)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	opts := blockchain.SendOptions{Fee: blockchain.FeePolicy{Base: fee}}
+	wallet := spendingWallet(from, nodeID, &opts)
+	builder := blockchain.NewTxBuilder(&wallet, UTXOSet, opts)
+	builder.From = from
+	if err := builder.AddData(data); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	tx, _, err := builder.Build()
+	if err != nil {
+		fmt.Println("Error:", err)
+		runtime.Goexit()
+	}
+
+	submitTx(chain, UTXOSet, tx, from, mineNow)
+	fmt.Printf("Anchored %x in transaction %x\n", data, tx.ID)
+}
+
+// findAnchor will print the block that anchored the hash of a document
+func (cli *CommandLine) findAnchor(hash, file, nodeID string) {
+	data := documentHash(hash, file)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Store.Close()
+
+	anchor, err := chain.FindData(data)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("Data:        %x\n", data)
+	fmt.Printf("Transaction: %x\n", anchor.Transaction.ID)
+	fmt.Printf("Block:       %x\n", anchor.Block.Hash)
+	fmt.Printf("Height:      %d\n", anchor.Block.Heigth)
+	fmt.Printf("Time:        %s\n", time.Unix(anchor.Block.TimeStamp, 0).Format(time.RFC3339))
+}
+
+// documentHash will decode the given hex hash or hash the file with sha256
+func documentHash(hash, file string) []byte {
+	if file == "" {
+		data, err := hex.DecodeString(hash)
+		if err != nil || len(data) == 0 || len(data) > script.MaxDataSize {
+			fmt.Printf("The hash must be at most %d hex encoded bytes\n", script.MaxDataSize)
+			runtime.Goexit()
+		}
+
+		return data
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	return hasher.Sum(nil)
+}
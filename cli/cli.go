@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/Haizza1/go-block/blockchain"
 	"github.com/Haizza1/go-block/network"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/rpc"
 	"github.com/Haizza1/go-block/wallet"
 )
 
@@ -17,25 +20,88 @@ type CommandLine struct{}
 
 // print usage will print the cli usage
 func (cli *CommandLine) printUsage() {
-	fmt.Println("Usage:")
+	fmt.Println("Usage: [-network mainnet|testnet|regtest] [-datadir <DIR>] <COMMAND>")
 	fmt.Println("	getbalance -address <ADDRESS> - get the balance for the given address")
-	fmt.Println(" 	createBlockchain -address <ADDRESS> create a blockchain with the given address")
+	fmt.Println("	rpc -method <METHOD> -params <JSON ARRAY> - call a method of the json-rpc api of the running node")
+	fmt.Println("		getblock, getblockhash, getbestblockhash, gettransaction, getbalance, sendtoaddress, walletpassphrase, walletlock, getmempoolinfo, getpeerinfo, generate, stop")
+	fmt.Println(" 	createBlockchain -address <ADDRESS> create a blockchain from the genesis block of the network, the first block pays the address")
+	fmt.Println("	generate -n <BLOCKS> -to <ADDRESS> - mine blocks that pay the reward to the address right away, only on regtest")
+	fmt.Println("	creategenesis -spec <FILE> -out <FILE> - mine the genesis block of a json spec and write the spec with its nonce and hash")
 	fmt.Println(" 	printchain - Prints the blocks in the Blockchain")
 	fmt.Println(" 	send -from <FROM> -to <TO> -amount <AMOUNT> -mine - Send Send amount of coins")
-	fmt.Println("	createWallet - creates a new Wallet")
+	fmt.Println("		-strategy default|largest|bnb|random -nomix -fee <FEE> -feeperinput <FEE> -exclude <TXID:INDEX,...> -locktime <HEIGHT|UNIX>")
+	fmt.Println("	sendmany -from <FROM> -file <CSV|JSON> -mine - Send coins to every recipient of the file in one transaction")
+	fmt.Println("	listunspent -address <ADDRESS> - list the unspent outputs of the address")
+	fmt.Println("	lockunspent -outpoint <TXID:INDEX> -unlock - lock or unlock an output for coin selection")
+	fmt.Println("	listlockunspent - list the locked outputs")
+	fmt.Println("	createWallet -mnemonic - creates a new Wallet address, -mnemonic prints the backup phrase")
+	fmt.Println("	restoreWallet -mnemonic <WORDS> -passphrase <PASS> - restores the wallet from its backup phrase")
+	fmt.Println("	encryptwallet - encrypts the wallet file with a passphrase")
+	fmt.Println("	walletpassphrase -timeout <SECONDS> - unlocks the wallet of the running node for the given time")
+	fmt.Println("	walletlock - locks the wallet of the running node")
+	fmt.Println("	getpubkey -address <ADDRESS> - print the public key of an address of the wallet")
+	fmt.Println("	createmultisig -required <M> -pubkeys <KEY,KEY,...> - create an address that needs M signatures of the keys")
+	fmt.Println("	getschnorraddress -address <ADDRESS> - print the address that pays to the key of an address with a schnorr output")
+	fmt.Println("	createmusig -pubkeys <KEY,KEY,...> - create the schnorr address of the keys aggregated with musig, every holder must sign")
+	fmt.Println("	createtimelock -pubkey <KEY> -locktime <HEIGHT|UNIX> | -blocks <N> | -seconds <N> - create an address the key can spend once the lock is reached")
+	fmt.Println("	createtx -from <FROM> -to <TO> -amount <AMOUNT> -out <FILE> -fee <FEE> -redeem <HEX> -locktime <HEIGHT|UNIX> - write an unsigned transaction, no keys needed")
+	fmt.Println("		without -from only the payment is written, the contributors add the inputs with fundtx")
+	fmt.Println("	fundtx -in <FILE> -from <FROM> -amount <AMOUNT> -out <FILE> - add coins worth exactly the amount to the transaction")
+	fmt.Println("	musignonce -in <FILE> -out <FILE> - add the nonces of the wallet to the musig inputs, combine them before signing")
+	fmt.Println("	signtx -in <FILE> -out <FILE> -sighash ALL|NONE|SINGLE[|ANYONECANPAY] - add the signatures of the wallet, the blockchain is not needed")
+	fmt.Println("	combinetx -in <FILE,FILE,...> -out <FILE> - merge the signatures of transactions signed apart, or the inputs signed with ANYONECANPAY")
+	fmt.Println("	broadcasttx -in <FILE> -mine - send the transaction once it has enough signatures")
+	fmt.Println("	initiateswap -from <FROM> -participant <KEY> -amount <AMOUNT> -locktime <HEIGHT|UNIX> -mine - lock coins in a new atomic swap contract")
+	fmt.Println("	participateswap -from <FROM> -initiator <KEY> -secrethash <HASH> -amount <AMOUNT> -locktime <HEIGHT|UNIX> -mine - lock coins in the other side of a swap")
+	fmt.Println("	redeemswap -contract <HEX> -secret <SECRET> -to <ADDRESS> -fee <FEE> -mine - claim the coins of a contract with the secret")
+	fmt.Println("	refundswap -contract <HEX> -to <ADDRESS> -fee <FEE> -mine - take back the coins of an expired contract")
+	fmt.Println("	auditswap -contract <HEX> - print the terms and the locked coins of a contract")
+	fmt.Println("	extractsecret -contract <HEX> - print the secret revealed by the redemption of a contract")
+	fmt.Println("	anchor -from <FROM> -hash <HEX> | -file <PATH> -fee <FEE> -mine - store the hash of a document in the blockchain")
+	fmt.Println("	findanchor -hash <HEX> | -file <PATH> - print the block that anchored the hash of a document")
 	fmt.Println("	listaddresses - list the address in our wallet file")
 	fmt.Println("	reindex - Rebuilds The unspent transactions outputs set")
-	fmt.Println(" 	startnode -miner ADDRESS - Start a node with ID specified in NODE_ID env. var. -miner enables mining")
+	fmt.Println(" 	startnode -miner ADDRESS -rpcport <PORT> -rest -explorer - Start a node with ID specified in NODE_ID env. var. -miner enables mining")
+	fmt.Println("		the node listens on the NODE_ID port, the seed node of mainnet, testnet and regtest uses 3000, 13000 and 23000")
+	fmt.Println("		the json-rpc api listens on localhost, on the NODE_ID port plus 1000 by default, with the credentials of the cookie in the data directory")
+	fmt.Println("		-rest serves /rest/block/<HASH>, /rest/tx/<TXID>, /rest/headers/<COUNT>/<HASH>, /rest/getutxos/<TXID>-<INDEX>/... and /rest/chaininfo")
+	fmt.Println("		without credentials, add .json, .bin or .hex to the path")
+	fmt.Println("		and streams the events of the node as server-sent events at /rest/events?topics=<TOPIC,...>&addresses=<ADDRESS,...>")
+	fmt.Println("		topics: blockconnected, blockdisconnected, txaccepted, txremoved, peerconnected")
+	fmt.Println("		-explorer serves web pages to browse the blocks, transactions and addresses at /explorer/")
+	fmt.Println("		while the node runs getbalance, send and generate call it, send uses the wallet of the node and only takes -fee")
 }
 
-// validateArgs will check if args were given
-func (cli *CommandLine) validateArgs() {
-	if len(os.Args) < 2 {
+// validateArgs will check if a command was given
+func (cli *CommandLine) validateArgs(args []string) {
+	if len(args) < 1 {
 		cli.printUsage()
 		runtime.Goexit()
 	}
 }
 
+// parseGlobalFlags will read the options that go before the command, the
+// network and the data directory, and return the command with its args
+func (cli *CommandLine) parseGlobalFlags() []string {
+	globalCmd := flag.NewFlagSet("goblock", flag.ExitOnError)
+	dataDir := globalCmd.String("datadir", params.DataDir, "The directory that holds the data of every network")
+	netName := globalCmd.String("network", params.Active.Name, "The network to run on: mainnet, testnet or regtest")
+
+	err := globalCmd.Parse(os.Args[1:])
+	blockchain.CheckError(err)
+
+	net, err := params.Lookup(*netName)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	params.Active = net
+	params.DataDir = *dataDir
+	network.KnownNodes = []string{net.SeedNode()}
+	return globalCmd.Args()
+}
+
 // validateAddress will check if the given address is valid
 func (cli *CommandLine) validateAddress(address string) {
 	if !wallet.ValidateAddress(address) {
@@ -45,7 +111,7 @@ func (cli *CommandLine) validateAddress(address string) {
 }
 
 // Start node will start the node in the blockchain network
-func (cli *CommandLine) StartNode(nodeID, minerAddress string) {
+func (cli *CommandLine) StartNode(nodeID, minerAddress string, opts network.HTTPOptions) {
 	fmt.Printf("Starting Node... %s\n", nodeID)
 
 	if len(minerAddress) > 0 {
@@ -57,14 +123,14 @@ func (cli *CommandLine) StartNode(nodeID, minerAddress string) {
 		}
 	}
 
-	network.StartServer(nodeID, minerAddress)
+	network.StartServer(nodeID, minerAddress, opts)
 }
 
 // reindex unspent transactions will call the reindex method
 // on the UtxoSet
 func (cli *CommandLine) reindexUTXO(nodeID string) {
 	chain := blockchain.ContinueBlockChain(nodeID)
-	defer chain.Database.Close()
+	defer chain.Store.Close()
 	UTXSet := blockchain.UTXOSet{BlockChain: chain}
 	UTXSet.Reindex()
 
@@ -82,18 +148,134 @@ func (cli *CommandLine) listAddresses(nodeId string) {
 	}
 }
 
+// openWallets will load the wallet file of the node, a node without one
+// gets an empty wallet. A file that can not be read stops the command
+func openWallets(nodeID string) *wallet.Wallets {
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	return wallets
+}
+
 // createWallet will create new wallet and saved to the wallet file
-func (cli *CommandLine) createWallet(nodeID string) {
-	wallets, _ := wallet.CreateWallets(nodeID)
+func (cli *CommandLine) createWallet(nodeID string, showMnemonic bool) {
+	wallets := openWallets(nodeID)
+	if err := unlockWallets(wallets); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
 	address := wallets.AddWallet()
 	wallets.SaveFile(nodeID)
 	fmt.Printf("New address is: %s\n", address)
+
+	if showMnemonic {
+		fmt.Println("Write down the backup phrase and keep it safe:")
+		fmt.Println(wallets.Mnemonic)
+	}
+}
+
+// encryptWallet will encrypt the wallet file with a new passphrase
+func (cli *CommandLine) encryptWallet(nodeID string) {
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("No wallet found, create one first")
+		runtime.Goexit()
+	}
+
+	passphrase := readPassphrase("New passphrase: ")
+	if passphrase == "" || passphrase != readPassphrase("Repeat passphrase: ") {
+		fmt.Println("The passphrases are empty or do not match")
+		runtime.Goexit()
+	}
+
+	if err := wallets.Encrypt(passphrase); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets.SaveFile(nodeID)
+	fmt.Println("Wallet encrypted, keep the passphrase safe")
+}
+
+// walletPassphrase will unlock the wallet of the running node for the
+// given time, the passphrase goes through the authenticated rpc api
+func (cli *CommandLine) walletPassphrase(nodeID string, timeout int) {
+	client, ok := nodeClient(nodeID)
+	if !ok {
+		fmt.Println(rpc.ErrNoCookie)
+		runtime.Goexit()
+	}
+
+	passphrase := readPassphrase("Wallet passphrase: ")
+	var res string
+	if err := client.Call("walletpassphrase", &res, passphrase, timeout); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Println(res)
+}
+
+// walletLock will lock the wallet of the running node
+func (cli *CommandLine) walletLock(nodeID string) {
+	client, ok := nodeClient(nodeID)
+	if !ok {
+		fmt.Println(rpc.ErrNoCookie)
+		runtime.Goexit()
+	}
+
+	var res string
+	if err := client.Call("walletlock", &res); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Println(res)
+}
+
+// restoreWallet will rebuild the wallet file from its backup phrase and
+// rescan the blockchain for the addresses that were already used
+func (cli *CommandLine) restoreWallet(mnemonic, passphrase, nodeID string) {
+	wallets := openWallets(nodeID)
+	if err := wallets.SetMnemonic(mnemonic, passphrase); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if blockchain.ChainExists(nodeID) {
+		chain := blockchain.ContinueBlockChain(nodeID)
+		used := chain.UsedPubKeyHashes()
+		chain.Store.Close()
+
+		found, err := wallets.Rescan(func(pubKeyHash []byte) bool {
+			return used[hex.EncodeToString(pubKeyHash)]
+		}, wallet.GapLimit)
+		if err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+
+		fmt.Printf("Found %d used addresses\n", found)
+	}
+
+	if len(wallets.Wallets) == 0 {
+		wallets.AddWallet()
+	}
+
+	wallets.SaveFile(nodeID)
+	for _, address := range wallets.GetAllAddress() {
+		fmt.Println(address)
+	}
 }
 
 // printChain will print all the blocks in the blockchain
 func (cli *CommandLine) printChain(nodeID string) {
 	chain := blockchain.ContinueBlockChain(nodeID)
-	defer chain.Database.Close()
+	defer chain.Store.Close()
 	iter := chain.Iterator()
 
 	for {
@@ -116,24 +298,39 @@ func (cli *CommandLine) printChain(nodeID string) {
 	}
 }
 
-// create blockchain will create a new blockchain instance with the given address
+// create blockchain will create a new blockchain instance, the reward of
+// its first block goes to the given address when there is one
 func (cli *CommandLine) createBLockChain(address, nodeID string) {
-	cli.validateAddress(address)
+	if address != "" {
+		cli.validateAddress(address)
+	}
 	chain := blockchain.InitBLockChain(address, nodeID)
-	chain.Database.Close()
+	defer chain.Store.Close()
+
+	UTXOSet := blockchain.UTXOSet{BlockChain: chain}
+	UTXOSet.Reindex()
 	fmt.Println("Finished!")
 }
 
 func (cli *CommandLine) getBalance(address, nodeID string) {
 	cli.validateAddress(address)
+	if client, ok := nodeClient(nodeID); ok {
+		var balance int
+		if err := client.Call("getbalance", &balance, address); err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+
+		fmt.Printf("Balance of %s: %d\n", address, balance)
+		return
+	}
+
 	chain := blockchain.ContinueBlockChain(nodeID)
 	UTXIOSet := blockchain.UTXOSet{BlockChain: chain}
-	defer chain.Database.Close()
+	defer chain.Store.Close()
 
 	balance := 0
-	pubKeyHash := wallet.Base58Encode([]byte(address))
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4]
-	unspentTxs := UTXIOSet.FindUTXO(pubKeyHash)
+	unspentTxs := UTXIOSet.FindUTXO(addressScript(address))
 
 	for _, out := range unspentTxs {
 		balance += out.Value
@@ -142,39 +339,108 @@ func (cli *CommandLine) getBalance(address, nodeID string) {
 	fmt.Printf("Balance of %s: %d\n", address, balance)
 }
 
-func (cli *CommandLine) send(from, to string, amount int, nodeID string, mineNow bool) {
+func (cli *CommandLine) send(from, to string, amount int, nodeID string, mineNow bool, strategy string, noMix bool, opts blockchain.SendOptions) {
 	cli.validateAddress(from)
 	cli.validateAddress(to)
 
+	if client, ok := nodeClient(nodeID); ok {
+		if mineNow {
+			fmt.Println("The node is running, it mines the transaction, send it without -mine")
+			runtime.Goexit()
+		}
+
+		var exclude []string
+		for _, outPoint := range opts.Exclude {
+			exclude = append(exclude, outPoint.String())
+		}
+
+		var txID string
+		if err := client.Call("sendtoaddress", &txID, from, to, amount, opts.Fee.Base, opts.Fee.PerInput, strategy, noMix, exclude, opts.LockTime); err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+
+		fmt.Printf("Sent transaction %s\n", txID)
+		fmt.Println("Success!")
+		return
+	}
+
 	chain := blockchain.ContinueBlockChain(nodeID)
 	UTXIOSet := &blockchain.UTXOSet{BlockChain: chain}
-	defer chain.Database.Close()
+	defer chain.Store.Close()
 
+	wallet := spendingWallet(from, nodeID, &opts)
+	builder := blockchain.NewTxBuilder(&wallet, UTXIOSet, opts)
+	builder.From = from
+	if err := builder.AddPayment(to, amount); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	tx, _, err := builder.Build()
+	if err != nil {
+		fmt.Println("Error:", err)
+		runtime.Goexit()
+	}
+
+	submitTx(chain, UTXIOSet, tx, from, mineNow)
+	fmt.Println("Success!")
+}
+
+// spendingWallet will load and unlock the wallet of the given address and
+// exclude the locked outpoints from the coin selection options, the
+// schnorr address of a key of the wallet is spent with that key
+func spendingWallet(from, nodeID string, opts *blockchain.SendOptions) wallet.Wallet {
 	wallets, err := wallet.CreateWallets(nodeID)
 	if err != nil {
 		log.Println(err)
 		runtime.Goexit()
 	}
 
-	wallet := wallets.GetWallet(from)
+	if _, ok := wallets.FindWallet(from); !ok {
+		fmt.Printf("Address %s is not in the wallet\n", from)
+		runtime.Goexit()
+	}
+
+	if err := unlockWallets(wallets); err != nil {
+		log.Println(err)
+		runtime.Goexit()
+	}
+
+	for _, outPoint := range wallets.ListLockUnspent() {
+		op, err := blockchain.ParseOutPoint(outPoint)
+		blockchain.CheckError(err)
+		opts.Exclude = append(opts.Exclude, op)
+	}
+
+	w, err := wallets.SigningWallet(from)
+	if err != nil {
+		log.Println(err)
+		runtime.Goexit()
+	}
+
+	return w
+}
 
-	tx := blockchain.NewTransaction(&wallet, to, amount, UTXIOSet)
+// submitTx will mine the transaction right away, paying the reward and
+// the fee to the sender, or send it to the network
+func submitTx(chain *blockchain.BlockChain, UTXOSet *blockchain.UTXOSet, tx *blockchain.Transaction, from string, mineNow bool) {
 	if mineNow {
-		cbTx := blockchain.CoinbaseTx(from, "")
+		fees := chain.TransactionFees([]*blockchain.Transaction{tx})
+		cbTx := blockchain.CoinbaseTxWithFees(from, "", fees)
 		txs := []*blockchain.Transaction{cbTx, tx}
 		block := chain.MineBlock(txs)
-		UTXIOSet.Update(block)
+		UTXOSet.Update(block)
 	} else {
 		network.SendTx(network.KnownNodes[0], tx)
 		fmt.Println("Sending transaction....")
 	}
-
-	fmt.Println("Success!")
 }
 
 // Run will start the comman line app and validate the args
 func (cli *CommandLine) Run() {
-	cli.validateArgs()
+	args := cli.parseGlobalFlags()
+	cli.validateArgs(args)
 
 	nodeID := os.Getenv("NODE_ID")
 	if nodeID == "" {
@@ -188,48 +454,287 @@ func (cli *CommandLine) Run() {
 	printChainCmd := flag.NewFlagSet("printchain", flag.ExitOnError)
 	listAddressesCmd := flag.NewFlagSet("listaddresses", flag.ExitOnError)
 	createWalletCmd := flag.NewFlagSet("createWallet", flag.ExitOnError)
+	restoreWalletCmd := flag.NewFlagSet("restoreWallet", flag.ExitOnError)
+	encryptWalletCmd := flag.NewFlagSet("encryptwallet", flag.ExitOnError)
+	walletPassphraseCmd := flag.NewFlagSet("walletpassphrase", flag.ExitOnError)
+	walletLockCmd := flag.NewFlagSet("walletlock", flag.ExitOnError)
+	sendManyCmd := flag.NewFlagSet("sendmany", flag.ExitOnError)
+	listUnspentCmd := flag.NewFlagSet("listunspent", flag.ExitOnError)
+	lockUnspentCmd := flag.NewFlagSet("lockunspent", flag.ExitOnError)
+	listLockUnspentCmd := flag.NewFlagSet("listlockunspent", flag.ExitOnError)
+	getPubKeyCmd := flag.NewFlagSet("getpubkey", flag.ExitOnError)
+	createMultisigCmd := flag.NewFlagSet("createmultisig", flag.ExitOnError)
+	getSchnorrAddressCmd := flag.NewFlagSet("getschnorraddress", flag.ExitOnError)
+	createMuSigCmd := flag.NewFlagSet("createmusig", flag.ExitOnError)
+	createTimeLockCmd := flag.NewFlagSet("createtimelock", flag.ExitOnError)
+	createTxCmd := flag.NewFlagSet("createtx", flag.ExitOnError)
+	fundTxCmd := flag.NewFlagSet("fundtx", flag.ExitOnError)
+	muSigNonceCmd := flag.NewFlagSet("musignonce", flag.ExitOnError)
+	signTxCmd := flag.NewFlagSet("signtx", flag.ExitOnError)
+	combineTxCmd := flag.NewFlagSet("combinetx", flag.ExitOnError)
+	broadcastTxCmd := flag.NewFlagSet("broadcasttx", flag.ExitOnError)
+	initiateSwapCmd := flag.NewFlagSet("initiateswap", flag.ExitOnError)
+	participateSwapCmd := flag.NewFlagSet("participateswap", flag.ExitOnError)
+	redeemSwapCmd := flag.NewFlagSet("redeemswap", flag.ExitOnError)
+	refundSwapCmd := flag.NewFlagSet("refundswap", flag.ExitOnError)
+	auditSwapCmd := flag.NewFlagSet("auditswap", flag.ExitOnError)
+	extractSecretCmd := flag.NewFlagSet("extractsecret", flag.ExitOnError)
+	anchorCmd := flag.NewFlagSet("anchor", flag.ExitOnError)
+	findAnchorCmd := flag.NewFlagSet("findanchor", flag.ExitOnError)
+	createGenesisCmd := flag.NewFlagSet("creategenesis", flag.ExitOnError)
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
 	reindexCmd := flag.NewFlagSet("reindex", flag.ExitOnError)
 	startNodeCmd := flag.NewFlagSet("startNode", flag.ExitOnError)
+	rpcCmd := flag.NewFlagSet("rpc", flag.ExitOnError)
 
 	getBalanceAddress := getBalanceCmd.String("address", "", "The address to get balance for")
-	createBLockChainAddress := createBLockchainCmd.String("address", "", "The address to send genesis block reward to")
+	createBLockChainAddress := createBLockchainCmd.String("address", "", "The address to send the reward of the first block to")
 	sendFrom := sendCmd.String("from", "", "Source wallet address")
 	sendTo := sendCmd.String("to", "", "Destination wallet address")
 	sendAmount := sendCmd.Int("amount", 0, "Amount to send")
 	sendMineNow := sendCmd.Bool("mine", false, "Mine immediatly on the same node")
+	sendStrategy := sendCmd.String("strategy", "default", "Coin selection strategy: default, largest, bnb or random")
+	sendNoMix := sendCmd.Bool("nomix", false, "Never merge coins received in different transactions")
+	sendFee := sendCmd.Int("fee", 0, "Fixed fee of the transaction")
+	sendFeePerInput := sendCmd.Int("feeperinput", 0, "Fee of every spent input")
+	sendExclude := sendCmd.String("exclude", "", "Comma separated outpoints that must not be spent")
+	sendLockTime := sendCmd.Int64("locktime", 0, "Height or unix time before which the transaction can not be mined")
+	sendManyFrom := sendManyCmd.String("from", "", "Source wallet address")
+	sendManyFile := sendManyCmd.String("file", "", "CSV or JSON file with the recipients and amounts")
+	sendManyMineNow := sendManyCmd.Bool("mine", false, "Mine immediatly on the same node")
+	sendManyStrategy := sendManyCmd.String("strategy", "default", "Coin selection strategy: default, largest, bnb or random")
+	sendManyNoMix := sendManyCmd.Bool("nomix", false, "Never merge coins received in different transactions")
+	sendManyFee := sendManyCmd.Int("fee", 0, "Fixed fee of the transaction")
+	sendManyFeePerInput := sendManyCmd.Int("feeperinput", 0, "Fee of every spent input")
+	sendManyFeePerOutput := sendManyCmd.Int("feeperoutput", 0, "Fee of every output")
+	listUnspentAddress := listUnspentCmd.String("address", "", "The address to list the unspent outputs for")
+	lockUnspentOutPoint := lockUnspentCmd.String("outpoint", "", "The outpoint to lock, in the form <TXID>:<INDEX>")
+	lockUnspentUnlock := lockUnspentCmd.Bool("unlock", false, "Unlock the outpoint instead")
+	getPubKeyAddress := getPubKeyCmd.String("address", "", "The address of the wallet")
+	createMultisigRequired := createMultisigCmd.Int("required", 0, "Signatures needed to spend")
+	createMultisigPubKeys := createMultisigCmd.String("pubkeys", "", "Comma separated hex public keys of the holders")
+	getSchnorrAddressAddress := getSchnorrAddressCmd.String("address", "", "The address of the wallet")
+	createMuSigPubKeys := createMuSigCmd.String("pubkeys", "", "Comma separated compressed hex public keys of the holders")
+	createTimeLockPubKey := createTimeLockCmd.String("pubkey", "", "Hex public key that can spend once the lock is reached")
+	createTimeLockLockTime := createTimeLockCmd.Int64("locktime", 0, "Height or unix time the coins are locked until")
+	createTimeLockBlocks := createTimeLockCmd.Int("blocks", 0, "Confirmations every coin needs before it can be spent")
+	createTimeLockSeconds := createTimeLockCmd.Int64("seconds", 0, "Seconds every coin needs since it was mined before it can be spent")
+	createTxFrom := createTxCmd.String("from", "", "Source address, multisig addresses included")
+	createTxTo := createTxCmd.String("to", "", "Destination wallet address")
+	createTxAmount := createTxCmd.Int("amount", 0, "Amount to send")
+	createTxOut := createTxCmd.String("out", "", "File to write the unsigned transaction to")
+	createTxFee := createTxCmd.Int("fee", 0, "Fixed fee of the transaction")
+	createTxStrategy := createTxCmd.String("strategy", "default", "Coin selection strategy: default, largest, bnb or random")
+	createTxRedeem := createTxCmd.String("redeem", "", "Hex redeem script of an address the wallet does not watch")
+	createTxLockTime := createTxCmd.Int64("locktime", 0, "Height or unix time before which the transaction can not be mined")
+	fundTxIn := fundTxCmd.String("in", "", "File of the transaction to fund")
+	fundTxFrom := fundTxCmd.String("from", "", "Address that contributes the coins")
+	fundTxAmount := fundTxCmd.Int("amount", 0, "Exact value of the contributed coins")
+	fundTxOut := fundTxCmd.String("out", "", "File to write the funded transaction to, the input file by default")
+	muSigNonceIn := muSigNonceCmd.String("in", "", "File of the transaction to add the nonces to")
+	muSigNonceOut := muSigNonceCmd.String("out", "", "File to write the transaction to, the input file by default")
+	signTxIn := signTxCmd.String("in", "", "File of the transaction to sign")
+	signTxOut := signTxCmd.String("out", "", "File to write the signed transaction to, the input file by default")
+	signTxSigHash := signTxCmd.String("sighash", "ALL", "What the signatures commit to: ALL, NONE or SINGLE, optionally with |ANYONECANPAY")
+	combineTxIn := combineTxCmd.String("in", "", "Comma separated files of the signed transactions")
+	combineTxOut := combineTxCmd.String("out", "", "File to write the combined transaction to")
+	broadcastTxIn := broadcastTxCmd.String("in", "", "File of the signed transaction")
+	broadcastTxMineNow := broadcastTxCmd.Bool("mine", false, "Mine immediatly on the same node")
+	initiateSwapFrom := initiateSwapCmd.String("from", "", "Source wallet address")
+	initiateSwapParticipant := initiateSwapCmd.String("participant", "", "Hex public key of the participant")
+	initiateSwapAmount := initiateSwapCmd.Int("amount", 0, "Amount to lock")
+	initiateSwapLockTime := initiateSwapCmd.Int64("locktime", 0, "Height or unix time of the refund, 48 hours by default")
+	initiateSwapMineNow := initiateSwapCmd.Bool("mine", false, "Mine immediatly on the same node")
+	participateSwapFrom := participateSwapCmd.String("from", "", "Source wallet address")
+	participateSwapInitiator := participateSwapCmd.String("initiator", "", "Hex public key of the initiator")
+	participateSwapSecretHash := participateSwapCmd.String("secrethash", "", "Secret hash of the contract of the initiator")
+	participateSwapAmount := participateSwapCmd.Int("amount", 0, "Amount to lock")
+	participateSwapLockTime := participateSwapCmd.Int64("locktime", 0, "Height or unix time of the refund, 24 hours by default")
+	participateSwapMineNow := participateSwapCmd.Bool("mine", false, "Mine immediatly on the same node")
+	redeemSwapContract := redeemSwapCmd.String("contract", "", "Hex contract to redeem")
+	redeemSwapSecret := redeemSwapCmd.String("secret", "", "Hex secret of the contract")
+	redeemSwapTo := redeemSwapCmd.String("to", "", "Address that receives the coins")
+	redeemSwapFee := redeemSwapCmd.Int("fee", 0, "Fee of the transaction")
+	redeemSwapMineNow := redeemSwapCmd.Bool("mine", false, "Mine immediatly on the same node")
+	refundSwapContract := refundSwapCmd.String("contract", "", "Hex contract to refund")
+	refundSwapTo := refundSwapCmd.String("to", "", "Address that receives the coins")
+	refundSwapFee := refundSwapCmd.Int("fee", 0, "Fee of the transaction")
+	refundSwapMineNow := refundSwapCmd.Bool("mine", false, "Mine immediatly on the same node")
+	auditSwapContract := auditSwapCmd.String("contract", "", "Hex contract to audit")
+	extractSecretContract := extractSecretCmd.String("contract", "", "Hex contract that was redeemed")
+	anchorFrom := anchorCmd.String("from", "", "Source wallet address that pays the fee")
+	anchorHash := anchorCmd.String("hash", "", "Hex hash of the document")
+	anchorFile := anchorCmd.String("file", "", "Document to hash with sha256 instead of -hash")
+	anchorFee := anchorCmd.Int("fee", 1, "Fee of the transaction")
+	anchorMineNow := anchorCmd.Bool("mine", false, "Mine immediatly on the same node")
+	findAnchorHash := findAnchorCmd.String("hash", "", "Hex hash of the document")
+	findAnchorFile := findAnchorCmd.String("file", "", "Document to hash with sha256 instead of -hash")
+	generateBlocks := generateCmd.Int("n", 1, "Number of blocks to mine")
+	generateTo := generateCmd.String("to", "", "The address to send the rewards to")
+	createGenesisSpec := createGenesisCmd.String("spec", "", "Json spec of the genesis block")
+	createGenesisOut := createGenesisCmd.String("out", "", "File to write the solved spec to")
 	startNodeMiner := startNodeCmd.String("miner", "", "Enable mining node and send reward to")
+	startNodeRPCPort := startNodeCmd.Int("rpcport", 0, "Port of the json-rpc api, 0 uses the node port plus 1000")
+	startNodeREST := startNodeCmd.Bool("rest", false, "Serve the read only rest api on the rpc port")
+	startNodeExplorer := startNodeCmd.Bool("explorer", false, "Serve the block explorer on the rpc port")
+	rpcMethod := rpcCmd.String("method", "", "The method to call")
+	rpcParams := rpcCmd.String("params", "", "The params of the call as a json array")
+	createWalletMnemonic := createWalletCmd.Bool("mnemonic", false, "Print the backup phrase of the wallet")
+	restoreMnemonic := restoreWalletCmd.String("mnemonic", "", "The backup phrase of the wallet")
+	restorePassphrase := restoreWalletCmd.String("passphrase", "", "The optional passphrase of the backup phrase")
+	walletPassphraseTimeout := walletPassphraseCmd.Int("timeout", 60, "Seconds the wallet stays unlocked")
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "getbalance":
-		err := getBalanceCmd.Parse(os.Args[2:])
+		err := getBalanceCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "send":
-		err := sendCmd.Parse(os.Args[2:])
+		err := sendCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "printchain":
-		err := printChainCmd.Parse(os.Args[2:])
+		err := printChainCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "createBlockchain":
-		err := createBLockchainCmd.Parse(os.Args[2:])
+		err := createBLockchainCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "startNode":
-		err := startNodeCmd.Parse(os.Args[2:])
+		err := startNodeCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "createWallet":
-		err := createWalletCmd.Parse(os.Args[2:])
+		err := createWalletCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "restoreWallet":
+		err := restoreWalletCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "encryptwallet":
+		err := encryptWalletCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "walletpassphrase":
+		err := walletPassphraseCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "walletlock":
+		err := walletLockCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "sendmany":
+		err := sendManyCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "listunspent":
+		err := listUnspentCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "lockunspent":
+		err := lockUnspentCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "listlockunspent":
+		err := listLockUnspentCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "getpubkey":
+		err := getPubKeyCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "createmultisig":
+		err := createMultisigCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "getschnorraddress":
+		err := getSchnorrAddressCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "createmusig":
+		err := createMuSigCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "createtimelock":
+		err := createTimeLockCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "createtx":
+		err := createTxCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "fundtx":
+		err := fundTxCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "musignonce":
+		err := muSigNonceCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "signtx":
+		err := signTxCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "combinetx":
+		err := combineTxCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "broadcasttx":
+		err := broadcastTxCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "initiateswap":
+		err := initiateSwapCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "participateswap":
+		err := participateSwapCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "redeemswap":
+		err := redeemSwapCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "refundswap":
+		err := refundSwapCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "auditswap":
+		err := auditSwapCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "extractsecret":
+		err := extractSecretCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "anchor":
+		err := anchorCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "findanchor":
+		err := findAnchorCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "generate":
+		err := generateCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "creategenesis":
+		err := createGenesisCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "listaddresses":
-		err := listAddressesCmd.Parse(os.Args[2:])
+		err := listAddressesCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	case "reindex":
-		err := reindexCmd.Parse(os.Args[2:])
+		err := reindexCmd.Parse(args[1:])
+		blockchain.CheckError(err)
+
+	case "rpc":
+		err := rpcCmd.Parse(args[1:])
 		blockchain.CheckError(err)
 
 	default:
@@ -256,7 +761,17 @@ func (cli *CommandLine) Run() {
 			runtime.Goexit()
 		}
 
-		cli.StartNode(nodeID, *startNodeMiner)
+		opts := network.HTTPOptions{RPCPort: *startNodeRPCPort, REST: *startNodeREST, Explorer: *startNodeExplorer}
+		cli.StartNode(nodeID, *startNodeMiner, opts)
+	}
+
+	if rpcCmd.Parsed() {
+		if *rpcMethod == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.callNode(*rpcMethod, *rpcParams, nodeID)
 	}
 
 	if sendCmd.Parsed() {
@@ -265,7 +780,9 @@ func (cli *CommandLine) Run() {
 			runtime.Goexit()
 		}
 
-		cli.send(*sendFrom, *sendTo, *sendAmount, nodeID, *sendMineNow)
+		opts := sendOptions(*sendStrategy, *sendNoMix, *sendFee, *sendFeePerInput, *sendExclude)
+		opts.LockTime = *sendLockTime
+		cli.send(*sendFrom, *sendTo, *sendAmount, nodeID, *sendMineNow, *sendStrategy, *sendNoMix, opts)
 	}
 
 	if printChainCmd.Parsed() {
@@ -273,19 +790,281 @@ func (cli *CommandLine) Run() {
 	}
 
 	if createWalletCmd.Parsed() {
-		cli.createWallet(nodeID)
+		cli.createWallet(nodeID, *createWalletMnemonic)
 	}
 
-	if listAddressesCmd.Parsed() {
-		cli.listAddresses(nodeID)
+	if restoreWalletCmd.Parsed() {
+		if *restoreMnemonic == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.restoreWallet(*restoreMnemonic, *restorePassphrase, nodeID)
 	}
 
-	if createBLockchainCmd.Parsed() {
-		if *createBLockChainAddress == "" {
+	if encryptWalletCmd.Parsed() {
+		cli.encryptWallet(nodeID)
+	}
+
+	if walletPassphraseCmd.Parsed() {
+		if *walletPassphraseTimeout <= 0 {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.walletPassphrase(nodeID, *walletPassphraseTimeout)
+	}
+
+	if walletLockCmd.Parsed() {
+		cli.walletLock(nodeID)
+	}
+
+	if sendManyCmd.Parsed() {
+		if *sendManyFrom == "" || *sendManyFile == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		opts := sendOptions(*sendManyStrategy, *sendManyNoMix, *sendManyFee, *sendManyFeePerInput, "")
+		opts.Fee.PerOutput = *sendManyFeePerOutput
+		cli.sendMany(*sendManyFrom, *sendManyFile, nodeID, *sendManyMineNow, opts)
+	}
+
+	if listUnspentCmd.Parsed() {
+		if *listUnspentAddress == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.listUnspent(*listUnspentAddress, nodeID)
+	}
+
+	if lockUnspentCmd.Parsed() {
+		if *lockUnspentOutPoint == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.lockUnspent(*lockUnspentOutPoint, nodeID, *lockUnspentUnlock)
+	}
+
+	if listLockUnspentCmd.Parsed() {
+		cli.listLockUnspent(nodeID)
+	}
+
+	if getPubKeyCmd.Parsed() {
+		if *getPubKeyAddress == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.getPubKey(*getPubKeyAddress, nodeID)
+	}
+
+	if createMultisigCmd.Parsed() {
+		if *createMultisigRequired <= 0 || *createMultisigPubKeys == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.createMultisig(*createMultisigRequired, *createMultisigPubKeys, nodeID)
+	}
+
+	if getSchnorrAddressCmd.Parsed() {
+		if *getSchnorrAddressAddress == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.getSchnorrAddress(*getSchnorrAddressAddress, nodeID)
+	}
+
+	if createMuSigCmd.Parsed() {
+		if *createMuSigPubKeys == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.createMuSig(*createMuSigPubKeys, nodeID)
+	}
+
+	if createTimeLockCmd.Parsed() {
+		if *createTimeLockPubKey == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.createTimeLock(*createTimeLockPubKey, *createTimeLockLockTime, *createTimeLockBlocks, *createTimeLockSeconds, nodeID)
+	}
+
+	if createTxCmd.Parsed() {
+		if *createTxTo == "" || *createTxAmount <= 0 || *createTxOut == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		if *createTxFrom == "" {
+			cli.createTemplate(*createTxTo, *createTxAmount, *createTxOut)
+		} else {
+			opts := sendOptions(*createTxStrategy, false, *createTxFee, 0, "")
+			opts.LockTime = *createTxLockTime
+			cli.createTx(*createTxFrom, *createTxTo, *createTxAmount, *createTxRedeem, *createTxOut, nodeID, opts)
+		}
+	}
+
+	if fundTxCmd.Parsed() {
+		if *fundTxIn == "" || *fundTxFrom == "" || *fundTxAmount <= 0 {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		if *fundTxOut == "" {
+			*fundTxOut = *fundTxIn
+		}
+
+		cli.fundTx(*fundTxIn, *fundTxFrom, *fundTxAmount, *fundTxOut, nodeID)
+	}
+
+	if muSigNonceCmd.Parsed() {
+		if *muSigNonceIn == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		if *muSigNonceOut == "" {
+			*muSigNonceOut = *muSigNonceIn
+		}
+
+		cli.muSigNonce(*muSigNonceIn, *muSigNonceOut, nodeID)
+	}
+
+	if signTxCmd.Parsed() {
+		if *signTxIn == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		if *signTxOut == "" {
+			*signTxOut = *signTxIn
+		}
+
+		cli.signTx(*signTxIn, *signTxOut, *signTxSigHash, nodeID)
+	}
+
+	if combineTxCmd.Parsed() {
+		if *combineTxIn == "" || *combineTxOut == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.combineTx(*combineTxIn, *combineTxOut)
+	}
+
+	if broadcastTxCmd.Parsed() {
+		if *broadcastTxIn == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.broadcastTx(*broadcastTxIn, nodeID, *broadcastTxMineNow)
+	}
+
+	if initiateSwapCmd.Parsed() {
+		if *initiateSwapFrom == "" || *initiateSwapParticipant == "" || *initiateSwapAmount <= 0 {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.initiateSwap(*initiateSwapFrom, *initiateSwapParticipant, *initiateSwapAmount, *initiateSwapLockTime, nodeID, *initiateSwapMineNow)
+	}
+
+	if participateSwapCmd.Parsed() {
+		if *participateSwapFrom == "" || *participateSwapInitiator == "" || *participateSwapSecretHash == "" || *participateSwapAmount <= 0 {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.participateSwap(*participateSwapFrom, *participateSwapInitiator, *participateSwapSecretHash,
+			*participateSwapAmount, *participateSwapLockTime, nodeID, *participateSwapMineNow)
+	}
+
+	if redeemSwapCmd.Parsed() {
+		if *redeemSwapContract == "" || *redeemSwapSecret == "" || *redeemSwapTo == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.redeemSwap(*redeemSwapContract, *redeemSwapSecret, *redeemSwapTo, *redeemSwapFee, nodeID, *redeemSwapMineNow)
+	}
+
+	if refundSwapCmd.Parsed() {
+		if *refundSwapContract == "" || *refundSwapTo == "" {
 			cli.printUsage()
 			runtime.Goexit()
 		}
 
+		cli.refundSwap(*refundSwapContract, *refundSwapTo, *refundSwapFee, nodeID, *refundSwapMineNow)
+	}
+
+	if auditSwapCmd.Parsed() {
+		if *auditSwapContract == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.auditSwap(*auditSwapContract, nodeID)
+	}
+
+	if extractSecretCmd.Parsed() {
+		if *extractSecretContract == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.extractSecret(*extractSecretContract, nodeID)
+	}
+
+	if anchorCmd.Parsed() {
+		if *anchorFrom == "" || *anchorHash == "" && *anchorFile == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.anchor(*anchorFrom, *anchorHash, *anchorFile, *anchorFee, nodeID, *anchorMineNow)
+	}
+
+	if findAnchorCmd.Parsed() {
+		if *findAnchorHash == "" && *findAnchorFile == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.findAnchor(*findAnchorHash, *findAnchorFile, nodeID)
+	}
+
+	if generateCmd.Parsed() {
+		if *generateTo == "" || *generateBlocks <= 0 {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.generate(*generateBlocks, *generateTo, nodeID)
+	}
+
+	if createGenesisCmd.Parsed() {
+		if *createGenesisSpec == "" || *createGenesisOut == "" {
+			cli.printUsage()
+			runtime.Goexit()
+		}
+
+		cli.createGenesis(*createGenesisSpec, *createGenesisOut)
+	}
+
+	if listAddressesCmd.Parsed() {
+		cli.listAddresses(nodeID)
+	}
+
+	if createBLockchainCmd.Parsed() {
 		cli.createBLockChain(*createBLockChainAddress, nodeID)
 	}
 }
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// listUnspent will print the unspent outputs of the given address
+func (cli *CommandLine) listUnspent(address, nodeID string) {
+	cli.validateAddress(address)
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Store.Close()
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	UTXOSet := blockchain.UTXOSet{BlockChain: chain}
+
+	for _, coin := range UTXOSet.SpendableCoins(addressScript(address)) {
+		locked := ""
+		if wallets.Locked[coin.OutPoint.String()] {
+			locked = " (locked)"
+		}
+
+		fmt.Printf("%s %d%s\n", coin.OutPoint, coin.Output.Value, locked)
+	}
+}
+
+// lockUnspent will lock or unlock the given outpoint so coin selection skips it
+func (cli *CommandLine) lockUnspent(outPoint, nodeID string, unlock bool) {
+	op, err := blockchain.ParseOutPoint(outPoint)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("No wallet found, create one first")
+		runtime.Goexit()
+	}
+
+	if unlock {
+		if !wallets.UnlockUnspent(op.String()) {
+			fmt.Printf("%s is not locked\n", op)
+			runtime.Goexit()
+		}
+	} else {
+		wallets.LockUnspent(op.String())
+	}
+
+	wallets.SaveFile(nodeID)
+	fmt.Println("Success!")
+}
+
+// listLockUnspent will print the locked outpoints of the wallet
+func (cli *CommandLine) listLockUnspent(nodeID string) {
+	wallets, _ := wallet.CreateWallets(nodeID)
+	for _, outPoint := range wallets.ListLockUnspent() {
+		fmt.Println(outPoint)
+	}
+}
+
+// parseOutPoints will parse a comma separated list of outpoints
+func parseOutPoints(list string) []blockchain.OutPoint {
+	var outPoints []blockchain.OutPoint
+
+	for _, str := range strings.Split(list, ",") {
+		if strings.TrimSpace(str) == "" {
+			continue
+		}
+
+		op, err := blockchain.ParseOutPoint(str)
+		if err != nil {
+			fmt.Printf("%s: %s\n", str, err)
+			runtime.Goexit()
+		}
+
+		outPoints = append(outPoints, op)
+	}
+
+	return outPoints
+}
+
+// sendOptions will build the coin selection options of the send command
+func sendOptions(strategy string, noMix bool, fee, feePerInput int, exclude string) blockchain.SendOptions {
+	selector, err := blockchain.SelectorByName(strategy)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if noMix {
+		selector = blockchain.NoMixing{Selector: selector}
+	}
+
+	return blockchain.SendOptions{
+		Selector: selector,
+		Fee:      blockchain.FeePolicy{Base: fee, PerInput: feePerInput},
+		Exclude:  parseOutPoints(exclude),
+	}
+}
+
+// addressScript will return the locking script of a valid address
+func addressScript(address string) []byte {
+	locking, err := blockchain.LockingScript(address)
+	blockchain.CheckError(err)
+	return locking
+}
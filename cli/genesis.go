@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/params"
+)
+
+// createGenesis will mine the genesis block described by the json spec
+// for the active network and write the spec with the nonce and the hash,
+// ready to be the genesis of the chain parameters of a network
+func (cli *CommandLine) createGenesis(specFile, out string) {
+	spec, err := params.ReadGenesisSpec(specFile)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	spec, block, err := blockchain.MineGenesis(spec)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if err := params.WriteGenesisSpec(out, spec); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("Genesis block of %s: %x\n", params.Active.Name, block.Hash)
+	fmt.Printf("Nonce: %d, written to %s\n", spec.Nonce, out)
+}
+
+// generate will mine the given number of blocks on regtest, each one pays
+// its reward to the address so the tests get coins and confirmations at once
+func (cli *CommandLine) generate(n int, to, nodeID string) {
+	cli.validateAddress(to)
+	if client, ok := nodeClient(nodeID); ok {
+		var hashes []string
+		if err := client.Call("generate", &hashes, n, to); err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+
+		for _, hash := range hashes {
+			fmt.Println(hash)
+		}
+
+		fmt.Printf("Generated %d blocks\n", len(hashes))
+		return
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Store.Close()
+
+	blocks, err := chain.Generate(n, to)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	for _, block := range blocks {
+		fmt.Printf("%x\n", block.Hash)
+	}
+
+	fmt.Printf("Generated %d blocks, the height is %d\n", len(blocks), chain.GetBestHeigth())
+}
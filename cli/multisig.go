@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// getPubKey will print the public key of an address of the wallet, the
+// key is shared with the other holders of a multisig
+func (cli *CommandLine) getPubKey(address, nodeID string) {
+	wallets, _ := wallet.CreateWallets(nodeID)
+	w, ok := wallets.Wallets[address]
+	if !ok {
+		fmt.Printf("Address %s is not in the wallet\n", address)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("%x\n", w.PublicKey)
+}
+
+// parsePubKey will decode the hex public key, only standard SEC1 keys
+// are accepted
+func parsePubKey(key string) []byte {
+	pubKey, err := hex.DecodeString(key)
+	if err == nil {
+		_, err = wallet.ParsePubKey(pubKey)
+	}
+
+	if err != nil {
+		fmt.Printf("Public key %s is invalid\n", key)
+		runtime.Goexit()
+	}
+
+	return pubKey
+}
+
+// createMultisig will create the address that needs the given number of
+// signatures of the public keys and watch it in the wallet
+func (cli *CommandLine) createMultisig(required int, keys, nodeID string) {
+	var pubKeys [][]byte
+	for _, key := range strings.Split(keys, ",") {
+		pubKeys = append(pubKeys, parsePubKey(strings.TrimSpace(key)))
+	}
+
+	redeem, err := script.MultiSig(required, pubKeys)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	address := wallets.AddScript(redeem)
+	wallets.SaveFile(nodeID)
+
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Redeem script: %x\n", redeem)
+}
+
+// createTimeLock will create the address that the owner of the public key
+// can spend once the lock is reached and watch it in the wallet. Only one
+// of the absolute lock time, the relative blocks or the relative seconds is used
+func (cli *CommandLine) createTimeLock(key string, lockTime int64, blocks int, seconds int64, nodeID string) {
+	pubKey := parsePubKey(key)
+
+	var redeem []byte
+	switch {
+	case lockTime > 0:
+		redeem = script.TimeLock(lockTime, false, pubKey)
+	case blocks > 0:
+		redeem = script.TimeLock(int64(blockchain.RelativeBlocks(blocks)), true, pubKey)
+	case seconds > 0:
+		redeem = script.TimeLock(int64(blockchain.RelativeSeconds(seconds)), true, pubKey)
+	default:
+		fmt.Println("A lock time, relative blocks or relative seconds is required")
+		runtime.Goexit()
+	}
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	address := wallets.AddScript(redeem)
+	wallets.SaveFile(nodeID)
+
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Redeem script: %x\n", redeem)
+}
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// createTx will create the unsigned transaction on a watch-only machine,
+// it only needs the blockchain and the redeem script of multisig addresses
+// or the keys of musig addresses
+func (cli *CommandLine) createTx(from, to string, amount int, redeemHex, file, nodeID string, opts blockchain.SendOptions) {
+	cli.validateAddress(from)
+	cli.validateAddress(to)
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	redeem := wallets.GetScript(from)
+	if redeemHex != "" {
+		var err error
+		if redeem, err = hex.DecodeString(redeemHex); err != nil {
+			fmt.Println("The redeem script is not valid hex")
+			runtime.Goexit()
+		}
+	}
+
+	// time locked coins can only be spent by a transaction that meets the lock
+	if lock, relative, _, ok := script.ExtractTimeLock(redeem); ok {
+		if relative {
+			opts.Sequence = uint32(lock)
+		} else if opts.LockTime < lock {
+			opts.LockTime = lock
+		}
+	}
+
+	for _, outPoint := range wallets.ListLockUnspent() {
+		op, err := blockchain.ParseOutPoint(outPoint)
+		blockchain.CheckError(err)
+		opts.Exclude = append(opts.Exclude, op)
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	builder := blockchain.NewAddressTxBuilder(from, UTXOSet, opts)
+	if err := builder.AddPayment(to, amount); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	ptx, sel, err := builder.BuildPartial()
+	if err != nil {
+		fmt.Println("Error:", err)
+		runtime.Goexit()
+	}
+
+	if redeem != nil && ptx.AddRedeemScript(redeem) == 0 {
+		fmt.Printf("The redeem script does not belong to %s\n", from)
+		runtime.Goexit()
+	}
+
+	if keys := wallets.GetMuSig(from); keys != nil {
+		ptx.AddMuSigKeys(keys)
+	}
+
+	writePartialTx(file, ptx)
+	fmt.Printf("Spending %d inputs, fee %d, change %d\n", len(sel.Coins), sel.Fee, sel.Change)
+	fmt.Printf("Unsigned transaction written to %s\n", file)
+}
+
+// createTemplate will create a transaction that only pays the amount,
+// the contributors add the inputs with fundtx
+func (cli *CommandLine) createTemplate(to string, amount int, file string) {
+	cli.validateAddress(to)
+	if amount <= 0 {
+		fmt.Println(blockchain.ErrInvalidAmount)
+		runtime.Goexit()
+	}
+
+	tx := blockchain.Transaction{Outputs: []blockchain.TxOutput{*blockchain.NewTXOutput(amount, to)}}
+	writePartialTx(file, blockchain.NewPartialTx(&tx, nil))
+	fmt.Printf("Transaction paying %d to %s written to %s, add the inputs with fundtx\n", amount, to, file)
+}
+
+// fundTx will add coins of the address worth exactly the amount to the
+// transaction of the file. There is no change output, so the signatures
+// of the other contributors made with ANYONECANPAY stay valid
+func (cli *CommandLine) fundTx(in, from string, amount int, out, nodeID string) {
+	cli.validateAddress(from)
+	ptx := readPartialTx(in)
+
+	locking, err := blockchain.LockingScript(from)
+	blockchain.CheckError(err)
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	var exclude []blockchain.OutPoint
+	for _, outPoint := range wallets.ListLockUnspent() {
+		op, err := blockchain.ParseOutPoint(outPoint)
+		blockchain.CheckError(err)
+		exclude = append(exclude, op)
+	}
+
+	for _, txIn := range ptx.Tx.Inputs {
+		exclude = append(exclude, blockchain.OutPoint{TxID: txIn.ID, Index: txIn.Out})
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	coins := blockchain.ExcludeCoins(UTXOSet.SpendableCoins(locking), exclude)
+	sel, err := blockchain.BranchAndBound{}.Select(coins, blockchain.Target{Amount: amount})
+	if err != nil {
+		fmt.Printf("No coins of %s add up to exactly %d, send yourself a coin of that value first\n", from, amount)
+		runtime.Goexit()
+	}
+
+	for _, coin := range sel.Coins {
+		ptx.AddInput(blockchain.TxInput{ID: coin.TxID, Out: coin.Index, Sequence: blockchain.SequenceFinal}, coin.Output)
+	}
+
+	if redeem := wallets.GetScript(from); redeem != nil {
+		ptx.AddRedeemScript(redeem)
+	}
+
+	if keys := wallets.GetMuSig(from); keys != nil {
+		ptx.AddMuSigKeys(keys)
+	}
+
+	writePartialTx(out, ptx)
+	fmt.Printf("Added %d inputs worth %d, written to %s\n", len(sel.Coins), sel.Total, out)
+	fmt.Println("Sign them with -sighash ALL|ANYONECANPAY so the other inputs can be combined")
+}
+
+// signTx will add the signatures of the wallet to the transaction of the
+// file, it never touches the blockchain so it runs on an offline machine
+func (cli *CommandLine) signTx(in, out, sigHash, nodeID string) {
+	ptx := readPartialTx(in)
+	hashType, err := blockchain.ParseSigHashType(sigHash)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil || !wallets.HasSeed() && !wallets.IsEncrypted() {
+		fmt.Println("No signing wallet found")
+		runtime.Goexit()
+	}
+
+	printPartialTx(ptx)
+	if err := unlockWallets(wallets); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	signed := signPartialTx(ptx, wallets, hashType)
+	if signed == 0 {
+		fmt.Println(blockchain.ErrCannotSign)
+		runtime.Goexit()
+	}
+
+	// the used musig nonces were taken out of the wallet, they never sign twice
+	wallets.SaveFile(nodeID)
+
+	writePartialTx(out, ptx)
+	fmt.Printf("Added %d signatures, written to %s\n", signed, out)
+	for i := range ptx.Inputs {
+		fmt.Printf("Input %d needs %d more signatures\n", i, ptx.Inputs[i].Missing())
+	}
+}
+
+// signPartialTx will sign the transaction with every key of the unlocked
+// wallet and return how many signatures were added, the musig inputs get
+// the partial signatures of the nonces the wallet added
+func signPartialTx(ptx *blockchain.PartialTx, wallets *wallet.Wallets, hashType blockchain.SigHashType) int {
+	signed := 0
+	for _, w := range wallets.Wallets {
+		n, err := ptx.Sign(w.PrivateKey, w.PublicKey, hashType)
+		if err != nil && err != blockchain.ErrCannotSign {
+			fmt.Println(err)
+		}
+
+		signed += n
+
+		n, err = ptx.SignMuSig(w.PrivateKey, w.PublicKey, hashType, wallets.TakeMuSigNonce)
+		if err != nil && err != blockchain.ErrCannotSign {
+			fmt.Println(err)
+		}
+
+		signed += n
+	}
+
+	return signed
+}
+
+// combineTx will merge the signatures of partial transactions signed apart
+func (cli *CommandLine) combineTx(files, out string) {
+	var ptxs []*blockchain.PartialTx
+	for _, file := range strings.Split(files, ",") {
+		ptxs = append(ptxs, readPartialTx(strings.TrimSpace(file)))
+	}
+
+	ptx := ptxs[0]
+	if err := ptx.Combine(ptxs[1:]...); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	writePartialTx(out, ptx)
+	fmt.Printf("Combined %d transactions into %s, complete: %t\n", len(ptxs), out, ptx.IsComplete())
+}
+
+// broadcastTx will finalize the signed transaction of the file, check it
+// against the blockchain and send it
+func (cli *CommandLine) broadcastTx(file, nodeID string, mineNow bool) {
+	ptx := readPartialTx(file)
+	tx, err := ptx.Finalize()
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	if !chain.VerifyTransaction(tx) {
+		fmt.Println("The transaction is not valid")
+		runtime.Goexit()
+	}
+
+	// the reward of a local block goes back to the address that paid
+	from := ptx.Inputs[0].PrevOut.Address()
+	submitTx(chain, UTXOSet, tx, from, mineNow)
+	fmt.Printf("%x\n", tx.ID)
+	fmt.Println("Success!")
+}
+
+// printPartialTx will print what the transaction pays so the signer can
+// check it before signing
+func printPartialTx(ptx *blockchain.PartialTx) {
+	for i, in := range ptx.Inputs {
+		fmt.Printf("Input %d: %x:%d %d from %s\n", i, ptx.Tx.Inputs[i].ID, ptx.Tx.Inputs[i].Out, in.PrevOut.Value, in.PrevOut.Address())
+	}
+
+	for i, out := range ptx.Tx.Outputs {
+		fmt.Printf("Output %d: %d to %s\n", i, out.Value, out.Address())
+	}
+
+	if ptx.Tx.LockTime != 0 {
+		fmt.Printf("Lock time: %d\n", ptx.Tx.LockTime)
+	}
+
+	fmt.Printf("Fee: %d\n", ptx.Fee())
+}
+
+// writePartialTx will write the partial transaction as base64 to the file
+func writePartialTx(file string, ptx *blockchain.PartialTx) {
+	if err := ioutil.WriteFile(file, []byte(ptx.String()+"\n"), 0600); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+}
+
+// readPartialTx will read the base64 partial transaction of the file
+func readPartialTx(file string) *blockchain.PartialTx {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	ptx, err := blockchain.DecodePartialTx(string(content))
+	if err != nil {
+		fmt.Printf("%s: %s\n", file, err)
+		runtime.Goexit()
+	}
+
+	return ptx
+}
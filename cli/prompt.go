@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Haizza1/go-block/wallet"
+	"golang.org/x/term"
+)
+
+// stdin is shared so consecutive prompts do not lose buffered input
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassphrase will ask the user for a passphrase, the input is not
+// echoed when stdin is a terminal
+func readPassphrase(prompt string) string {
+	fmt.Print(prompt)
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return ""
+		}
+
+		return string(passphrase)
+	}
+
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// unlockWallets will prompt for the passphrase when the wallets are locked
+func unlockWallets(wallets *wallet.Wallets) error {
+	if !wallets.IsLocked() {
+		return nil
+	}
+
+	return wallets.Unlock(readPassphrase("Wallet passphrase: "))
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/Haizza1/go-block/rpc"
+)
+
+// nodeClient will return a client of the node with the given id when it
+// is running, the node holds the database so the commands must call it
+func nodeClient(nodeID string) (*rpc.Client, bool) {
+	client, err := rpc.Dial(nodeID)
+	if err != nil {
+		return nil, false
+	}
+
+	return client, true
+}
+
+// callNode will run a method of the api on the running node, the params
+// are a json array, and print its result
+func (cli *CommandLine) callNode(method, params, nodeID string) {
+	client, ok := nodeClient(nodeID)
+	if !ok {
+		fmt.Println(rpc.ErrNoCookie)
+		runtime.Goexit()
+	}
+
+	var args []interface{}
+	if params != "" {
+		if err := json.Unmarshal([]byte(params), &args); err != nil {
+			fmt.Println("the params must be a json array:", err)
+			runtime.Goexit()
+		}
+	}
+
+	var result json.RawMessage
+	if err := client.Call(method, &result, args...); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Println(string(out))
+}
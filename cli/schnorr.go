@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// getSchnorrAddress will print the schnorr address of the key of an
+// address of the wallet, coins sent to it are spent with schnorr signatures
+func (cli *CommandLine) getSchnorrAddress(address, nodeID string) {
+	wallets, _ := wallet.CreateWallets(nodeID)
+	w, ok := wallets.Wallets[address]
+	if !ok {
+		fmt.Printf("Address %s is not in the wallet\n", address)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("%s\n", w.SchnorrAddress())
+}
+
+// createMuSig will create the address of the key aggregated from the
+// public keys and watch it in the wallet, every holder must sign to spend
+// it but on chain it looks like a single key
+func (cli *CommandLine) createMuSig(keys, nodeID string) {
+	var pubKeys [][]byte
+	for _, key := range strings.Split(keys, ",") {
+		pubKey := parsePubKey(strings.TrimSpace(key))
+		if len(pubKey) != 33 {
+			fmt.Printf("Public key %s must be compressed\n", key)
+			runtime.Goexit()
+		}
+
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	address, err := wallets.AddMuSig(pubKeys)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets.SaveFile(nodeID)
+	fmt.Printf("Address: %s\n", address)
+}
+
+// muSigNonce will add the nonces of the keys of the wallet to the musig
+// inputs of the transaction, the first signing round. The secret nonces
+// stay in the wallet until signtx uses them
+func (cli *CommandLine) muSigNonce(in, out, nodeID string) {
+	ptx := readPartialTx(in)
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil || !wallets.HasSeed() && !wallets.IsEncrypted() {
+		fmt.Println("No signing wallet found")
+		runtime.Goexit()
+	}
+
+	// the secret nonces are sealed with the seed of an encrypted wallet
+	if err := unlockWallets(wallets); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	added := 0
+	for _, w := range wallets.Wallets {
+		n, err := ptx.AddNonces(w.PublicKey, wallets.AddMuSigNonce)
+		if err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+
+		added += n
+	}
+
+	if added == 0 {
+		fmt.Println("The wallet has no key of a musig input without a nonce")
+		runtime.Goexit()
+	}
+
+	wallets.SaveFile(nodeID)
+	writePartialTx(out, ptx)
+	fmt.Printf("Added %d nonces, written to %s\n", added, out)
+	fmt.Println("Combine the nonces of every holder with combinetx before signing")
+}
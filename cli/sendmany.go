@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Haizza1/go-block/blockchain"
+)
+
+// sendMany will pay every recipient of the given file with a single transaction
+func (cli *CommandLine) sendMany(from, file, nodeID string, mineNow bool, opts blockchain.SendOptions) {
+	cli.validateAddress(from)
+
+	payments, err := readPayments(file)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	wallet := spendingWallet(from, nodeID, &opts)
+	builder := blockchain.NewTxBuilder(&wallet, UTXOSet, opts)
+	builder.From = from
+
+	for _, payment := range payments {
+		if err := builder.AddPayment(payment.Address, payment.Amount); err != nil {
+			fmt.Println(err)
+			runtime.Goexit()
+		}
+	}
+
+	tx, sel, err := builder.Build()
+	if err != nil {
+		fmt.Println("Error:", err)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("Paying %d to %d recipients with %d inputs, fee %d, change %d\n",
+		builder.Total(), len(payments), len(sel.Coins), sel.Fee, sel.Change)
+
+	submitTx(chain, UTXOSet, tx, from, mineNow)
+	fmt.Println("Success!")
+}
+
+// readPayments will read the recipients from a JSON or CSV file, the
+// format is picked by the file extension
+func readPayments(file string) ([]blockchain.Payment, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return readJSONPayments(f)
+	}
+
+	return readCSVPayments(f)
+}
+
+// readJSONPayments will read either a list of {"address", "amount"}
+// objects or an object that maps every address to its amount
+func readJSONPayments(r io.Reader) ([]blockchain.Payment, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []blockchain.Payment
+	if err := json.Unmarshal(data, &payments); err == nil {
+		return payments, checkAmounts(payments)
+	}
+
+	var amounts map[string]int
+	if err := json.Unmarshal(data, &amounts); err != nil {
+		return nil, fmt.Errorf("the JSON file must hold a list of payments or an address to amount object: %w", err)
+	}
+
+	for address, amount := range amounts {
+		payments = append(payments, blockchain.Payment{Address: address, Amount: amount})
+	}
+
+	sort.Slice(payments, func(i, j int) bool { return payments[i].Address < payments[j].Address })
+
+	return payments, checkAmounts(payments)
+}
+
+// checkAmounts will reject a file that pays an amount that is not positive
+func checkAmounts(payments []blockchain.Payment) error {
+	for _, payment := range payments {
+		if payment.Amount <= 0 {
+			return fmt.Errorf("%s: %w", payment.Address, blockchain.ErrInvalidAmount)
+		}
+	}
+
+	return nil
+}
+
+// readCSVPayments will read address,amount records, a first record
+// whose amount is not a number is taken as the header
+func readCSVPayments(r io.Reader) ([]blockchain.Payment, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []blockchain.Payment
+	for i, record := range records {
+		amount, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: the amount %q is not a number", i+1, record[1])
+		}
+
+		if amount <= 0 {
+			return nil, fmt.Errorf("line %d: %w", i+1, blockchain.ErrInvalidAmount)
+		}
+
+		payments = append(payments, blockchain.Payment{Address: strings.TrimSpace(record[0]), Amount: amount})
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+const (
+	initiatorLockTime   = 48 * time.Hour // refund time of the contract of the initiator
+	participantLockTime = 24 * time.Hour // refund time of the contract of the participant, it must expire first
+)
+
+// initiateSwap will create a new secret and lock the amount in a contract
+// that the participant redeems with the secret
+func (cli *CommandLine) initiateSwap(from, participant string, amount int, lockTime int64, nodeID string, mineNow bool) {
+	if lockTime == 0 {
+		lockTime = time.Now().Add(initiatorLockTime).Unix()
+	}
+
+	secret, secretHash := blockchain.NewSecret()
+	cli.fundContract(from, participant, secretHash, amount, lockTime, nodeID, mineNow)
+	fmt.Printf("Secret:      %x\n", secret)
+	fmt.Printf("Secret hash: %x\n", secretHash)
+	fmt.Println("Keep the secret until the participant funded its contract")
+}
+
+// participateSwap will lock the amount in a contract with the secret hash
+// of the initiator, the initiator redeems it revealing the secret
+func (cli *CommandLine) participateSwap(from, initiator, secretHash string, amount int, lockTime int64, nodeID string, mineNow bool) {
+	hash, err := hex.DecodeString(secretHash)
+	if err != nil || len(hash) != 32 {
+		fmt.Println("The secret hash must be 32 hex encoded bytes")
+		runtime.Goexit()
+	}
+
+	if lockTime == 0 {
+		lockTime = time.Now().Add(participantLockTime).Unix()
+	}
+
+	cli.fundContract(from, initiator, hash, amount, lockTime, nodeID, mineNow)
+}
+
+// fundContract will pay the amount from the address to a new contract
+// with the given counterparty and watch it in the wallet
+func (cli *CommandLine) fundContract(from, counterparty string, secretHash []byte, amount int, lockTime int64, nodeID string, mineNow bool) {
+	cli.validateAddress(from)
+
+	recipient := parsePubKey(counterparty)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	opts := blockchain.SendOptions{}
+	sender := spendingWallet(from, nodeID, &opts)
+	contract := script.HTLC{
+		SecretHash: secretHash,
+		Recipient:  recipient,
+		Sender:     sender.PublicKey,
+		LockTime:   lockTime,
+	}
+
+	wallets, _ := wallet.CreateWallets(nodeID)
+	address := wallets.AddScript(contract.Script())
+	wallets.SaveFile(nodeID)
+
+	tx := blockchain.NewTransactionWithOptions(&sender, address, amount, UTXOSet, opts)
+	submitTx(chain, UTXOSet, tx, from, mineNow)
+
+	fmt.Printf("Contract address: %s\n", address)
+	fmt.Printf("Contract:         %x\n", contract.Script())
+	fmt.Printf("Contract tx:      %x\n", tx.ID)
+	fmt.Printf("Refund after:     %s\n", formatLockTime(lockTime))
+}
+
+// redeemSwap will claim the coins of the contract with the secret
+func (cli *CommandLine) redeemSwap(contractHex, secretHex, to string, fee int, nodeID string, mineNow bool) {
+	secret, err := hex.DecodeString(secretHex)
+	if err != nil || len(secret) != script.SecretSize {
+		fmt.Println("The secret must be 32 hex encoded bytes")
+		runtime.Goexit()
+	}
+
+	cli.spendContract(contractHex, secret, to, fee, nodeID, mineNow)
+}
+
+// refundSwap will take the coins of the contract back once it expired
+func (cli *CommandLine) refundSwap(contractHex, to string, fee int, nodeID string, mineNow bool) {
+	cli.spendContract(contractHex, nil, to, fee, nodeID, mineNow)
+}
+
+// spendContract will move the coins of the contract to the address,
+// signing with the key of the wallet that the branch needs
+func (cli *CommandLine) spendContract(contractHex string, secret []byte, to string, fee int, nodeID string, mineNow bool) {
+	cli.validateAddress(to)
+	redeem, _ := parseContract(contractHex)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	ptx, err := blockchain.NewContractSpend(UTXOSet, redeem, secret, to, fee)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	wallets, err := wallet.CreateWallets(nodeID)
+	if err != nil {
+		fmt.Println("No wallet found, create one first")
+		runtime.Goexit()
+	}
+
+	if err := unlockWallets(wallets); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if signPartialTx(ptx, wallets, blockchain.SigHashAll) == 0 {
+		fmt.Println(blockchain.ErrCannotSign)
+		runtime.Goexit()
+	}
+
+	tx, err := ptx.Finalize()
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if err := chain.CheckTxLocks(tx); err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	if !chain.VerifyTransaction(tx) {
+		fmt.Println("The transaction is not valid")
+		runtime.Goexit()
+	}
+
+	submitTx(chain, UTXOSet, tx, to, mineNow)
+	fmt.Printf("%x\n", tx.ID)
+	fmt.Println("Success!")
+}
+
+// auditSwap will print the terms of the contract and the coins it holds
+// on this chain, a participant checks them before funding its own contract
+func (cli *CommandLine) auditSwap(contractHex, nodeID string) {
+	redeem, contract := parseContract(contractHex)
+	address := blockchain.ContractAddress(contract)
+
+	fmt.Printf("Contract address:  %s\n", address)
+	fmt.Printf("Recipient address: %s\n", wallet.Wallet{PublicKey: contract.Recipient}.Address())
+	fmt.Printf("Refund address:    %s\n", wallet.Wallet{PublicKey: contract.Sender}.Address())
+	fmt.Printf("Secret hash:       %x\n", contract.SecretHash)
+	fmt.Printf("Refund after:      %s\n", formatLockTime(contract.LockTime))
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	UTXOSet := &blockchain.UTXOSet{BlockChain: chain}
+	defer chain.Store.Close()
+
+	value := 0
+	for _, coin := range UTXOSet.SpendableCoins(addressScript(address)) {
+		fmt.Printf("Locked output:     %s %d\n", coin.OutPoint, coin.Output.Value)
+		value += coin.Output.Value
+	}
+
+	fmt.Printf("Locked value:      %d\n", value)
+	if secret, err := chain.FindContractSecret(redeem); err == nil {
+		fmt.Printf("Redeemed, secret:  %x\n", secret)
+	}
+}
+
+// extractSecret will find the secret revealed by the redemption of the contract
+func (cli *CommandLine) extractSecret(contractHex, nodeID string) {
+	redeem, _ := parseContract(contractHex)
+
+	chain := blockchain.ContinueBlockChain(nodeID)
+	defer chain.Store.Close()
+
+	secret, err := chain.FindContractSecret(redeem)
+	if err != nil {
+		fmt.Println(err)
+		runtime.Goexit()
+	}
+
+	fmt.Printf("Secret: %x\n", secret)
+}
+
+// parseContract will decode the hex redeem script of a contract
+func parseContract(contractHex string) ([]byte, script.HTLC) {
+	redeem, err := hex.DecodeString(contractHex)
+	if err != nil {
+		fmt.Println("The contract must be hex encoded")
+		runtime.Goexit()
+	}
+
+	contract, ok := script.ExtractHTLC(redeem)
+	if !ok {
+		fmt.Println(blockchain.ErrNotContract)
+		runtime.Goexit()
+	}
+
+	return redeem, contract
+}
+
+// formatLockTime will print a lock time as a height or a date
+func formatLockTime(lockTime int64) string {
+	if lockTime < blockchain.LockTimeThreshold {
+		return fmt.Sprintf("block %d", lockTime)
+	}
+
+	return time.Unix(lockTime, 0).Format(time.RFC3339)
+}
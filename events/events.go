@@ -0,0 +1,176 @@
+// Package events carries what happens in a running node to whoever
+// listens: blocks connected to and disconnected from the best chain,
+// transactions entering and leaving the memory pool and new peers. The
+// publishers never wait for the listeners
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Topic is the kind of an event
+type Topic string
+
+const (
+	BlockConnected    Topic = "blockconnected"
+	BlockDisconnected Topic = "blockdisconnected"
+	TxAccepted        Topic = "txaccepted"
+	TxRemoved         Topic = "txremoved"
+	PeerConnected     Topic = "peerconnected"
+)
+
+// the reasons a transaction leaves the memory pool
+const (
+	ReasonMined = "mined"
+)
+
+// bufferSize is how many events a subscription holds before it is dropped
+const bufferSize = 256
+
+var ErrUnknownTopic = errors.New("unknown topic, use blockconnected, blockdisconnected, txaccepted, txremoved or peerconnected")
+
+// Event is something that happened in the node
+type Event struct {
+	Topic     Topic    `json:"topic"`
+	Time      int64    `json:"time"`                // represents when the event was published
+	Hash      string   `json:"hash,omitempty"`      // represents the hash of the block or the id of the transaction
+	Height    int      `json:"height,omitempty"`    // represents the height of the block
+	Peer      string   `json:"peer,omitempty"`      // represents the address of the peer
+	Reason    string   `json:"reason,omitempty"`    // represents why the transaction left the memory pool
+	Addresses []string `json:"addresses,omitempty"` // represents the addresses paid or spent by the block or the transaction
+}
+
+// Filter selects the events of a subscription, an empty set of topics or
+// addresses lets every event through
+type Filter struct {
+	Topics    map[Topic]bool  // represents the topics to receive
+	Addresses map[string]bool // represents the addresses the events must touch
+}
+
+// ParseFilter will build a filter from lists of topics and addresses
+// separated by commas
+func ParseFilter(topics, addresses string) (Filter, error) {
+	filter := Filter{Topics: make(map[Topic]bool), Addresses: make(map[string]bool)}
+	for _, name := range splitList(topics) {
+		topic := Topic(name)
+		switch topic {
+		case BlockConnected, BlockDisconnected, TxAccepted, TxRemoved, PeerConnected:
+			filter.Topics[topic] = true
+		default:
+			return filter, ErrUnknownTopic
+		}
+	}
+
+	for _, address := range splitList(addresses) {
+		filter.Addresses[address] = true
+	}
+
+	return filter, nil
+}
+
+// splitList will split a list separated by commas, without empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Match will check if the event passes the filter
+func (f Filter) Match(e Event) bool {
+	if len(f.Topics) > 0 && !f.Topics[e.Topic] {
+		return false
+	}
+
+	if len(f.Addresses) == 0 {
+		return true
+	}
+
+	for _, address := range e.Addresses {
+		if f.Addresses[address] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bus hands the published events to the subscriptions that match them, a
+// nil bus drops every event
+type Bus struct {
+	mu   sync.Mutex
+	subs map[*Subscription]bool
+}
+
+// Subscription receives the events that match its filter on C, which is
+// closed when the subscription ends. A subscription that falls behind by
+// more than bufferSize events is dropped so it never stalls the node
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+	bus    *Bus
+}
+
+// NewBus will create a bus without subscriptions
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]bool)}
+}
+
+// Subscribe will start receiving the events that match the filter
+func (b *Bus) Subscribe(filter Filter) *Subscription {
+	ch := make(chan Event, bufferSize)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, bus: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = true
+	return sub
+}
+
+// Publish will stamp the event and hand it to the matching subscriptions
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	e.Time = time.Now().Unix()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Close will end the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// remove will drop a subscription and close its channel, the caller holds
+// the mutex
+func (b *Bus) remove(sub *Subscription) {
+	if b.subs[sub] {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe(Filter{})
+	fast := bus.Subscribe(Filter{})
+
+	for i := 0; i <= bufferSize; i++ {
+		bus.Publish(Event{Topic: BlockConnected, Height: i})
+
+		if e := <-fast.C; e.Height != i {
+			t.Fatalf("the fast subscriber got height %d, want %d", e.Height, i)
+		}
+	}
+
+	// the slow subscriber keeps the events it buffered and then ends
+	for i := 0; i < bufferSize; i++ {
+		e, ok := <-slow.C
+		if !ok || e.Height != i {
+			t.Fatalf("event %d: got height %d open %v", i, e.Height, ok)
+		}
+	}
+
+	if _, ok := <-slow.C; ok {
+		t.Fatal("the slow subscriber was not dropped")
+	}
+
+	bus.Publish(Event{Topic: BlockConnected, Height: bufferSize + 1})
+	if e := <-fast.C; e.Height != bufferSize+1 {
+		t.Fatalf("the fast subscriber got height %d after the drop, want %d", e.Height, bufferSize+1)
+	}
+
+	slow.Close()
+	fast.Close()
+	if _, ok := <-fast.C; ok {
+		t.Fatal("the closed subscription is still open")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		topics    string
+		addresses string
+		event     Event
+		match     bool
+	}{
+		{"", "", Event{Topic: PeerConnected}, true},
+		{"blockconnected, txaccepted", "", Event{Topic: TxAccepted}, true},
+		{"blockconnected", "", Event{Topic: BlockDisconnected}, false},
+		{"", "a,b", Event{Topic: TxAccepted, Addresses: []string{"c", "b"}}, true},
+		{"", "a", Event{Topic: TxAccepted, Addresses: []string{"c"}}, false},
+		{"", "a", Event{Topic: PeerConnected}, false},
+		{"txremoved", "a", Event{Topic: TxAccepted, Addresses: []string{"a"}}, false},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseFilter(test.topics, test.addresses)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if match := filter.Match(test.event); match != test.match {
+			t.Fatalf("%q %q: got match %v for %s, want %v", test.topics, test.addresses, match, test.event.Topic, test.match)
+		}
+	}
+
+	if _, err := ParseFilter("blockconnected,blocks", ""); !errors.Is(err, ErrUnknownTopic) {
+		t.Fatalf("got error %v, want %v", err, ErrUnknownTopic)
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Topic: BlockConnected})
+}
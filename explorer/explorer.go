@@ -0,0 +1,458 @@
+// Package explorer serves web pages to browse the chain of a running
+// node: the recent blocks, every block with its proof of work, every
+// transaction with links to the coins it spends and the addresses it
+// pays, and the balance and history of an address. It only reads
+package explorer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/rpc"
+	"github.com/Haizza1/go-block/script"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+const (
+	Prefix       = "/explorer/" // represents the path the explorer is served under
+	pageSize     = 20           // represents the blocks of a page of recent blocks
+	historyLimit = 100          // represents the most transactions of an address page
+)
+
+// Explorer is the http handler of the explorer pages
+type Explorer struct {
+	backend rpc.Backend
+	pages   *template.Template
+}
+
+// recentView is the page of recent blocks
+type recentView struct {
+	Blocks  []rpc.BlockResult
+	MemPool int
+	Older   int // represents the height to page from, -1 at the genesis
+}
+
+// blockView is the page of a block
+type blockView struct {
+	rpc.BlockResult
+	ValidPoW     bool
+	Size         int
+	Transactions []rpc.TxResult
+}
+
+// inputView is an input of a transaction with the output it spends
+type inputView struct {
+	rpc.InputResult
+	Coinbase bool
+	Address  string
+	Value    int
+	Asm      string
+}
+
+// outputView is an output of a transaction with its disassembled script
+type outputView struct {
+	rpc.OutputResult
+	Asm string
+}
+
+// txView is the page of a transaction
+type txView struct {
+	rpc.TxResult
+	Height  int
+	Inputs  []inputView
+	Outputs []outputView
+}
+
+// historyRow is a transaction of an address with the change of its balance
+type historyRow struct {
+	TxID      string
+	BlockHash string
+	Height    int
+	Time      int64
+	Delta     int
+}
+
+// addressView is the page of an address
+type addressView struct {
+	Address   string
+	Balance   int
+	Received  int
+	Sent      int
+	History   []historyRow
+	Truncated bool
+}
+
+// errorView is the page of a failed request
+type errorView struct {
+	Status  int
+	Message string
+}
+
+// New will create the explorer of the node
+func New(backend rpc.Backend) *Explorer {
+	funcs := template.FuncMap{
+		"time": func(unix int64) string {
+			return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+		},
+		"short": func(hash string) string {
+			if len(hash) > 16 {
+				return hash[:16] + "…"
+			}
+
+			return hash
+		},
+		"link": func(kind, id string) string {
+			return Prefix + kind + "/" + id
+		},
+	}
+
+	pages := template.Must(template.New("layout").Funcs(funcs).Parse(layoutTemplate))
+	template.Must(pages.Parse(pageTemplates))
+	return &Explorer{backend: backend, pages: pages}
+}
+
+// ServeHTTP will route the request to its page
+func (e *Explorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "the explorer only answers GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, Prefix)
+	parts := strings.SplitN(path, "/", 2)
+	arg := ""
+	if len(parts) == 2 {
+		arg = parts[1]
+	}
+
+	switch parts[0] {
+	case "":
+		e.recent(w, r)
+	case "block":
+		e.block(w, arg)
+	case "tx":
+		e.tx(w, arg)
+	case "address":
+		e.address(w, arg)
+	case "search":
+		e.search(w, r)
+	default:
+		e.fail(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// render will write a page with the layout
+func (e *Explorer) render(w http.ResponseWriter, status int, page string, data interface{}) {
+	var buf bytes.Buffer
+	if err := e.pages.ExecuteTemplate(&buf, page, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
+
+// fail will write the error page
+func (e *Explorer) fail(w http.ResponseWriter, status int, message string) {
+	e.render(w, status, "error", errorView{Status: status, Message: message})
+}
+
+// recent will show a page of blocks from the tip down, or from the height
+// of the before param down
+func (e *Explorer) recent(w http.ResponseWriter, r *http.Request) {
+	chain := e.backend.Chain()
+	best, err := chain.BestHeight()
+	if err != nil {
+		e.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	before := best + 1
+	if param := r.URL.Query().Get("before"); param != "" {
+		height, err := strconv.Atoi(param)
+		if err != nil || height < 1 {
+			e.fail(w, http.StatusBadRequest, "before must be a positive height")
+			return
+		}
+
+		before = height
+	}
+
+	view := recentView{MemPool: len(e.backend.MemPool()), Older: -1}
+	iter := chain.Iterator()
+	for {
+		block, err := iter.NextBlock()
+		if err != nil {
+			e.fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if block.Heigth < before {
+			view.Blocks = append(view.Blocks, rpc.NewBlockResult(block, best))
+		}
+
+		if len(block.PrevHash) == 0 {
+			break
+		}
+
+		if len(view.Blocks) == pageSize {
+			view.Older = block.Heigth
+			break
+		}
+	}
+
+	e.render(w, http.StatusOK, "recent", view)
+}
+
+// block will show a block with its transactions and the check of its
+// proof of work
+func (e *Explorer) block(w http.ResponseWriter, arg string) {
+	hash, err := hex.DecodeString(arg)
+	chain := e.backend.Chain()
+	var block blockchain.Block
+	if err == nil {
+		block, err = chain.GetBlock(hash)
+	}
+
+	if err != nil {
+		e.fail(w, http.StatusNotFound, "block "+arg+" not found")
+		return
+	}
+
+	best, err := chain.BestHeight()
+	if err != nil {
+		e.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	view := blockView{
+		BlockResult: rpc.NewBlockResult(&block, best),
+		ValidPoW:    blockchain.NewProof(&block).Validate(),
+		Size:        len(block.Serialize()),
+	}
+
+	for _, tx := range block.Transactions {
+		view.Transactions = append(view.Transactions, rpc.NewTxResult(tx, &block, best))
+	}
+
+	e.render(w, http.StatusOK, "block", view)
+}
+
+// tx will show a transaction of the memory pool or of the chain, with
+// the outputs its inputs spend
+func (e *Explorer) tx(w http.ResponseWriter, arg string) {
+	id, err := hex.DecodeString(arg)
+	if err != nil || len(id) == 0 {
+		e.fail(w, http.StatusNotFound, "transaction "+arg+" not found")
+		return
+	}
+
+	chain := e.backend.Chain()
+	tx, block, ok := e.findTx(id)
+	if !ok {
+		e.fail(w, http.StatusNotFound, "transaction "+arg+" not found")
+		return
+	}
+
+	best, err := chain.BestHeight()
+	if err != nil {
+		e.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	view := txView{TxResult: rpc.NewTxResult(tx, block, best), Height: -1}
+	if block != nil {
+		view.Height = block.Heigth
+	}
+
+	for i, in := range view.TxResult.Inputs {
+		input := inputView{InputResult: in, Coinbase: tx.IsCoinBase(), Asm: script.Disassemble(tx.Inputs[i].Script)}
+		if !input.Coinbase {
+			if prevTx, err := chain.FindTransaction(tx.Inputs[i].ID); err == nil && in.Vout < len(prevTx.Outputs) {
+				input.Address = prevTx.Outputs[in.Vout].Address()
+				input.Value = prevTx.Outputs[in.Vout].Value
+			}
+		}
+
+		view.Inputs = append(view.Inputs, input)
+	}
+
+	for i, out := range view.TxResult.Outputs {
+		view.Outputs = append(view.Outputs, outputView{OutputResult: out, Asm: script.Disassemble(tx.Outputs[i].Script)})
+	}
+
+	e.render(w, http.StatusOK, "tx", view)
+}
+
+// findTx will look for the transaction in the memory pool and then in
+// the chain, the block is nil for the memory pool
+func (e *Explorer) findTx(id []byte) (*blockchain.Transaction, *blockchain.Block, bool) {
+	for _, tx := range e.backend.MemPool() {
+		if bytes.Equal(tx.ID, id) {
+			return &tx, nil, true
+		}
+	}
+
+	block, err := e.backend.Chain().TxBlock(id)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	for _, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, id) {
+			return tx, block, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// address will show the balance of an address and the transactions that
+// paid it or spent its coins, newest first
+func (e *Explorer) address(w http.ResponseWriter, address string) {
+	locking, err := blockchain.LockingScript(address)
+	if err != nil {
+		e.fail(w, http.StatusNotFound, "address "+address+" is not valid")
+		return
+	}
+
+	chain := e.backend.Chain()
+	view := addressView{Address: address}
+	UTXOSet := blockchain.UTXOSet{BlockChain: chain}
+	outs, err := UTXOSet.FindUnspentOutputs(locking)
+	if err != nil {
+		e.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, out := range outs {
+		view.Balance += out.Value
+	}
+
+	best, err := chain.BestHeight()
+	if err != nil {
+		e.fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// walk from the genesis up so the coins of the address are known
+	// before the transactions that spend them, one block at a time and
+	// keeping only the newest rows of the history
+	owned := make(map[string]int)
+	var history []historyRow
+	for height := 0; height <= best; height++ {
+		hash, err := chain.BlockHash(height)
+		if err != nil {
+			e.fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			e.fail(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		for _, tx := range block.Transactions {
+			delta := 0
+			touched := false
+			if !tx.IsCoinBase() {
+				for _, in := range tx.Inputs {
+					key := blockchain.OutPoint{TxID: in.ID, Index: in.Out}.String()
+					if value, ok := owned[key]; ok {
+						delta -= value
+						view.Sent += value
+						touched = true
+						delete(owned, key)
+					}
+				}
+			}
+
+			for index, out := range tx.Outputs {
+				if bytes.Equal(out.Script, locking) {
+					owned[blockchain.OutPoint{TxID: tx.ID, Index: index}.String()] = out.Value
+					delta += out.Value
+					view.Received += out.Value
+					touched = true
+				}
+			}
+
+			if !touched {
+				continue
+			}
+
+			history = append(history, historyRow{
+				TxID:      hex.EncodeToString(tx.ID),
+				BlockHash: hex.EncodeToString(block.Hash),
+				Height:    block.Heigth,
+				Time:      block.TimeStamp,
+				Delta:     delta,
+			})
+
+			if len(history) > historyLimit {
+				history = history[1:]
+				view.Truncated = true
+			}
+		}
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		view.History = append(view.History, history[i])
+	}
+
+	e.render(w, http.StatusOK, "address", view)
+}
+
+// search will send the query to the page of the address, block or
+// transaction it names, a number is the height of a block
+func (e *Explorer) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Redirect(w, r, Prefix, http.StatusFound)
+		return
+	}
+
+	if wallet.ValidateAddress(query) {
+		http.Redirect(w, r, Prefix+"address/"+query, http.StatusFound)
+		return
+	}
+
+	chain := e.backend.Chain()
+	if height, err := strconv.Atoi(query); err == nil {
+		for hash := chain.LastHash; len(hash) > 0; {
+			header, err := chain.GetHeader(hash)
+			if err != nil || header.Heigth < height {
+				break
+			}
+
+			if header.Heigth == height {
+				http.Redirect(w, r, Prefix+"block/"+hex.EncodeToString(hash), http.StatusFound)
+				return
+			}
+
+			hash = header.PrevHash
+		}
+	}
+
+	if hash, err := hex.DecodeString(query); err == nil && len(hash) > 0 {
+		if _, err := chain.GetHeader(hash); err == nil {
+			http.Redirect(w, r, Prefix+"block/"+query, http.StatusFound)
+			return
+		}
+
+		if _, _, ok := e.findTx(hash); ok {
+			http.Redirect(w, r, Prefix+"tx/"+query, http.StatusFound)
+			return
+		}
+	}
+
+	e.fail(w, http.StatusNotFound, "nothing matches "+query)
+}
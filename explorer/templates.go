@@ -0,0 +1,131 @@
+package explorer
+
+// layoutTemplate wraps every page with the search box
+const layoutTemplate = `{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>go-block explorer</title>
+<style>
+body { font-family: sans-serif; margin: 0 auto; max-width: 1100px; padding: 0 1em; color: #222; }
+header { display: flex; align-items: center; justify-content: space-between; border-bottom: 1px solid #ccc; padding: .8em 0; }
+header a { color: #222; font-weight: bold; text-decoration: none; }
+input[type=text] { width: 30em; padding: .3em; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { text-align: left; padding: .3em .6em; border-bottom: 1px solid #eee; }
+td.num { text-align: right; }
+code { font-size: .9em; word-break: break-all; }
+.ok { color: #080; } .bad { color: #b00; } .muted { color: #888; }
+</style>
+</head>
+<body>
+<header>
+<a href="/explorer/">go-block explorer</a>
+<form action="/explorer/search" method="get">
+<input type="text" name="q" placeholder="block hash, height, transaction id or address">
+<input type="submit" value="Search">
+</form>
+</header>
+{{end}}
+
+{{define "footer"}}
+</body>
+</html>
+{{end}}`
+
+// pageTemplates are the pages of the explorer
+const pageTemplates = `{{define "recent"}}{{template "header"}}
+<h2>Recent blocks</h2>
+<p class="muted">{{.MemPool}} transactions waiting in the memory pool</p>
+<table>
+<tr><th>Height</th><th>Hash</th><th>Time</th><th>Transactions</th><th>Bits</th></tr>
+{{range .Blocks}}<tr>
+<td>{{.Height}}</td>
+<td><a href="{{link "block" .Hash}}"><code>{{.Hash}}</code></a></td>
+<td>{{time .Time}}</td>
+<td class="num">{{len .Transactions}}</td>
+<td class="num">{{.Bits}}</td>
+</tr>{{end}}
+</table>
+{{if ge .Older 0}}<p><a href="/explorer/?before={{.Older}}">Older blocks</a></p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "block"}}{{template "header"}}
+<h2>Block {{.Height}}</h2>
+<table>
+<tr><th>Hash</th><td><code>{{.Hash}}</code></td></tr>
+<tr><th>Previous block</th><td>{{if .PrevHash}}<a href="{{link "block" .PrevHash}}"><code>{{.PrevHash}}</code></a>{{else}}<span class="muted">none, this is the genesis</span>{{end}}</td></tr>
+<tr><th>Time</th><td>{{time .Time}}</td></tr>
+<tr><th>Confirmations</th><td>{{.Confirmations}}</td></tr>
+<tr><th>Difficulty bits</th><td>{{.Bits}}</td></tr>
+<tr><th>Nonce</th><td>{{.Nonce}}</td></tr>
+<tr><th>Size</th><td>{{.Size}} bytes</td></tr>
+<tr><th>Proof of work</th><td>{{if .ValidPoW}}<span class="ok">valid</span>{{else}}<span class="bad">invalid</span>{{end}}</td></tr>
+</table>
+<h3>Transactions</h3>
+<table>
+<tr><th>Id</th><th>Inputs</th><th>Outputs</th></tr>
+{{range .Transactions}}<tr>
+<td><a href="{{link "tx" .TxID}}"><code>{{.TxID}}</code></a>{{if .Coinbase}} <span class="muted">coinbase</span>{{end}}</td>
+<td class="num">{{len .Inputs}}</td>
+<td>{{range .Outputs}}{{if .Address}}<a href="{{link "address" .Address}}">{{short .Address}}</a>{{else}}<span class="muted">non standard</span>{{end}} {{.Value}}<br>{{end}}</td>
+</tr>{{end}}
+</table>
+{{template "footer"}}{{end}}
+
+{{define "tx"}}{{template "header"}}
+<h2>Transaction</h2>
+<table>
+<tr><th>Id</th><td><code>{{.TxID}}</code></td></tr>
+<tr><th>Block</th><td>{{if .BlockHash}}<a href="{{link "block" .BlockHash}}"><code>{{.BlockHash}}</code></a> at height {{.Height}}{{else}}<span class="muted">waiting in the memory pool</span>{{end}}</td></tr>
+<tr><th>Confirmations</th><td>{{.Confirmations}}</td></tr>
+{{if .LockTime}}<tr><th>Lock time</th><td>{{.LockTime}}</td></tr>{{end}}
+</table>
+<h3>Inputs</h3>
+<table>
+<tr><th>Spends</th><th>From</th><th>Value</th><th>Script</th></tr>
+{{range .Inputs}}<tr>
+{{if .Coinbase}}<td><span class="muted">new coins</span></td><td></td><td></td>
+{{else}}<td><a href="{{link "tx" .TxID}}"><code>{{short .TxID}}</code></a>:{{.Vout}}</td>
+<td>{{if .Address}}<a href="{{link "address" .Address}}">{{.Address}}</a>{{end}}</td>
+<td class="num">{{.Value}}</td>{{end}}
+<td><code>{{.Asm}}</code></td>
+</tr>{{end}}
+</table>
+<h3>Outputs</h3>
+<table>
+<tr><th>#</th><th>To</th><th>Value</th><th>Script</th></tr>
+{{range .Outputs}}<tr>
+<td>{{.N}}</td>
+<td>{{if .Address}}<a href="{{link "address" .Address}}">{{.Address}}</a>{{else}}<span class="muted">non standard</span>{{end}}</td>
+<td class="num">{{.Value}}</td>
+<td><code>{{.Asm}}</code></td>
+</tr>{{end}}
+</table>
+{{template "footer"}}{{end}}
+
+{{define "address"}}{{template "header"}}
+<h2>Address</h2>
+<table>
+<tr><th>Address</th><td><code>{{.Address}}</code></td></tr>
+<tr><th>Balance</th><td>{{.Balance}}</td></tr>
+<tr><th>Received</th><td>{{.Received}}</td></tr>
+<tr><th>Sent</th><td>{{.Sent}}</td></tr>
+</table>
+<h3>History</h3>
+<table>
+<tr><th>Transaction</th><th>Block</th><th>Time</th><th>Change</th></tr>
+{{range .History}}<tr>
+<td><a href="{{link "tx" .TxID}}"><code>{{.TxID}}</code></a></td>
+<td><a href="{{link "block" .BlockHash}}">{{.Height}}</a></td>
+<td>{{time .Time}}</td>
+<td class="num">{{.Delta}}</td>
+</tr>{{end}}
+</table>
+{{if .Truncated}}<p class="muted">Only the newest transactions are shown</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "error"}}{{template "header"}}
+<h2>{{.Status}}</h2>
+<p>{{.Message}}</p>
+{{template "footer"}}{{end}}`
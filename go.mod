@@ -4,8 +4,10 @@ go 1.16
 
 require (
 	github.com/dgraph-io/badger/v3 v3.2011.1 // direct
-	github.com/mr-tron/base58 v1.2.0 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
-	github.com/vrecan/death/v3 v3.0.3 // indirect
-	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a // indirect
+	github.com/mr-tron/base58 v1.2.0
+	github.com/pkg/errors v0.9.1
+	github.com/tyler-smith/go-bip39 v1.1.0
+	github.com/vrecan/death/v3 v3.0.3
+	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
+	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1
 )
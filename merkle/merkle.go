@@ -0,0 +1,76 @@
+// Package merkle implements the merkle tree used to commit to the
+// transactions of a block.
+//
+// The construction is fully specified so the root can be reproduced
+// outside of this code base:
+//
+//	leaf  = sha256(0x00 || data)
+//	inner = sha256(0x01 || left || right)
+//
+// Leaves are paired from left to right. When a level has an odd number
+// of nodes the last node is promoted to the next level unchanged, it is
+// never duplicated or hashed with itself. The root of a single leaf is
+// the leaf hash and the root of an empty list is sha256 of no data.
+// Reference vectors are kept in vectors.json.
+package merkle
+
+import "crypto/sha256"
+
+const (
+	LeafPrefix = byte(0x00) // domain separator for leaf hashes
+	NodePrefix = byte(0x01) // domain separator for inner node hashes
+)
+
+// HashLeaf will hash the given data as a leaf of the tree
+func HashLeaf(data []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{LeafPrefix})
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
+
+// HashNode will hash the two given child hashes into their parent hash
+func HashNode(left, right []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{NodePrefix})
+	hasher.Write(left)
+	hasher.Write(right)
+	return hasher.Sum(nil)
+}
+
+// Root will compute the merkle root of the given data without building
+// the pointer tree, only one level of hashes is kept in memory at a time
+func Root(data [][]byte) []byte {
+	if len(data) == 0 {
+		hash := sha256.Sum256(nil)
+		return hash[:]
+	}
+
+	level := make([][]byte, len(data))
+	for i, dat := range data {
+		level[i] = HashLeaf(dat)
+	}
+
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+
+	return level[0]
+}
+
+// nextLevel will hash the given level in pairs, promoting the last
+// hash when the level has an odd number of hashes
+func nextLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+
+		next = append(next, HashNode(level[i], level[i+1]))
+	}
+
+	return next
+}
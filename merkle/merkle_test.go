@@ -0,0 +1,120 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// vector is a case of vectors.json, the leaves are hex encoded
+type vector struct {
+	Leaves []string `json:"leaves"`
+	Root   string   `json:"root"`
+}
+
+// loadVectors will read the reference vectors and decode their leaves
+func loadVectors(t *testing.T) ([][][]byte, [][]byte) {
+	t.Helper()
+
+	content, err := ioutil.ReadFile("vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file struct {
+		Vectors []vector `json:"vectors"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	var leaves [][][]byte
+	var roots [][]byte
+	for _, v := range file.Vectors {
+		var data [][]byte
+		for _, leaf := range v.Leaves {
+			dat, err := hex.DecodeString(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data = append(data, dat)
+		}
+
+		root, err := hex.DecodeString(v.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		leaves = append(leaves, data)
+		roots = append(roots, root)
+	}
+
+	return leaves, roots
+}
+
+func TestVectors(t *testing.T) {
+	leaves, roots := loadVectors(t)
+
+	// the odd levels promote their last hash, they must be covered
+	sizes := make(map[int]bool)
+	for _, data := range leaves {
+		sizes[len(data)] = true
+	}
+
+	for _, size := range []int{0, 1, 3, 5, 6} {
+		if !sizes[size] {
+			t.Fatalf("vectors.json has no case with %d leaves", size)
+		}
+	}
+
+	for i, data := range leaves {
+		t.Run(fmt.Sprintf("%d leaves", len(data)), func(t *testing.T) {
+			if root := Root(data); !bytes.Equal(root, roots[i]) {
+				t.Fatalf("Root got %x, want %x", root, roots[i])
+			}
+
+			tree := NewTree(data)
+			if root := tree.Root(); !bytes.Equal(root, roots[i]) {
+				t.Fatalf("Tree got %x, want %x", root, roots[i])
+			}
+
+			for index, dat := range data {
+				proof, err := BuildProof(data, index)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				treeProof, err := tree.Proof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(proof, treeProof) {
+					t.Fatalf("leaf %d: BuildProof and the tree built different proofs", index)
+				}
+
+				if !proof.Verify(roots[i], dat) {
+					t.Fatalf("leaf %d: the proof does not verify", index)
+				}
+
+				if proof.Verify(roots[i], append([]byte{0xff}, dat...)) {
+					t.Fatalf("leaf %d: the proof verifies other data", index)
+				}
+			}
+
+			if _, err := BuildProof(data, len(data)); !errors.Is(err, ErrIndexOutOfRange) {
+				t.Fatalf("got error %v for an index past the leaves, want %v", err, ErrIndexOutOfRange)
+			}
+
+			if _, err := tree.Proof(-1); !errors.Is(err, ErrIndexOutOfRange) {
+				t.Fatalf("got error %v for a negative index, want %v", err, ErrIndexOutOfRange)
+			}
+		})
+	}
+}
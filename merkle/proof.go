@@ -0,0 +1,91 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+)
+
+var ErrIndexOutOfRange = errors.New("merkle: leaf index out of range")
+
+type ProofStep struct {
+	Hash []byte // represents the sibling hash at this level
+	Left bool   // represents if the sibling is on the left side
+}
+
+type Proof struct {
+	Index int         // represents the index of the proven leaf
+	Steps []ProofStep // represents the sibling hashes from the leaf to the root
+}
+
+// Proof will build the inclusion proof of the leaf at the given index
+func (t *Tree) Proof(index int) (*Proof, error) {
+	if index < 0 || index >= len(t.Leaves) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	proof := &Proof{Index: index}
+	node := t.Leaves[index]
+
+	for node.Parent != nil {
+		parent := node.Parent
+		if parent.Left == node {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: parent.Right.Hash, Left: false})
+		} else {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: parent.Left.Hash, Left: true})
+		}
+
+		node = parent
+	}
+
+	return proof, nil
+}
+
+// BuildProof will build the inclusion proof of the leaf at the given
+// index without keeping the pointer tree around
+func BuildProof(data [][]byte, index int) (*Proof, error) {
+	if index < 0 || index >= len(data) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	level := make([][]byte, len(data))
+	for i, dat := range data {
+		level[i] = HashLeaf(dat)
+	}
+
+	proof := &Proof{Index: index}
+	pos := index
+
+	for len(level) > 1 {
+		if pos%2 == 1 {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: level[pos-1], Left: true})
+		} else if pos+1 < len(level) {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: level[pos+1], Left: false})
+		}
+
+		level = nextLevel(level)
+		pos /= 2
+	}
+
+	return proof, nil
+}
+
+// ComputeRoot will fold the proof over the given leaf data and return
+// the resulting root hash
+func (p *Proof) ComputeRoot(data []byte) []byte {
+	hash := HashLeaf(data)
+
+	for _, step := range p.Steps {
+		if step.Left {
+			hash = HashNode(step.Hash, hash)
+		} else {
+			hash = HashNode(hash, step.Hash)
+		}
+	}
+
+	return hash
+}
+
+// Verify will check that the given leaf data is included under the given root
+func (p *Proof) Verify(root, data []byte) bool {
+	return bytes.Equal(p.ComputeRoot(data), root)
+}
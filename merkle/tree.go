@@ -0,0 +1,65 @@
+package merkle
+
+type Tree struct {
+	RootNode *Node   // represents the root of the merkle tree
+	Leaves   []*Node // represents the leaves of the tree in order
+}
+
+type Node struct {
+	Left   *Node  // represents the left child of the node, nil for leaves
+	Right  *Node  // represents the right child of the node, nil for leaves
+	Parent *Node  // represents the parent of the node, nil for the root
+	Hash   []byte // represents the hash of the node
+}
+
+// NewTree will build the full pointer tree for the given data. Use Root
+// when only the root hash is needed
+func NewTree(data [][]byte) *Tree {
+	tree := &Tree{}
+
+	if len(data) == 0 {
+		tree.RootNode = &Node{Hash: Root(nil)}
+		return tree
+	}
+
+	for _, dat := range data {
+		tree.Leaves = append(tree.Leaves, &Node{Hash: HashLeaf(dat)})
+	}
+
+	level := tree.Leaves
+	for len(level) > 1 {
+		var next []*Node
+
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+
+			next = append(next, newParent(level[i], level[i+1]))
+		}
+
+		level = next
+	}
+
+	tree.RootNode = level[0]
+	return tree
+}
+
+// newParent will create the inner node for the given children
+func newParent(left, right *Node) *Node {
+	node := &Node{Left: left, Right: right, Hash: HashNode(left.Hash, right.Hash)}
+	left.Parent = node
+	right.Parent = node
+	return node
+}
+
+// Root will return the root hash of the tree
+func (t *Tree) Root() []byte {
+	return t.RootNode.Hash
+}
+
+// IsLeaf will check if the node has no children
+func (n *Node) IsLeaf() bool {
+	return n.Left == nil && n.Right == nil
+}
@@ -1,14 +1,15 @@
 package network
 
 import (
-	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 
 	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/events"
 )
 
 // handle version will handle the get version request
@@ -30,6 +31,7 @@ func HandleVersion(request []byte, chain *blockchain.BlockChain) {
 
 	if !NodeIsKnown(payload.AddrFrom) {
 		KnownNodes = append(KnownNodes, payload.AddrFrom)
+		nodeEvents.Publish(events.Event{Topic: events.PeerConnected, Peer: payload.AddrFrom})
 	}
 }
 
@@ -56,48 +58,74 @@ func HandleInv(request []byte, chain *blockchain.BlockChain) {
 	fmt.Printf("Recivied inventory with %d, %s\n", len(payload.Items), payload.Type)
 
 	if payload.Type == "block" {
-		blocksInTransit = payload.Items
-		blockHash := payload.Items[0]
-		SendGetData(payload.AddrFrom, "block", blockHash)
-
-		newInTransit := [][]byte{}
-
-		for _, v := range blocksInTransit {
-			if !bytes.Equal(v, blockHash) {
-				newInTransit = append(newInTransit, v)
-			}
+		blocksInTransit = missingBlocks(chain, payload.Items)
+		if len(blocksInTransit) == 0 {
+			return
 		}
 
-		blocksInTransit = newInTransit
+		blockHash := blocksInTransit[0]
+		blocksInTransit = blocksInTransit[1:]
+		SendGetData(payload.AddrFrom, "block", blockHash)
 	}
 
 	if payload.Type == "tx" {
 		txID := payload.Items[0]
-		if memoryPool[hex.EncodeToString(txID)].ID == nil {
+		if _, ok := poolTx(hex.EncodeToString(txID)); !ok {
 			SendGetData(payload.AddrFrom, "tx", txID)
 		}
 	}
 }
 
-// handle block will handle the address get block request
+// missingBlocks will return the blocks of the inventory the chain does
+// not have, the inventory lists them from the tip down and they are
+// asked for from the oldest so every block arrives after its parent
+func missingBlocks(chain *blockchain.BlockChain, items [][]byte) [][]byte {
+	missing := [][]byte{}
+	for i := len(items) - 1; i >= 0; i-- {
+		if !chain.HasBlock(items[i]) {
+			missing = append(missing, items[i])
+		}
+	}
+
+	return missing
+}
+
+// handle block will check the block received from a peer and add it to
+// the chain, a block whose parent is unknown asks the peer for its blocks
 func HandeBlock(request []byte, chain *blockchain.BlockChain) {
 	var payload Block
 	if err := DeserializePayload(request, &payload); err != nil {
 		log.Panic(err)
 	}
 
-	blockData := payload.Block
-	block := blockchain.Deserialize(blockData)
+	block, err := blockchain.ParseBlock(payload.Block)
+	if err != nil {
+		fmt.Printf("Rejected a block from %s: %s\n", payload.AddrFrom, err)
+		return
+	}
+
 	fmt.Println("Recevied a new block!")
 
-	chain.MineBlock(block.Transactions)
+	if err := chain.AddBlock(block); err != nil {
+		fmt.Printf("Rejected block %x: %s\n", block.Hash, err)
+		blocksInTransit = [][]byte{}
+
+		if errors.Is(err, blockchain.ErrOrphanBlock) {
+			SendGetBlock(payload.AddrFrom)
+		}
+
+		return
+	}
+
+	removeFromPool(block.Transactions, events.ReasonMined)
 	fmt.Printf("Added block %x\n", block.Hash)
 
 	if len(blocksInTransit) > 0 {
+		// the next block is taken before it is asked for, its answer may
+		// be handled before this handler returns
 		blockHash := blocksInTransit[0]
-		SendGetData(payload.AddrFrom, "block", blockHash)
-
 		blocksInTransit = blocksInTransit[1:]
+		SendGetData(payload.AddrFrom, "block", blockHash)
 	} else {
 		UtxoSet := blockchain.UTXOSet{BlockChain: chain}
 		UtxoSet.Reindex()
@@ -134,7 +162,11 @@ func HandleGetData(request []byte, chain *blockchain.BlockChain) {
 
 	if payload.Type == "tx" {
 		txID := hex.EncodeToString(payload.ID)
-		tx := memoryPool[txID]
+		tx, ok := poolTx(txID)
+		if !ok {
+			return
+		}
+
 		SendTx(payload.AddrFrom, &tx)
 	}
 }
@@ -148,20 +180,8 @@ func HandleTx(request []byte, chain *blockchain.BlockChain) {
 
 	txData := payload.Transaction
 	tx := blockchain.DeserializeTransaction(txData)
-	memoryPool[hex.EncodeToString(tx.ID)] = tx
-
-	fmt.Printf("%s, %d", nodeAddress, len(memoryPool))
-
-	if nodeAddress == KnownNodes[0] {
-		for _, node := range KnownNodes {
-			if node != nodeAddress && node != payload.AddrFrom {
-				SendInv(node, "tx", [][]byte{tx.ID})
-			}
-		}
-	} else {
-		if len(memoryPool) >= 2 && len(minerAddress) > 0 {
-			MineTx(chain)
-		}
+	if err := acceptTx(chain, &tx, payload.AddrFrom); err != nil {
+		fmt.Printf("Rejected transaction %x: %s\n", tx.ID, err)
 	}
 }
 
@@ -174,9 +194,18 @@ func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
 		log.Panic(err)
 	}
 
+	req, ok := stripMagic(req)
+	if !ok {
+		fmt.Printf("Dropped a request from another network\n")
+		return
+	}
+
 	command := BytesToCmd(req[:commandLength])
 	fmt.Printf("Received %s command\n", command)
 
+	chainLock.Lock()
+	defer chainLock.Unlock()
+
 	switch command {
 	case "addr":
 		HandleAddr(req)
@@ -187,7 +216,7 @@ func HandleConnection(conn net.Conn, chain *blockchain.BlockChain) {
 	case "inv":
 		HandleInv(req, chain)
 
-	case "getblcoks":
+	case "getblocks":
 		HandleGetBlocks(req, chain)
 
 	case "getdata":
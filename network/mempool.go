@@ -0,0 +1,117 @@
+package network
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/events"
+)
+
+var (
+	// poolMu guards the memory pool, the peers and the rpc server use it
+	// at the same time
+	poolMu sync.Mutex
+	// poolAddresses holds the addresses touched by the transactions of the
+	// memory pool, found when they were accepted
+	poolAddresses = make(map[string][]string)
+)
+
+// addToPool will add the transaction to the memory pool, publish it with
+// the addresses it touches and return the number of transactions waiting
+func addToPool(tx blockchain.Transaction, addresses []string) int {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	txID := hex.EncodeToString(tx.ID)
+	if _, ok := memoryPool[txID]; !ok {
+		nodeEvents.Publish(events.Event{Topic: events.TxAccepted, Hash: txID, Addresses: addresses})
+	}
+
+	memoryPool[txID] = tx
+	poolAddresses[txID] = addresses
+	return len(memoryPool)
+}
+
+// poolTx will return the transaction of the memory pool with the given hex id
+func poolTx(txID string) (blockchain.Transaction, bool) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	tx, ok := memoryPool[txID]
+	return tx, ok
+}
+
+// poolTxs will return a copy of the transactions of the memory pool
+func poolTxs() []blockchain.Transaction {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	txs := make([]blockchain.Transaction, 0, len(memoryPool))
+	for _, tx := range memoryPool {
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+// removeFromPool will drop the transactions from the memory pool, publish
+// the ones that were in it with the reason and return the number of
+// transactions still waiting
+func removeFromPool(txs []*blockchain.Transaction, reason string) int {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	for _, tx := range txs {
+		txID := hex.EncodeToString(tx.ID)
+		if _, ok := memoryPool[txID]; !ok {
+			continue
+		}
+
+		nodeEvents.Publish(events.Event{Topic: events.TxRemoved, Hash: txID, Reason: reason, Addresses: poolAddresses[txID]})
+		delete(memoryPool, txID)
+		delete(poolAddresses, txID)
+	}
+
+	return len(memoryPool)
+}
+
+// acceptTx will add a transaction received from a peer, or created by the
+// node itself when from is empty, to the memory pool and pass it on. The
+// seed node announces it to the other nodes, the other nodes send their
+// own transactions to the seed and mine once two transactions wait. The
+// inputs are verified before, so the block that mines it finds them in
+// the signature cache
+func acceptTx(chain *blockchain.BlockChain, tx *blockchain.Transaction, from string) error {
+	if !chain.VerifyTransaction(tx) {
+		return blockchain.ErrInvalidTx
+	}
+
+	if err := chain.CheckTxLocks(tx); err != nil {
+		return err
+	}
+
+	size := addToPool(*tx, chain.TxAddresses(tx))
+	fmt.Printf("%s, %d\n", nodeAddress, size)
+
+	if len(KnownNodes) > 0 && nodeAddress == KnownNodes[0] {
+		for _, node := range KnownNodes {
+			if node != nodeAddress && node != from {
+				SendInv(node, "tx", [][]byte{tx.ID})
+			}
+		}
+
+		return nil
+	}
+
+	if from == "" && len(KnownNodes) > 0 {
+		SendTx(KnownNodes[0], tx)
+	}
+
+	if size >= 2 && len(minerAddress) > 0 {
+		MineTx(chain)
+	}
+
+	return nil
+}
@@ -2,27 +2,36 @@ package network
 
 import (
 	"bytes"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"sync"
 
 	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/events"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/wallet"
 )
 
 const (
 	protocol      = "tcp"
 	version       = 1
 	commandLength = 12
+	magicLength   = 4
 )
 
 var (
 	nodeAddress     string
 	minerAddress    string
-	KnownNodes      = []string{"localhost:3000"}
+	KnownNodes      = []string{params.Active.SeedNode()}
 	blocksInTransit = [][]byte{}
 	memoryPool      = make(map[string]blockchain.Transaction)
+	nodeEvents      = events.NewBus()
+
+	// chainLock is held while a message of a peer or a call of the rpc
+	// server reads or changes the chain, they run one at a time
+	chainLock sync.Mutex
 )
 
 type Addr struct {
@@ -72,8 +81,7 @@ func RequestBlocks() {
 func SendAddr(address string) {
 	nodes := Addr{AddrList: KnownNodes}
 	nodes.AddrList = append(nodes.AddrList, nodeAddress)
-	payload := GobEncode(nodes)
-	request := append(CmdToBytes("addr"), payload...)
+	request := NewRequest("addr", &nodes)
 
 	sendData(address, request)
 }
@@ -81,8 +89,7 @@ func SendAddr(address string) {
 // Send block will create the request of the block to be send
 func SendBlock(addr string, b *blockchain.Block) {
 	data := Block{AddrFrom: nodeAddress, Block: b.Serialize()}
-	payload := GobEncode(data)
-	request := append(CmdToBytes("block"), payload...)
+	request := NewRequest("block", &data)
 
 	sendData(addr, request)
 }
@@ -90,38 +97,33 @@ func SendBlock(addr string, b *blockchain.Block) {
 // SendInv will the create request of the inventory to be send
 func SendInv(addr, kind string, items [][]byte) {
 	inventory := Inv{AddrFrom: nodeAddress, Type: kind, Items: items}
-	payload := GobEncode(inventory)
-	request := append(CmdToBytes("inv"), payload...)
+	request := NewRequest("inv", &inventory)
 	sendData(addr, request)
 }
 
 // SendTx will the create request of the transaction to be send
 func SendTx(addr string, txn *blockchain.Transaction) {
 	data := Tx{AddrFrom: nodeAddress, Transaction: txn.Serialize()}
-	payload := GobEncode(data)
-	request := append(CmdToBytes("tx"), payload...)
+	request := NewRequest("tx", &data)
 	sendData(addr, request)
 }
 
 // SendVersion will the create request of the Version to be send
 func SendVersion(addr string, chain *blockchain.BlockChain) {
 	bestHeigth := chain.GetBestHeigth()
-	payload := GobEncode(Version{Version: version, BestHeigth: bestHeigth, AddrFrom: nodeAddress})
-	request := append(CmdToBytes("version"), payload...)
+	request := NewRequest("version", &Version{Version: version, BestHeigth: bestHeigth, AddrFrom: nodeAddress})
 	sendData(addr, request)
 }
 
 // SendGetBLock will the create request of the Getblock to be send
 func SendGetBlock(addr string) {
-	payload := GobEncode(GetBlocks{AddrFrom: nodeAddress})
-	request := append(CmdToBytes("getblocks"), payload...)
+	request := NewRequest("getblocks", &GetBlocks{AddrFrom: nodeAddress})
 	sendData(addr, request)
 }
 
 // SendGetData will the create request of the GetData to be send
 func SendGetData(addr, kind string, id []byte) {
-	payload := GobEncode(GetData{AddrFrom: nodeAddress, Type: kind, ID: id})
-	request := append(CmdToBytes("getdata"), payload...)
+	request := NewRequest("getdata", &GetData{AddrFrom: nodeAddress, Type: kind, ID: id})
 	sendData(addr, request)
 }
 
@@ -150,24 +152,26 @@ func sendData(addr string, data []byte) {
 	}
 }
 
-// mineTx will will nine the transaction
+// mineTx will will nine the transaction, the inputs of the transactions
+// of the memory pool were verified when they entered it so the block
+// verifies them from the signature cache
 func MineTx(chain *blockchain.BlockChain) {
 	var txs []*blockchain.Transaction
 
-	for id := range memoryPool {
-		fmt.Printf("tx: %s\n", memoryPool[id].ID)
-		tx := memoryPool[id]
-		if chain.VerifyTransaction(&tx) {
+	for _, tx := range poolTxs() {
+		tx := tx
+		fmt.Printf("tx: %x\n", tx.ID)
+		if chain.CheckTxLocks(&tx) == nil {
 			txs = append(txs, &tx)
 		}
 	}
 
 	if len(txs) == 0 {
-		fmt.Println("All the transactions are invalid")
+		fmt.Println("All the transactions are still locked")
 		return
 	}
 
-	cbTx := blockchain.CoinbaseTx(minerAddress, "")
+	cbTx := blockchain.CoinbaseTxWithFees(minerAddress, "", chain.TransactionFees(txs))
 	txs = append(txs, cbTx)
 	newBlock := chain.MineBlock(txs)
 
@@ -175,10 +179,7 @@ func MineTx(chain *blockchain.BlockChain) {
 	UtxoSet.Reindex()
 	fmt.Println("New Block mined")
 
-	for _, tx := range txs {
-		txID := hex.EncodeToString(tx.ID)
-		delete(memoryPool, txID)
-	}
+	left := removeFromPool(txs, events.ReasonMined)
 
 	for _, node := range KnownNodes {
 		if node != nodeAddress {
@@ -186,13 +187,21 @@ func MineTx(chain *blockchain.BlockChain) {
 		}
 	}
 
-	if len(memoryPool) > 0 {
+	if left > 0 {
 		MineTx(chain)
 	}
 }
 
-// StartServer will start the server with the given node id
-func StartServer(nodeID, mineAddress string) {
+// HTTPOptions are the settings of the http services of the node
+type HTTPOptions struct {
+	RPCPort  int  // represents the port of the rpc server, 0 picks the node port plus rpc.PortOffset
+	REST     bool // represents if the read only rest api is served next to the rpc
+	Explorer bool // represents if the block explorer is served next to the rpc
+}
+
+// StartServer will start the server with the given node id, and the http
+// services of the node with the given options
+func StartServer(nodeID, mineAddress string, opts HTTPOptions) {
 	nodeAddress = fmt.Sprintf("localhost:%s", nodeID)
 	minerAddress = mineAddress
 	ln, err := net.Listen(protocol, nodeAddress)
@@ -202,9 +211,19 @@ func StartServer(nodeID, mineAddress string) {
 
 	defer ln.Close()
 
+	if wallets, err := wallet.CreateWallets(nodeID); err == nil {
+		wallets.Lock()
+		nodeWallets = wallets
+	}
+
 	chain := blockchain.ContinueBlockChain(nodeID)
-	defer chain.Database.Close()
-	go CloseDB(chain)
+	chain.Events = nodeEvents
+	defer chain.Store.Close()
+	go CloseDB(chain, nodeID)
+
+	if err := startRPC(chain, nodeID, opts); err != nil {
+		log.Panic(err)
+	}
 
 	if nodeAddress != KnownNodes[0] {
 		SendVersion(KnownNodes[0], chain)
@@ -0,0 +1,102 @@
+package network
+
+import "github.com/Haizza1/go-block/wire"
+
+// Encode will write the addresses of the known nodes
+func (p *Addr) Encode(w *wire.Writer) {
+	w.VarInt(uint64(len(p.AddrList)))
+	for _, addr := range p.AddrList {
+		w.String(addr)
+	}
+}
+
+// Decode will read the addresses of the known nodes
+func (p *Addr) Decode(r *wire.Reader) {
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		p.AddrList = append(p.AddrList, r.String())
+	}
+}
+
+// Encode will write the sender and the serialized block
+func (p *Block) Encode(w *wire.Writer) {
+	w.String(p.AddrFrom)
+	w.VarBytes(p.Block)
+}
+
+// Decode will read the sender and the serialized block
+func (p *Block) Decode(r *wire.Reader) {
+	p.AddrFrom = r.String()
+	p.Block = r.VarBytes()
+}
+
+// Encode will write the sender
+func (p *GetBlocks) Encode(w *wire.Writer) {
+	w.String(p.AddrFrom)
+}
+
+// Decode will read the sender
+func (p *GetBlocks) Decode(r *wire.Reader) {
+	p.AddrFrom = r.String()
+}
+
+// Encode will write the sender, the kind and the id of the requested data
+func (p *GetData) Encode(w *wire.Writer) {
+	w.String(p.AddrFrom)
+	w.String(p.Type)
+	w.VarBytes(p.ID)
+}
+
+// Decode will read the sender, the kind and the id of the requested data
+func (p *GetData) Decode(r *wire.Reader) {
+	p.AddrFrom = r.String()
+	p.Type = r.String()
+	p.ID = r.VarBytes()
+}
+
+// Encode will write the sender, the kind and the ids of the inventory
+func (p *Inv) Encode(w *wire.Writer) {
+	w.String(p.AddrFrom)
+	w.String(p.Type)
+	w.VarInt(uint64(len(p.Items)))
+	for _, item := range p.Items {
+		w.VarBytes(item)
+	}
+}
+
+// Decode will read the sender, the kind and the ids of the inventory
+func (p *Inv) Decode(r *wire.Reader) {
+	p.AddrFrom = r.String()
+	p.Type = r.String()
+
+	count := r.Count()
+	for i := 0; i < count && r.Err() == nil; i++ {
+		p.Items = append(p.Items, r.VarBytes())
+	}
+}
+
+// Encode will write the sender and the serialized transaction
+func (p *Tx) Encode(w *wire.Writer) {
+	w.String(p.AddrFrom)
+	w.VarBytes(p.Transaction)
+}
+
+// Decode will read the sender and the serialized transaction
+func (p *Tx) Decode(r *wire.Reader) {
+	p.AddrFrom = r.String()
+	p.Transaction = r.VarBytes()
+}
+
+// Encode will write the protocol version, the best height and the sender
+func (p *Version) Encode(w *wire.Writer) {
+	w.Uint32(uint32(p.Version))
+	w.Uint32(uint32(p.BestHeigth))
+	w.String(p.AddrFrom)
+}
+
+// Decode will read the protocol version, the best height and the sender
+func (p *Version) Decode(r *wire.Reader) {
+	p.Version = int(r.Uint32())
+	p.BestHeigth = int(r.Uint32())
+	p.AddrFrom = r.String()
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/events"
+	"github.com/Haizza1/go-block/explorer"
+	"github.com/Haizza1/go-block/rpc"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// nodeWallets holds the wallets of the running node, nil when the node has no wallet file
+var nodeWallets *wallet.Wallets
+
+// nodeBackend is the running node seen by the rpc server
+type nodeBackend struct {
+	chain  *blockchain.BlockChain // represents the blockchain of the node
+	nodeID string                 // represents the id of the node
+	server *rpc.Server            // represents the rpc server of the node
+}
+
+// startRPC will write the cookie of the node and serve its rpc api on
+// localhost, on the node port plus rpc.PortOffset when the port is 0
+func startRPC(chain *blockchain.BlockChain, nodeID string, opts HTTPOptions) error {
+	port := opts.RPCPort
+	if port == 0 {
+		nodePort, err := strconv.Atoi(nodeID)
+		if err != nil {
+			return fmt.Errorf("the node id %s is not a port, set the rpc port", nodeID)
+		}
+
+		port = nodePort + rpc.PortOffset
+	}
+
+	addr := fmt.Sprintf("localhost:%d", port)
+	auth, err := rpc.WriteCookie(nodeID, addr)
+	if err != nil {
+		return err
+	}
+
+	backend := &nodeBackend{chain: chain, nodeID: nodeID}
+	backend.server = rpc.NewServer(backend, auth, &chainLock)
+	if opts.REST {
+		backend.server.EnableREST()
+	}
+
+	if opts.Explorer {
+		backend.server.Handle(explorer.Prefix, explorer.New(backend))
+	}
+
+	if err := backend.server.Start(addr); err != nil {
+		rpc.RemoveCookie(nodeID)
+		return err
+	}
+
+	fmt.Printf("RPC server listening on %s\n", addr)
+	if opts.REST {
+		fmt.Printf("REST api at http://%s/rest/\n", addr)
+	}
+
+	if opts.Explorer {
+		fmt.Printf("Block explorer at http://%s%s\n", addr, explorer.Prefix)
+	}
+
+	return nil
+}
+
+// Chain will return the blockchain of the node
+func (b *nodeBackend) Chain() *blockchain.BlockChain {
+	return b.chain
+}
+
+// Wallets will return the wallets of the node, nil when it has none
+func (b *nodeBackend) Wallets() *wallet.Wallets {
+	return nodeWallets
+}
+
+// MemPool will return the transactions waiting to be mined
+func (b *nodeBackend) MemPool() []blockchain.Transaction {
+	return poolTxs()
+}
+
+// Peers will return the addresses of the known nodes but this one
+func (b *nodeBackend) Peers() []string {
+	var peers []string
+	for _, node := range KnownNodes {
+		if node != nodeAddress {
+			peers = append(peers, node)
+		}
+	}
+
+	return peers
+}
+
+// Events will return the bus of the events of the node
+func (b *nodeBackend) Events() *events.Bus {
+	return nodeEvents
+}
+
+// SendTransaction will add a transaction of the node to the memory pool
+// and pass it to the network
+func (b *nodeBackend) SendTransaction(tx *blockchain.Transaction) error {
+	return acceptTx(b.chain, tx, "")
+}
+
+// Stop will wait for the stop call to reply, then remove the cookie,
+// close the database and exit
+func (b *nodeBackend) Stop() {
+	if err := b.server.Shutdown(); err != nil {
+		fmt.Println(err)
+	}
+
+	rpc.RemoveCookie(b.nodeID)
+	chainLock.Lock()
+	b.chain.Store.Close()
+	fmt.Println("Node stopped")
+	os.Exit(0)
+}
@@ -2,17 +2,34 @@ package network
 
 import (
 	"bytes"
-	"encoding/gob"
 	"fmt"
-	"log"
 	"os"
 	"runtime"
 	"syscall"
 
 	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/rpc"
+	"github.com/Haizza1/go-block/wire"
 	"github.com/vrecan/death/v3"
 )
 
+// withMagic will start the request with the magic of the active network,
+// the nodes of other networks drop it
+func withMagic(request []byte) []byte {
+	return append(params.Active.Magic[:], request...)
+}
+
+// stripMagic will check that the request belongs to the active network
+// and remove its magic
+func stripMagic(request []byte) ([]byte, bool) {
+	if len(request) < magicLength+commandLength || !bytes.Equal(request[:magicLength], params.Active.Magic[:]) {
+		return nil, false
+	}
+
+	return request[magicLength:], true
+}
+
 func ExtractCmd(request []byte) []byte {
 	return request[:commandLength]
 }
@@ -43,16 +60,23 @@ func BytesToCmd(bytes []byte) string {
 	return fmt.Sprintf("%s", cmd)
 }
 
-// GobEncode will allow us to serialize data in a generic way
-func GobEncode(data interface{}) []byte {
-	var buff bytes.Buffer
+// Payload is the data that follows the command of a request, it is
+// written with the canonical encoding of the wire package
+type Payload interface {
+	Encode(w *wire.Writer) // writes the payload
+	Decode(r *wire.Reader) // reads the payload
+}
 
-	encoder := gob.NewEncoder(&buff)
-	if err := encoder.Encode(data); err != nil {
-		log.Panic(err)
-	}
+// NewRequest will create the request of the command with the given
+// payload, the message version goes between them
+func NewRequest(cmd string, payload Payload) []byte {
+	w := wire.NewWriter()
+	w.Raw(params.Active.Magic[:])
+	w.Raw(CmdToBytes(cmd))
+	w.Uint32(wire.MessageVersion)
+	payload.Encode(w)
 
-	return buff.Bytes()
+	return w.Bytes()
 }
 
 // Node is known will check if the given node is
@@ -68,24 +92,28 @@ func NodeIsKnown(addr string) bool {
 }
 
 // Deserialze payload will deserialize the request into a struuct
-func DeserializePayload(request []byte, payload interface{}) error {
-	var buff bytes.Buffer
-	buff.Write(request[commandLength:])
-	dec := gob.NewDecoder(&buff)
-
-	if err := dec.Decode(payload); err != nil {
-		return err
+func DeserializePayload(request []byte, payload Payload) error {
+	if len(request) < commandLength {
+		return wire.ErrUnexpectedEOF
 	}
-	return nil
+
+	r := wire.NewReader(request[commandLength:])
+	r.Version(wire.MessageVersion)
+	payload.Decode(r)
+
+	return r.Done()
 }
 
-// CloseDB will grafully shutdown the system if the process is interrupt or recive a syscall
-func CloseDB(chain *blockchain.BlockChain) {
+// CloseDB will grafully shutdown the system if the process is interrupt or recive a syscall,
+// the rpc cookie of the node is removed so the cli stops calling it
+func CloseDB(chain *blockchain.BlockChain, nodeID string) {
 	d := death.NewDeath(syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 
 	d.WaitForDeathWithFunc(func() {
 		defer os.Exit(1)
 		defer runtime.Goexit()
-		chain.Database.Close()
+		rpc.RemoveCookie(nodeID)
+		chainLock.Lock()
+		chain.Store.Close()
 	})
 }
@@ -0,0 +1,185 @@
+// Package params holds the settings of the networks a node can join. Every
+// network has its own genesis block, consensus rules, network magic,
+// address versions and ports, and keeps its data in its own directory, so
+// a test chain and the main chain never mix
+package params
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+var ErrUnknownNetwork = errors.New("unknown network, use mainnet, testnet or regtest")
+
+// GenesisSpec describes the genesis block of a network, every node builds
+// the same block 0 from it. It is also the json file of the creategenesis
+// tool, which mines the nonce and fills the hash
+type GenesisSpec struct {
+	Timestamp int64  `json:"timestamp"`         // represents the time of the genesis block
+	Message   string `json:"message"`           // represents the data of the coinbase of the genesis block
+	Address   string `json:"address,omitempty"` // represents who gets the reward, empty burns it
+	Nonce     int    `json:"nonce"`             // represents the nonce that solves the proof of work
+	Hash      string `json:"hash,omitempty"`    // represents the hex hash every node must get
+}
+
+// ChainParams are the settings of one network
+type ChainParams struct {
+	Name        string  // represents the name of the network
+	Magic       [4]byte // represents the bytes that start every message of the network
+	DefaultPort int     // represents the port of the seed node of the network
+	SubDir      string  // represents the directory of the network inside the data directory
+
+	PubKeyHashAddrID byte // represents the version of the pay to public key hash addresses
+	ScriptHashAddrID byte // represents the version of the pay to script hash addresses
+	SchnorrKeyAddrID byte // represents the version of the pay to schnorr key addresses
+
+	Genesis          GenesisSpec    // represents the genesis block of the network
+	PowLimitBits     uint32         // represents the easiest difficulty, the leading zero bits of a hash
+	Subsidy          int            // represents the new coins created by every block
+	CoinbaseMaturity int            // represents the confirmations a coinbase needs before it is spent
+	RetargetInterval int            // represents how many blocks keep the same difficulty
+	TargetSpacing    int64          // represents the seconds wanted between two blocks
+	NoRetargeting    bool           // represents if the difficulty always stays at the limit
+	Checkpoints      map[int]string // represents the hex hash the block of a height must have
+}
+
+// the main network keeps its data in the root of the data directory, where
+// the nodes stored it before there were other networks, and keeps their
+// rules: coinbases mature at once and the difficulty never changes, so the
+// blocks of older nodes stay valid. The genesis blocks were made with the
+// creategenesis tool and are the first checkpoint of every network, the
+// later ones are added as the networks grow
+var (
+	Mainnet = ChainParams{
+		Name:             "mainnet",
+		Magic:            [4]byte{0xfa, 0xbf, 0xb5, 0xda},
+		DefaultPort:      3000,
+		SubDir:           "",
+		PubKeyHashAddrID: 0x00,
+		ScriptHashAddrID: 0x05,
+		SchnorrKeyAddrID: 0x0b,
+		Genesis: GenesisSpec{
+			Timestamp: 1640995200,
+			Message:   "First Transaction from genesis",
+			Nonce:     5302,
+			Hash:      "0005c3debe7ab74ec45c3ab2e5c380b2ba4d8b04f4d48eb9b236f24c92ea7513",
+		},
+		PowLimitBits:     12,
+		Subsidy:          20,
+		CoinbaseMaturity: 0,
+		RetargetInterval: 2016,
+		TargetSpacing:    600,
+		NoRetargeting:    true,
+		Checkpoints: map[int]string{
+			0: "0005c3debe7ab74ec45c3ab2e5c380b2ba4d8b04f4d48eb9b236f24c92ea7513",
+		},
+	}
+
+	Testnet = ChainParams{
+		Name:             "testnet",
+		Magic:            [4]byte{0x0c, 0x12, 0x0a, 0x08},
+		DefaultPort:      13000,
+		SubDir:           "testnet",
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		SchnorrKeyAddrID: 0x70,
+		Genesis: GenesisSpec{
+			Timestamp: 1640995200,
+			Message:   "First Transaction from the testnet genesis",
+			Nonce:     1399,
+			Hash:      "0008641e14a6ce03c51426ae0711cb347f714dcc5692f9361e73cb917185ba58",
+		},
+		PowLimitBits:     12,
+		Subsidy:          20,
+		CoinbaseMaturity: 0,
+		RetargetInterval: 144,
+		TargetSpacing:    60,
+		NoRetargeting:    false,
+		Checkpoints: map[int]string{
+			0: "0008641e14a6ce03c51426ae0711cb347f714dcc5692f9361e73cb917185ba58",
+		},
+	}
+
+	Regtest = ChainParams{
+		Name:             "regtest",
+		Magic:            [4]byte{0xfb, 0xc0, 0xb6, 0xdb},
+		DefaultPort:      23000,
+		SubDir:           "regtest",
+		PubKeyHashAddrID: 0x78,
+		ScriptHashAddrID: 0xc5,
+		SchnorrKeyAddrID: 0x79,
+		Genesis: GenesisSpec{
+			Timestamp: 1640995200,
+			Message:   "First Transaction from the regtest genesis",
+			Nonce:     0,
+			Hash:      "171dacf3a1b928d0ae34a0d0b3fa44ff3b0eb92053581b4de06df40a45742cf1",
+		},
+		PowLimitBits:     1,
+		Subsidy:          50,
+		CoinbaseMaturity: 100,
+		RetargetInterval: 144,
+		TargetSpacing:    600,
+		NoRetargeting:    true,
+		Checkpoints: map[int]string{
+			0: "171dacf3a1b928d0ae34a0d0b3fa44ff3b0eb92053581b4de06df40a45742cf1",
+		},
+	}
+)
+
+var (
+	// Active is the network the node runs on
+	Active = &Mainnet
+	// DataDir is the directory that holds the data of every network
+	DataDir = "./tmp"
+)
+
+// Lookup will return the settings of the network with the given name
+func Lookup(name string) (*ChainParams, error) {
+	for _, net := range []*ChainParams{&Mainnet, &Testnet, &Regtest} {
+		if net.Name == name {
+			return net, nil
+		}
+	}
+
+	return nil, ErrUnknownNetwork
+}
+
+// ReadGenesisSpec will read the json spec of a genesis block
+func ReadGenesisSpec(file string) (GenesisSpec, error) {
+	var spec GenesisSpec
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return spec, err
+	}
+
+	err = json.Unmarshal(data, &spec)
+	return spec, err
+}
+
+// WriteGenesisSpec will write the spec of a genesis block as json
+func WriteGenesisSpec(file string, spec GenesisSpec) error {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Dir will return the directory of the active network
+func Dir() string {
+	return filepath.Join(DataDir, Active.SubDir)
+}
+
+// Path will return the path of a file of the active network
+func Path(name string) string {
+	return filepath.Join(Dir(), name)
+}
+
+// SeedNode will return the address of the seed node of the network
+func (p *ChainParams) SeedNode() string {
+	return fmt.Sprintf("localhost:%d", p.DefaultPort)
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client calls the json-rpc api of a node
+type Client struct {
+	addr string       // represents the address of the rpc server
+	auth string       // represents the user and password of the cookie
+	http *http.Client // represents the http client of the calls
+	id   int          // represents the id of the last call
+}
+
+// NewClient will create a client of the rpc server at the given address
+func NewClient(addr, auth string) *Client {
+	return &Client{addr: addr, auth: auth, http: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Dial will create a client of the running node with the given id from
+// its cookie, and check that the node answers
+func Dial(nodeID string) (*Client, error) {
+	addr, auth, err := ReadCookie(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClient(addr, auth)
+	if err := c.Call("getbestblockhash", nil); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Call will run the method on the node with the positional params and
+// decode its result into result, which may be nil
+func (c *Client) Call(method string, result interface{}, params ...interface{}) error {
+	c.id++
+	raw := []json.RawMessage{}
+	for _, param := range params {
+		data, err := json.Marshal(param)
+		if err != nil {
+			return err
+		}
+
+		raw = append(raw, data)
+	}
+
+	body, err := json.Marshal(Request{JSONRPC: "2.0", ID: c.id, Method: method, Params: raw})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+c.addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	user := strings.SplitN(c.auth, ":", 2)
+	req.SetBasicAuth(user[0], user[len(user)-1])
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc: the node answered %s", resp.Status)
+	}
+
+	var res Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(res.Result, result)
+}
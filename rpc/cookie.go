@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Haizza1/go-block/params"
+)
+
+const (
+	cookieUser = "__cookie__"
+	PortOffset = 1000 // represents the distance between the node port and its default rpc port
+)
+
+var ErrNoCookie = errors.New("the node is not running, there is no rpc cookie")
+
+// CookieFile will return the path of the rpc cookie of the node in the
+// directory of the active network
+func CookieFile(nodeID string) string {
+	return params.Path(fmt.Sprintf("rpc_%s.cookie", nodeID))
+}
+
+// WriteCookie will create a random password for the rpc server of the node
+// and write it with the address of the server, only the owner can read it.
+// It returns the credentials the clients must send
+func WriteCookie(nodeID, addr string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(params.Dir(), 0700); err != nil {
+		return "", err
+	}
+
+	auth := cookieUser + ":" + hex.EncodeToString(secret)
+	data := []byte(addr + "\n" + auth + "\n")
+	if err := ioutil.WriteFile(CookieFile(nodeID), data, 0600); err != nil {
+		return "", err
+	}
+
+	return auth, nil
+}
+
+// ReadCookie will return the address of the rpc server of the node and
+// the credentials written in its cookie
+func ReadCookie(nodeID string) (string, string, error) {
+	data, err := ioutil.ReadFile(CookieFile(nodeID))
+	if os.IsNotExist(err) {
+		return "", "", ErrNoCookie
+	} else if err != nil {
+		return "", "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], cookieUser+":") {
+		return "", "", fmt.Errorf("the rpc cookie %s is malformed", CookieFile(nodeID))
+	}
+
+	return lines[0], lines[1], nil
+}
+
+// RemoveCookie will delete the cookie of the node when it stops
+func RemoveCookie(nodeID string) {
+	if err := os.Remove(CookieFile(nodeID)); err != nil && !os.IsNotExist(err) {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Haizza1/go-block/events"
+)
+
+// keepAlive is how often an idle event stream gets a comment, so proxies
+// and clients keep the connection open
+const keepAlive = 15 * time.Second
+
+// serveEvents will stream the events of the node as server-sent events.
+// The topics and addresses query params filter them, both are lists
+// separated by commas. The stream ends when the client leaves, when the
+// node stops or when the client falls too far behind
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "event streams must use GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "the connection can not stream", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := events.ParseFilter(query.Get("topics"), query.Get("addresses"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := s.backend.Events().Subscribe(filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": subscribed\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return // the client fell behind, it reconnects and catches up with the rest api
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Topic, data)
+			flusher.Flush()
+
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep alive\n\n")
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// handlers are the methods of the api by name
+var handlers = map[string]handler{
+	"getblock":         getBlock,
+	"getblockhash":     getBlockHash,
+	"getbestblockhash": getBestBlockHash,
+	"gettransaction":   getTransaction,
+	"getbalance":       getBalance,
+	"sendtoaddress":    sendToAddress,
+	"walletpassphrase": walletPassphrase,
+	"walletlock":       walletLock,
+	"getmempoolinfo":   getMemPoolInfo,
+	"getpeerinfo":      getPeerInfo,
+	"generate":         generate,
+	"stop":             stop,
+}
+
+// BlockResult is the json view of a block
+type BlockResult struct {
+	Hash          string   `json:"hash"`
+	Height        int      `json:"height"`
+	PrevHash      string   `json:"previousblockhash"`
+	Time          int64    `json:"time"`
+	Nonce         int      `json:"nonce"`
+	Bits          uint32   `json:"bits"`
+	Confirmations int      `json:"confirmations"`
+	Transactions  []string `json:"tx,omitempty"`
+}
+
+// TxResult is the json view of a transaction, a transaction of the
+// memory pool has no block and no confirmations
+type TxResult struct {
+	TxID          string         `json:"txid"`
+	LockTime      int64          `json:"locktime"`
+	Coinbase      bool           `json:"coinbase"`
+	Inputs        []InputResult  `json:"vin"`
+	Outputs       []OutputResult `json:"vout"`
+	BlockHash     string         `json:"blockhash,omitempty"`
+	Confirmations int            `json:"confirmations"`
+	Hex           string         `json:"hex"`
+}
+
+// InputResult is the json view of an input
+type InputResult struct {
+	TxID     string `json:"txid"`
+	Vout     int    `json:"vout"`
+	Script   string `json:"script"`
+	Sequence uint32 `json:"sequence"`
+}
+
+// OutputResult is the json view of an output, the address is empty when
+// the locking script is not a standard one
+type OutputResult struct {
+	N       int    `json:"n"`
+	Value   int    `json:"value"`
+	Address string `json:"address,omitempty"`
+	Script  string `json:"script"`
+}
+
+// MemPoolResult is the json view of the memory pool
+type MemPoolResult struct {
+	Size  int `json:"size"`  // represents the number of transactions
+	Bytes int `json:"bytes"` // represents the size of the serialized transactions
+}
+
+// NewBlockResult will build the json view of a block of a chain with the
+// given best height
+func NewBlockResult(block *blockchain.Block, bestHeight int) BlockResult {
+	res := BlockResult{
+		Hash:          hex.EncodeToString(block.Hash),
+		Height:        block.Heigth,
+		PrevHash:      hex.EncodeToString(block.PrevHash),
+		Time:          block.TimeStamp,
+		Nonce:         block.Nonce,
+		Bits:          block.Bits,
+		Confirmations: bestHeight - block.Heigth + 1,
+		Transactions:  []string{},
+	}
+
+	for _, tx := range block.Transactions {
+		res.Transactions = append(res.Transactions, hex.EncodeToString(tx.ID))
+	}
+
+	return res
+}
+
+// NewTxResult will build the json view of a transaction, the block is
+// nil for the transactions of the memory pool
+func NewTxResult(tx *blockchain.Transaction, block *blockchain.Block, bestHeight int) TxResult {
+	res := TxResult{
+		TxID:     hex.EncodeToString(tx.ID),
+		LockTime: tx.LockTime,
+		Coinbase: tx.IsCoinBase(),
+		Inputs:   []InputResult{},
+		Outputs:  []OutputResult{},
+		Hex:      hex.EncodeToString(tx.Serialize()),
+	}
+
+	for _, in := range tx.Inputs {
+		res.Inputs = append(res.Inputs, InputResult{
+			TxID:     hex.EncodeToString(in.ID),
+			Vout:     in.Out,
+			Script:   hex.EncodeToString(in.Script),
+			Sequence: in.Sequence,
+		})
+	}
+
+	for i, out := range tx.Outputs {
+		res.Outputs = append(res.Outputs, OutputResult{
+			N:       i,
+			Value:   out.Value,
+			Address: out.Address(),
+			Script:  hex.EncodeToString(out.Script),
+		})
+	}
+
+	if block != nil {
+		res.BlockHash = hex.EncodeToString(block.Hash)
+		res.Confirmations = bestHeight - block.Heigth + 1
+	}
+
+	return res
+}
+
+// decodeHash will decode a hex hash param
+func decodeHash(param string) ([]byte, *Error) {
+	hash, err := hex.DecodeString(param)
+	if err != nil || len(hash) == 0 {
+		return nil, newError(ErrCodeInvalidParams, "%s is not a hex hash", param)
+	}
+
+	return hash, nil
+}
+
+// bestHeight will return the height of the tip of the chain, an error of
+// the store becomes an internal error
+func bestHeight(chain *blockchain.BlockChain) (int, *Error) {
+	height, err := chain.BestHeight()
+	if err != nil {
+		return 0, newError(ErrCodeInternal, "%s", err)
+	}
+
+	return height, nil
+}
+
+// getBlock will return the block with the given hash, as json or as the
+// hex of its serialization when verbose is false
+func getBlock(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var param string
+	verbose := true
+	if err := parseParams(params, 1, &param, &verbose); err != nil {
+		return nil, err
+	}
+
+	hash, rpcErr := decodeHash(param)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	chain := s.backend.Chain()
+	block, err := chain.GetBlock(hash)
+	if err != nil {
+		return nil, newError(ErrCodeNotFound, "block %s not found", param)
+	}
+
+	if !verbose {
+		return hex.EncodeToString(block.Serialize()), nil
+	}
+
+	best, rpcErr := bestHeight(chain)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	return NewBlockResult(&block, best), nil
+}
+
+// getBlockHash will return the hash of the block of the best chain at the
+// given height
+func getBlockHash(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var height int
+	if err := parseParams(params, 1, &height); err != nil {
+		return nil, err
+	}
+
+	chain := s.backend.Chain()
+	best, rpcErr := bestHeight(chain)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	if height < 0 || height > best {
+		return nil, newError(ErrCodeInvalidParams, "height %d is out of range", height)
+	}
+
+	hash, err := chain.BlockHash(height)
+	if errors.Is(err, blockchain.ErrNotFound) {
+		return nil, newError(ErrCodeNotFound, "no block at height %d", height)
+	} else if err != nil {
+		return nil, newError(ErrCodeInternal, "%s", err)
+	}
+
+	return hex.EncodeToString(hash), nil
+}
+
+// getBestBlockHash will return the hash of the tip of the chain
+func getBestBlockHash(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(s.backend.Chain().LastHash), nil
+}
+
+// getTransaction will return a transaction of the memory pool or of the chain
+func getTransaction(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var param string
+	if err := parseParams(params, 1, &param); err != nil {
+		return nil, err
+	}
+
+	id, rpcErr := decodeHash(param)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	chain := s.backend.Chain()
+	for _, tx := range s.backend.MemPool() {
+		if bytes.Equal(tx.ID, id) {
+			return NewTxResult(&tx, nil, 0), nil
+		}
+	}
+
+	block, err := chain.TxBlock(id)
+	if err != nil {
+		return nil, newError(ErrCodeNotFound, "transaction %s not found", param)
+	}
+
+	best, rpcErr := bestHeight(chain)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	for _, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, id) {
+			return NewTxResult(tx, block, best), nil
+		}
+	}
+
+	return nil, newError(ErrCodeNotFound, "transaction %s not found", param)
+}
+
+// getBalance will return the value of the unspent outputs of the address
+func getBalance(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var address string
+	if err := parseParams(params, 1, &address); err != nil {
+		return nil, err
+	}
+
+	locking, err := blockchain.LockingScript(address)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidParams, "%s: %s", address, err)
+	}
+
+	UTXOSet := blockchain.UTXOSet{BlockChain: s.backend.Chain()}
+	outs, err := UTXOSet.FindUnspentOutputs(locking)
+	if err != nil {
+		return nil, newError(ErrCodeInternal, "%s", err)
+	}
+
+	balance := 0
+	for _, out := range outs {
+		balance += out.Value
+	}
+
+	return balance, nil
+}
+
+// sendToAddress will pay the amount from an address of the wallet of the
+// node and send the transaction to the network, it returns its id. The
+// params are the sender, the recipient, the amount and the optional fee,
+// fee per input, coin selection strategy, no mixing flag, outpoints to
+// exclude and lock time
+func sendToAddress(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var from, to, strategy string
+	var amount, fee, feePerInput int
+	var noMix bool
+	var exclude []string
+	var lockTime int64
+	if err := parseParams(params, 3, &from, &to, &amount, &fee, &feePerInput, &strategy, &noMix, &exclude, &lockTime); err != nil {
+		return nil, err
+	}
+
+	if amount <= 0 || fee < 0 || feePerInput < 0 || lockTime < 0 {
+		return nil, newError(ErrCodeInvalidParams, "the amount must be positive and the fees and lock time can not be negative")
+	}
+
+	selector, err := blockchain.SelectorByName(strategy)
+	if err != nil {
+		return nil, newError(ErrCodeInvalidParams, "%s", err)
+	}
+
+	if noMix {
+		selector = blockchain.NoMixing{Selector: selector}
+	}
+
+	opts := blockchain.SendOptions{
+		Selector: selector,
+		Fee:      blockchain.FeePolicy{Base: fee, PerInput: feePerInput},
+		LockTime: lockTime,
+	}
+
+	for _, outPoint := range exclude {
+		op, err := blockchain.ParseOutPoint(outPoint)
+		if err != nil {
+			return nil, newError(ErrCodeInvalidParams, "%s: %s", outPoint, err)
+		}
+
+		opts.Exclude = append(opts.Exclude, op)
+	}
+
+	wallets := s.backend.Wallets()
+	if wallets == nil {
+		return nil, newError(ErrCodeWallet, "the node has no wallet")
+	}
+
+	w, err := wallets.SigningWallet(from)
+	if errors.Is(err, wallet.ErrWalletLocked) {
+		return nil, newError(ErrCodeUnlockNeeded, "the wallet is locked, unlock it with walletpassphrase first")
+	} else if err != nil {
+		return nil, newError(ErrCodeWallet, "%s", err)
+	}
+
+	for _, outPoint := range wallets.ListLockUnspent() {
+		op, err := blockchain.ParseOutPoint(outPoint)
+		if err != nil {
+			return nil, newError(ErrCodeWallet, "%s", err)
+		}
+
+		opts.Exclude = append(opts.Exclude, op)
+	}
+
+	builder := blockchain.NewTxBuilder(&w, &blockchain.UTXOSet{BlockChain: s.backend.Chain()}, opts)
+	builder.From = from
+	if err := builder.AddPayment(to, amount); err != nil {
+		return nil, newError(ErrCodeInvalidParams, "%s", err)
+	}
+
+	tx, _, err := builder.Build()
+	if err != nil {
+		return nil, newError(ErrCodeWallet, "%s", err)
+	}
+
+	if err := s.backend.SendTransaction(tx); err != nil {
+		return nil, newError(ErrCodeRejected, "%s", err)
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
+
+// walletPassphrase will unlock the wallet of the node for the given
+// seconds, the params are the passphrase and the timeout
+func walletPassphrase(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var passphrase string
+	var timeout int
+	if err := parseParams(params, 2, &passphrase, &timeout); err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		return nil, newError(ErrCodeInvalidParams, "the timeout must be positive")
+	}
+
+	wallets := s.backend.Wallets()
+	if wallets == nil {
+		return nil, newError(ErrCodeWallet, "the node has no wallet")
+	}
+
+	duration := time.Duration(timeout) * time.Second
+	if err := wallets.UnlockFor(passphrase, duration); err != nil {
+		return nil, newError(ErrCodeWallet, "%s", err)
+	}
+
+	return "wallet unlocked for " + duration.String(), nil
+}
+
+// walletLock will lock the wallet of the node
+func walletLock(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	wallets := s.backend.Wallets()
+	if wallets == nil {
+		return nil, newError(ErrCodeWallet, "the node has no wallet")
+	}
+
+	wallets.Lock()
+	return "wallet locked", nil
+}
+
+// getMemPoolInfo will return the size of the memory pool
+func getMemPoolInfo(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	var res MemPoolResult
+	for _, tx := range s.backend.MemPool() {
+		res.Size++
+		res.Bytes += len(tx.Serialize())
+	}
+
+	return res, nil
+}
+
+// getPeerInfo will return the addresses of the nodes the node knows
+func getPeerInfo(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	peers := s.backend.Peers()
+	if peers == nil {
+		peers = []string{}
+	}
+
+	return peers, nil
+}
+
+// generate will mine blocks paying the address on regtest and return
+// their hashes
+func generate(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	var n int
+	var address string
+	if err := parseParams(params, 2, &n, &address); err != nil {
+		return nil, err
+	}
+
+	if n <= 0 {
+		return nil, newError(ErrCodeInvalidParams, "the number of blocks must be positive")
+	}
+
+	blocks, err := s.backend.Chain().Generate(n, address)
+	if err != nil {
+		return nil, newError(ErrCodeMisc, "%s", err)
+	}
+
+	hashes := []string{}
+	for _, block := range blocks {
+		hashes = append(hashes, hex.EncodeToString(block.Hash))
+	}
+
+	return hashes, nil
+}
+
+// stop will shut the node down once the reply is written
+func stop(s *Server, params []json.RawMessage) (interface{}, *Error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	go s.backend.Stop()
+	return "go-block node stopping", nil
+}
@@ -0,0 +1,375 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/wire"
+)
+
+const (
+	maxRestHeaders   = 2000 // represents the most headers of one request
+	maxRestOutPoints = 100  // represents the most outpoints of one request
+)
+
+// the formats of the rest answers, chosen by the extension of the path
+const (
+	formatJSON = "json"
+	formatBin  = "bin"
+	formatHex  = "hex"
+)
+
+// UTXOsResult is the json view of the outpoints asked to getutxos, the
+// bitmap has a 1 for every outpoint that is unspent
+type UTXOsResult struct {
+	ChainHeight int          `json:"chainHeight"`
+	TipHash     string       `json:"chaintipHash"`
+	Bitmap      string       `json:"bitmap"`
+	UTXOs       []UTXOResult `json:"utxos"`
+}
+
+// UTXOResult is the json view of an unspent output
+type UTXOResult struct {
+	TxID    string `json:"txid"`
+	Vout    int    `json:"vout"`
+	Value   int    `json:"value"`
+	Address string `json:"address,omitempty"`
+	Script  string `json:"script"`
+}
+
+// ChainInfoResult is the json view of the state of the chain
+type ChainInfoResult struct {
+	Chain         string `json:"chain"`
+	Blocks        int    `json:"blocks"`
+	BestBlockHash string `json:"bestblockhash"`
+	Bits          uint32 `json:"bits"`
+	MedianTime    int64  `json:"mediantime"`
+}
+
+// restError is a failed rest request with its http status
+type restError struct {
+	status  int
+	message string
+}
+
+// newRestError will create a rest error with the given status
+func newRestError(status int, format string, args ...interface{}) *restError {
+	return &restError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// restHandler answers a rest path with its json view and its raw bytes,
+// the raw bytes are nil when the resource is only available as json
+type restHandler func(s *Server, args []string) (interface{}, []byte, *restError)
+
+// restHandlers are the resources of the rest api by the first part of the path
+var restHandlers = map[string]restHandler{
+	"block":     restBlock,
+	"tx":        restTx,
+	"headers":   restHeaders,
+	"getutxos":  restGetUTXOs,
+	"chaininfo": restChainInfo,
+}
+
+// serveREST will answer a read only rest request, they need no credentials
+func (s *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "rest requests must use GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, format := splitFormat(strings.TrimPrefix(r.URL.Path, "/rest/"))
+	if format == "" {
+		http.Error(w, "unknown format, use .json, .bin or .hex", http.StatusNotFound)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	h, ok := restHandlers[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	view, raw, restErr := s.callREST(h, parts[1:])
+	if restErr != nil {
+		http.Error(w, restErr.message, restErr.status)
+		return
+	}
+
+	switch format {
+	case formatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(view); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	case formatBin:
+		if raw == nil {
+			http.Error(w, "the resource is only available as json", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(raw)
+
+	case formatHex:
+		if raw == nil {
+			http.Error(w, "the resource is only available as json", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, hex.EncodeToString(raw))
+	}
+}
+
+// callREST will run a rest handler between two calls, a panic of the
+// chain becomes an internal error
+func (s *Server) callREST(h restHandler, args []string) (view interface{}, raw []byte, restErr *restError) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			view, raw, restErr = nil, nil, newRestError(http.StatusInternalServerError, "%v", r)
+		}
+	}()
+
+	return h(s, args)
+}
+
+// splitFormat will split the extension of the path, a path without one is
+// answered with json and an unknown one has no format
+func splitFormat(path string) (string, string) {
+	dot := strings.LastIndex(path, ".")
+	if dot < 0 {
+		return path, formatJSON
+	}
+
+	switch ext := path[dot+1:]; ext {
+	case formatJSON, formatBin, formatHex:
+		return path[:dot], ext
+	}
+
+	return path, ""
+}
+
+// restHash will decode the hex hash of a rest path
+func restHash(param string) ([]byte, *restError) {
+	hash, err := hex.DecodeString(param)
+	if err != nil || len(hash) == 0 {
+		return nil, newRestError(http.StatusBadRequest, "%s is not a hex hash", param)
+	}
+
+	return hash, nil
+}
+
+// restBlock will answer /rest/block/<hash>
+func restBlock(s *Server, args []string) (interface{}, []byte, *restError) {
+	if len(args) != 1 {
+		return nil, nil, newRestError(http.StatusBadRequest, "use /rest/block/<hash>")
+	}
+
+	hash, restErr := restHash(args[0])
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+
+	chain := s.backend.Chain()
+	block, err := chain.GetBlock(hash)
+	if err != nil {
+		return nil, nil, newRestError(http.StatusNotFound, "block %s not found", args[0])
+	}
+
+	best, err := chain.BestHeight()
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	return NewBlockResult(&block, best), block.Serialize(), nil
+}
+
+// restTx will answer /rest/tx/<txid> with a transaction of the memory
+// pool or of the chain
+func restTx(s *Server, args []string) (interface{}, []byte, *restError) {
+	if len(args) != 1 {
+		return nil, nil, newRestError(http.StatusBadRequest, "use /rest/tx/<txid>")
+	}
+
+	id, restErr := restHash(args[0])
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+
+	for _, tx := range s.backend.MemPool() {
+		if bytes.Equal(tx.ID, id) {
+			return NewTxResult(&tx, nil, 0), tx.Serialize(), nil
+		}
+	}
+
+	chain := s.backend.Chain()
+	tx, err := chain.FindTransaction(id)
+	if err != nil {
+		return nil, nil, newRestError(http.StatusNotFound, "transaction %s not found", args[0])
+	}
+
+	block, err := chain.TxBlock(id)
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	best, err := chain.BestHeight()
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	return NewTxResult(&tx, block, best), tx.Serialize(), nil
+}
+
+// restHeaders will answer /rest/headers/<count>/<hash> with the headers
+// of the best chain from the block with the hash towards the tip, the
+// raw answer is the headers one after the other
+func restHeaders(s *Server, args []string) (interface{}, []byte, *restError) {
+	if len(args) != 2 {
+		return nil, nil, newRestError(http.StatusBadRequest, "use /rest/headers/<count>/<hash>")
+	}
+
+	count, err := strconv.Atoi(args[0])
+	if err != nil || count < 1 || count > maxRestHeaders {
+		return nil, nil, newRestError(http.StatusBadRequest, "the count must be between 1 and %d", maxRestHeaders)
+	}
+
+	start, restErr := restHash(args[1])
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+
+	chain := s.backend.Chain()
+	header, err := chain.GetHeader(start)
+	if err != nil {
+		return nil, nil, newRestError(http.StatusNotFound, "block %s not found", args[1])
+	}
+
+	bestHeight, err := chain.BestHeight()
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	views := []BlockResult{}
+	var raw []byte
+	for height := header.Heigth; height <= bestHeight && len(views) < count; height++ {
+		hash, err := chain.BlockHash(height)
+		if err != nil {
+			return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+		}
+
+		if height == header.Heigth && !bytes.Equal(hash, start) {
+			return nil, nil, newRestError(http.StatusNotFound, "block %s is not in the best chain", args[1])
+		}
+
+		block, err := chain.GetBlock(hash)
+		if err != nil {
+			return nil, nil, newRestError(http.StatusNotFound, "block %x not found", hash)
+		}
+
+		view := NewBlockResult(&block, bestHeight)
+		view.Transactions = nil
+		views = append(views, view)
+		raw = append(raw, block.Header(block.Nonce)...)
+	}
+
+	return views, raw, nil
+}
+
+// restGetUTXOs will answer /rest/getutxos/<txid>-<index>/... with the
+// outpoints that are still unspent
+func restGetUTXOs(s *Server, args []string) (interface{}, []byte, *restError) {
+	if len(args) == 0 || len(args) > maxRestOutPoints {
+		return nil, nil, newRestError(http.StatusBadRequest, "use /rest/getutxos/<txid>-<index>/..., at most %d outpoints", maxRestOutPoints)
+	}
+
+	chain := s.backend.Chain()
+	best, err := chain.BestHeight()
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	UTXOSet := blockchain.UTXOSet{BlockChain: chain}
+	res := UTXOsResult{
+		ChainHeight: best,
+		TipHash:     hex.EncodeToString(chain.LastHash),
+		UTXOs:       []UTXOResult{},
+	}
+
+	var bitmap []byte
+	for _, arg := range args {
+		op, err := blockchain.ParseOutPoint(strings.Replace(arg, "-", ":", 1))
+		if err != nil {
+			return nil, nil, newRestError(http.StatusBadRequest, "%s: %s", arg, err)
+		}
+
+		out, ok, err := UTXOSet.UnspentOutput(op)
+		if err != nil {
+			return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+		}
+
+		if !ok {
+			bitmap = append(bitmap, '0')
+			continue
+		}
+
+		bitmap = append(bitmap, '1')
+		res.UTXOs = append(res.UTXOs, UTXOResult{
+			TxID:    hex.EncodeToString(op.TxID),
+			Vout:    op.Index,
+			Value:   out.Value,
+			Address: out.Address(),
+			Script:  hex.EncodeToString(out.Script),
+		})
+	}
+
+	res.Bitmap = string(bitmap)
+
+	w := wire.NewWriter()
+	w.Uint32(uint32(res.ChainHeight))
+	w.VarBytes(chain.LastHash)
+	w.VarBytes(bitmap)
+	w.VarInt(uint64(len(res.UTXOs)))
+	for _, utxo := range res.UTXOs {
+		txID, _ := hex.DecodeString(utxo.TxID)
+		script, _ := hex.DecodeString(utxo.Script)
+		w.VarBytes(txID)
+		w.Uint32(uint32(utxo.Vout))
+		w.Int64(int64(utxo.Value))
+		w.VarBytes(script)
+	}
+
+	return res, w.Bytes(), nil
+}
+
+// restChainInfo will answer /rest/chaininfo, only as json
+func restChainInfo(s *Server, args []string) (interface{}, []byte, *restError) {
+	if len(args) != 0 {
+		return nil, nil, newRestError(http.StatusBadRequest, "use /rest/chaininfo")
+	}
+
+	chain := s.backend.Chain()
+	tip, err := chain.GetHeader(chain.LastHash)
+	if err != nil {
+		return nil, nil, newRestError(http.StatusInternalServerError, "%s", err)
+	}
+
+	return ChainInfoResult{
+		Chain:         params.Active.Name,
+		Blocks:        tip.Heigth,
+		BestBlockHash: hex.EncodeToString(chain.LastHash),
+		Bits:          tip.Bits,
+		MedianTime:    chain.MedianTimePast(chain.LastHash),
+	}, nil, nil
+}
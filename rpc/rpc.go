@@ -0,0 +1,78 @@
+// Package rpc serves the json-rpc api of a running node over http, so
+// other processes can query and control the node while it holds the
+// database. Requests are authenticated with the cookie the node writes in
+// its data directory, only local users that can read it may call the node
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Haizza1/go-block/blockchain"
+	"github.com/Haizza1/go-block/events"
+	"github.com/Haizza1/go-block/wallet"
+)
+
+// the codes of the errors, the first ones are defined by json-rpc
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+	ErrCodeNotFound       = -5  // the block, transaction or address is unknown
+	ErrCodeWallet         = -4  // the node has no wallet or it can not spend
+	ErrCodeUnlockNeeded   = -13 // the wallet of the node is locked
+	ErrCodeRejected       = -26 // the transaction was not accepted
+	ErrCodeMisc           = -1
+)
+
+// Request is a json-rpc call, the params are positional
+type Request struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      interface{}       `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// Response is the answer to a call, it has a result or an error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the error of a failed call
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error will return the message of the error with its code
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// newError will create an error with the given code
+func newError(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Backend is the running node the server answers for
+type Backend interface {
+	// Chain will return the blockchain of the node
+	Chain() *blockchain.BlockChain
+	// Wallets will return the wallets of the node, nil when it has none
+	Wallets() *wallet.Wallets
+	// MemPool will return the transactions waiting to be mined
+	MemPool() []blockchain.Transaction
+	// Peers will return the addresses of the known nodes
+	Peers() []string
+	// Events will return the bus where the node publishes its events
+	Events() *events.Bus
+	// SendTransaction will add the transaction to the memory pool and relay it
+	SendTransaction(tx *blockchain.Transaction) error
+	// Stop will shut the node down
+	Stop()
+}
@@ -0,0 +1,198 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxRequestSize is the largest body of a call the server reads
+const maxRequestSize = 1 << 20
+
+// handler runs a method with the positional params of the call
+type handler func(s *Server, params []json.RawMessage) (interface{}, *Error)
+
+// Server answers the json-rpc calls of a node, the calls hold the lock
+// of the chain the node shares with its peer handlers so they see the
+// chain between two blocks
+type Server struct {
+	backend Backend       // represents the node the server answers for
+	auth    string        // represents the user and password of the cookie
+	http    *http.Server  // represents the http server once it started
+	rest    bool          // represents if the read only rest api is served
+	mounts  []mount       // represents the read only handlers served next to the rpc
+	done    chan struct{} // represents the end of the event streams once closed
+	lock    sync.Locker   // represents the lock of the chain, held by every call
+	stop    sync.Once     // closes done once
+}
+
+// mount is a read only handler served under a path prefix
+type mount struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewServer will create the rpc server of the node, the clients must send
+// the given credentials with basic auth. The calls hold the given lock,
+// the node holds it too while it changes the chain
+func NewServer(backend Backend, auth string, lock sync.Locker) *Server {
+	return &Server{backend: backend, auth: auth, done: make(chan struct{}), lock: lock}
+}
+
+// EnableREST will serve the read only rest api under /rest/ too, with
+// the event stream at /rest/events, its requests need no credentials
+func (s *Server) EnableREST() {
+	s.rest = true
+}
+
+// Handle will serve a read only handler under the path prefix, its
+// requests need no credentials and run between two calls
+func (s *Server) Handle(prefix string, handler http.Handler) {
+	s.mounts = append(s.mounts, mount{prefix: prefix, handler: handler})
+}
+
+// Start will listen on the given address and serve the calls in the
+// background
+func (s *Server) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.http = &http.Server{Handler: s}
+	go s.http.Serve(ln)
+	return nil
+}
+
+// Shutdown will stop listening for calls, end the event streams and wait
+// for the calls in progress to reply
+func (s *Server) Shutdown() error {
+	s.stop.Do(func() { close(s.done) })
+	if s.http == nil {
+		return nil
+	}
+
+	return s.http.Shutdown(context.Background())
+}
+
+// ServeHTTP will authenticate the call, run its method and write the
+// response, the rest requests and the mounted handlers are answered
+// without credentials
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, m := range s.mounts {
+		if strings.HasPrefix(r.URL.Path, m.prefix) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			m.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/rest/") {
+		if !s.rest {
+			http.Error(w, "the rest api is disabled, start the node with -rest", http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Path == "/rest/events" {
+			s.serveEvents(w, r)
+		} else {
+			s.serveREST(w, r)
+		}
+
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "json-rpc calls must use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="go-block"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req Request
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil || json.Unmarshal(body, &req) != nil {
+		writeResponse(w, Response{JSONRPC: "2.0", Error: newError(ErrCodeParse, "the request is not valid json")})
+		return
+	}
+
+	res := Response{JSONRPC: "2.0", ID: req.ID}
+	result, rpcErr := s.call(req)
+	if rpcErr == nil {
+		res.Result, err = json.Marshal(result)
+		if err != nil {
+			rpcErr = newError(ErrCodeInternal, "%s", err)
+		}
+	}
+
+	res.Error = rpcErr
+	writeResponse(w, res)
+}
+
+// call will run the method of the request, a panic of the chain becomes
+// an internal error
+func (s *Server) call(req Request) (result interface{}, rpcErr *Error) {
+	if req.Method == "" {
+		return nil, newError(ErrCodeInvalidRequest, "the request has no method")
+	}
+
+	h, ok := handlers[req.Method]
+	if !ok {
+		return nil, newError(ErrCodeMethodNotFound, "method %s not found", req.Method)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			result, rpcErr = nil, newError(ErrCodeInternal, "%v", r)
+		}
+	}()
+
+	return h(s, req.Params)
+}
+
+// authorized will check the basic auth of the request against the cookie
+func (s *Server) authorized(r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(user+":"+password), []byte(s.auth)) == 1
+}
+
+// writeResponse will write the response of a call as json
+func writeResponse(w http.ResponseWriter, res Response) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseParams will decode the positional params into the given values,
+// the ones after the required count may be left out
+func parseParams(params []json.RawMessage, required int, values ...interface{}) *Error {
+	if len(params) < required || len(params) > len(values) {
+		return newError(ErrCodeInvalidParams, "expected %d to %d params, got %d", required, len(values), len(params))
+	}
+
+	for i, param := range params {
+		if err := json.Unmarshal(param, values[i]); err != nil {
+			return newError(ErrCodeInvalidParams, "param %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package script
+
+import "encoding/binary"
+
+// Builder helps to write scripts with the minimal push for every item
+type Builder struct {
+	script []byte
+}
+
+// NewBuilder will create an empty script builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// AddOp will append the given opcode
+func (b *Builder) AddOp(op byte) *Builder {
+	b.script = append(b.script, op)
+	return b
+}
+
+// AddData will append the smallest push of the given data
+func (b *Builder) AddData(data []byte) *Builder {
+	size := len(data)
+
+	switch {
+	case size == 0:
+		b.script = append(b.script, OP_0)
+
+	case size < int(OP_PUSHDATA1):
+		b.script = append(b.script, byte(size))
+
+	case size <= 0xff:
+		b.script = append(b.script, OP_PUSHDATA1, byte(size))
+
+	default:
+		var buff [2]byte
+		binary.LittleEndian.PutUint16(buff[:], uint16(size))
+		b.script = append(b.script, OP_PUSHDATA2)
+		b.script = append(b.script, buff[:]...)
+	}
+
+	b.script = append(b.script, data...)
+	return b
+}
+
+// AddInt will append the smallest push of the given number
+func (b *Builder) AddInt(n int64) *Builder {
+	switch {
+	case n == 0:
+		return b.AddOp(OP_0)
+
+	case n == -1:
+		return b.AddOp(OP_1NEGATE)
+
+	case n >= 1 && n <= 16:
+		return b.AddOp(OP_1 + byte(n-1))
+	}
+
+	return b.AddData(EncodeNum(n))
+}
+
+// Script will return a copy of the built script
+func (b *Builder) Script() []byte {
+	return append([]byte{}, b.script...)
+}
@@ -0,0 +1,503 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotPushOnly     = errors.New("script: the unlocking script may only push data")
+	ErrStackUnderflow  = errors.New("script: not enough items on the stack")
+	ErrStackOverflow   = errors.New("script: the stack is too deep")
+	ErrTooManyOps      = errors.New("script: the script runs too many opcodes")
+	ErrVerifyFailed    = errors.New("script: verify failed")
+	ErrEqualVerify     = errors.New("script: equal verify failed")
+	ErrCheckSigVerify  = errors.New("script: signature verify failed")
+	ErrOpReturn        = errors.New("script: OP_RETURN was executed")
+	ErrUnbalancedIf    = errors.New("script: unbalanced conditional")
+	ErrEvalFalse       = errors.New("script: the script finished with a false value")
+	ErrEmptyStack      = errors.New("script: the script finished with an empty stack")
+	ErrDisabledOpcode  = errors.New("script: unknown or disabled opcode")
+	ErrMultiSigVerify  = errors.New("script: multisig verify failed")
+	ErrInvalidKeyCount = errors.New("script: invalid number of public keys")
+	ErrInvalidSigCount = errors.New("script: invalid number of signatures")
+	ErrMissingRedeem   = errors.New("script: the unlocking script has no redeem script")
+	ErrNegativeLock    = errors.New("script: negative lock time")
+	ErrUnsatisfiedLock = errors.New("script: the lock time has not been reached")
+	ErrUnknownVersion  = errors.New("script: outputs of this version can not be spent yet")
+	ErrSchnorrVerify   = errors.New("script: schnorr signature verify failed")
+)
+
+// MaxMultiSigKeys is the largest number of public keys of a multisig
+const MaxMultiSigKeys = 16
+
+// SigChecker verifies signatures against the transaction being spent,
+// the engine does not know how the signed message is built
+type SigChecker interface {
+	// CheckSig will report if the signature is valid for the public key
+	CheckSig(signature, pubKey []byte) bool
+}
+
+// SchnorrChecker verifies the schnorr signatures that spend version 1
+// outputs, the checker of the engine may implement it to allow them
+type SchnorrChecker interface {
+	// CheckSchnorr will report if the signature is valid for the compressed key
+	CheckSchnorr(signature, pubKey []byte) bool
+}
+
+// LockChecker checks the lock times of the transaction being spent, the
+// checker of the engine may implement it to allow the time lock opcodes
+type LockChecker interface {
+	// CheckLockTime will report if the lock time of the transaction reached the given one
+	CheckLockTime(lockTime int64) bool
+	// CheckSequence will report if the relative lock of the input reached the given one
+	CheckSequence(sequence int64) bool
+}
+
+// Engine runs scripts over a single stack
+type Engine struct {
+	stack   [][]byte   // represents the main data stack
+	conds   []bool     // represents the state of the open if blocks
+	ops     int        // represents the non push opcodes run so far
+	checker SigChecker // represents the signature checker of the input
+}
+
+// NewEngine will create an engine that checks signatures with the given checker
+func NewEngine(checker SigChecker) *Engine {
+	return &Engine{checker: checker}
+}
+
+// Verify will run the unlocking script followed by the locking script and
+// report why the output can not be spent, nil means it can
+func Verify(unlocking, locking []byte, checker SigChecker) error {
+	if !IsPushOnly(unlocking) {
+		return ErrNotPushOnly
+	}
+
+	if version, program, ok := ExtractVersionedOutput(locking); ok {
+		return verifyVersioned(version, program, unlocking, checker)
+	}
+
+	engine := NewEngine(checker)
+	if err := engine.Run(unlocking); err != nil {
+		return err
+	}
+
+	unlocked := engine.Stack()
+	if err := engine.Run(locking); err != nil {
+		return err
+	}
+
+	if err := engine.result(); err != nil {
+		return err
+	}
+
+	if !IsPayToScriptHash(locking) {
+		return nil
+	}
+
+	// the hash matched, now the redeem script runs over the rest of the
+	// items pushed by the unlocking script
+	if len(unlocked) == 0 {
+		return ErrMissingRedeem
+	}
+
+	redeem := unlocked[len(unlocked)-1]
+	engine.stack = unlocked[:len(unlocked)-1]
+	if err := engine.Run(redeem); err != nil {
+		return err
+	}
+
+	return engine.result()
+}
+
+// verifyVersioned will check the input that spends a versioned output,
+// a version 1 output needs the schnorr signature of its key and nothing else
+func verifyVersioned(version int, program, unlocking []byte, checker SigChecker) error {
+	if version != SchnorrVersion || len(program) != 33 {
+		return ErrUnknownVersion
+	}
+
+	data, err := PushedData(unlocking)
+	if err != nil {
+		return err
+	}
+
+	if len(data) != 1 {
+		return ErrInvalidSigCount
+	}
+
+	schnorr, ok := checker.(SchnorrChecker)
+	if !ok || !schnorr.CheckSchnorr(data[0], program) {
+		return ErrSchnorrVerify
+	}
+
+	return nil
+}
+
+// result will check that the script finished with a true value on top
+func (e *Engine) result() error {
+	if len(e.stack) == 0 {
+		return ErrEmptyStack
+	}
+
+	if !asBool(e.stack[len(e.stack)-1]) {
+		return ErrEvalFalse
+	}
+
+	return nil
+}
+
+// Run will execute the given script over the current stack
+func (e *Engine) Run(script []byte) error {
+	instructions, err := Parse(script)
+	if err != nil {
+		return err
+	}
+
+	e.conds = nil
+	for _, inst := range instructions {
+		if err := e.step(inst); err != nil {
+			return err
+		}
+	}
+
+	if len(e.conds) != 0 {
+		return ErrUnbalancedIf
+	}
+
+	return nil
+}
+
+// Stack will return a copy of the current stack, the top is the last item
+func (e *Engine) Stack() [][]byte {
+	return append([][]byte{}, e.stack...)
+}
+
+// executing will check if the current branch runs
+func (e *Engine) executing() bool {
+	for _, cond := range e.conds {
+		if !cond {
+			return false
+		}
+	}
+
+	return true
+}
+
+// step will run a single instruction
+func (e *Engine) step(inst Instruction) error {
+	if !isPush(inst.Op) {
+		e.ops++
+		if e.ops > MaxOps {
+			return ErrTooManyOps
+		}
+	}
+
+	// conditionals are tracked even inside branches that do not run
+	switch inst.Op {
+	case OP_IF, OP_NOTIF:
+		cond := false
+		if e.executing() {
+			top, err := e.pop()
+			if err != nil {
+				return err
+			}
+
+			cond = asBool(top)
+			if inst.Op == OP_NOTIF {
+				cond = !cond
+			}
+		}
+
+		e.conds = append(e.conds, cond)
+		return nil
+
+	case OP_ELSE:
+		if len(e.conds) == 0 {
+			return ErrUnbalancedIf
+		}
+
+		e.conds[len(e.conds)-1] = !e.conds[len(e.conds)-1]
+		return nil
+
+	case OP_ENDIF:
+		if len(e.conds) == 0 {
+			return ErrUnbalancedIf
+		}
+
+		e.conds = e.conds[:len(e.conds)-1]
+		return nil
+	}
+
+	if !e.executing() {
+		return nil
+	}
+
+	if isPush(inst.Op) {
+		return e.push(pushValue(inst))
+	}
+
+	return e.execute(inst.Op)
+}
+
+// execute will run a non push opcode
+func (e *Engine) execute(op byte) error {
+	switch op {
+	case OP_VERIFY:
+		top, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		if !asBool(top) {
+			return ErrVerifyFailed
+		}
+
+	case OP_RETURN:
+		return ErrOpReturn
+
+	case OP_DROP:
+		_, err := e.pop()
+		return err
+
+	case OP_DUP:
+		top, err := e.peek(0)
+		if err != nil {
+			return err
+		}
+
+		return e.push(top)
+
+	case OP_SWAP:
+		if len(e.stack) < 2 {
+			return ErrStackUnderflow
+		}
+
+		n := len(e.stack)
+		e.stack[n-1], e.stack[n-2] = e.stack[n-2], e.stack[n-1]
+
+	case OP_SIZE:
+		top, err := e.peek(0)
+		if err != nil {
+			return err
+		}
+
+		return e.push(EncodeNum(int64(len(top))))
+
+	case OP_EQUAL, OP_EQUALVERIFY:
+		a, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		b, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		equal := bytes.Equal(a, b)
+		if op == OP_EQUALVERIFY {
+			if !equal {
+				return ErrEqualVerify
+			}
+			return nil
+		}
+
+		return e.push(fromBool(equal))
+
+	case OP_SHA256, OP_HASH256:
+		top, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.Sum256(top)
+		if op == OP_HASH256 {
+			hash = sha256.Sum256(hash[:])
+		}
+
+		return e.push(hash[:])
+
+	case OP_CHECKSIG, OP_CHECKSIGVERIFY:
+		pubKey, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		signature, err := e.pop()
+		if err != nil {
+			return err
+		}
+
+		valid := len(signature) > 0 && e.checker != nil && e.checker.CheckSig(signature, pubKey)
+		if op == OP_CHECKSIGVERIFY {
+			if !valid {
+				return ErrCheckSigVerify
+			}
+			return nil
+		}
+
+		return e.push(fromBool(valid))
+
+	case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+		valid, err := e.checkMultiSig()
+		if err != nil {
+			return err
+		}
+
+		if op == OP_CHECKMULTISIGVERIFY {
+			if !valid {
+				return ErrMultiSigVerify
+			}
+			return nil
+		}
+
+		return e.push(fromBool(valid))
+
+	case OP_CHECKLOCKTIMEVERIFY, OP_CHECKSEQUENCEVERIFY:
+		// the lock stays on the stack, scripts drop it themselves
+		top, err := e.peek(0)
+		if err != nil {
+			return err
+		}
+
+		lock, err := DecodeNum(top, maxNumSize)
+		if err != nil {
+			return err
+		}
+
+		if lock < 0 {
+			return ErrNegativeLock
+		}
+
+		checker, ok := e.checker.(LockChecker)
+		if !ok {
+			return ErrUnsatisfiedLock
+		}
+
+		reached := checker.CheckSequence(lock)
+		if op == OP_CHECKLOCKTIMEVERIFY {
+			reached = checker.CheckLockTime(lock)
+		}
+
+		if !reached {
+			return ErrUnsatisfiedLock
+		}
+
+	default:
+		return fmt.Errorf("%w %#x", ErrDisabledOpcode, op)
+	}
+
+	return nil
+}
+
+// checkMultiSig will pop <sig 1> ... <sig m> <m> <pubKey 1> ... <pubKey n> <n>
+// and check that every signature matches one of the public keys, the
+// signatures must follow the order of the public keys
+func (e *Engine) checkMultiSig() (bool, error) {
+	n, err := e.popInt()
+	if err != nil {
+		return false, err
+	}
+
+	if n < 0 || n > MaxMultiSigKeys {
+		return false, ErrInvalidKeyCount
+	}
+
+	e.ops += int(n)
+	if e.ops > MaxOps {
+		return false, ErrTooManyOps
+	}
+
+	pubKeys := make([][]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		if pubKeys[i], err = e.pop(); err != nil {
+			return false, err
+		}
+	}
+
+	m, err := e.popInt()
+	if err != nil {
+		return false, err
+	}
+
+	if m < 0 || m > n {
+		return false, ErrInvalidSigCount
+	}
+
+	signatures := make([][]byte, m)
+	for i := m - 1; i >= 0; i-- {
+		if signatures[i], err = e.pop(); err != nil {
+			return false, err
+		}
+	}
+
+	key := 0
+	for _, signature := range signatures {
+		for {
+			if len(pubKeys)-key < 1 {
+				return false, nil
+			}
+
+			matched := len(signature) > 0 && e.checker != nil && e.checker.CheckSig(signature, pubKeys[key])
+			key++
+			if matched {
+				break
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// popInt will pop the top of the stack as a number
+func (e *Engine) popInt() (int64, error) {
+	top, err := e.pop()
+	if err != nil {
+		return 0, err
+	}
+
+	return DecodeNum(top, maxNumSize)
+}
+
+// push will add the item on top of the stack
+func (e *Engine) push(data []byte) error {
+	if len(e.stack) >= MaxStackSize {
+		return ErrStackOverflow
+	}
+
+	e.stack = append(e.stack, data)
+	return nil
+}
+
+// pop will remove and return the top of the stack
+func (e *Engine) pop() ([]byte, error) {
+	if len(e.stack) == 0 {
+		return nil, ErrStackUnderflow
+	}
+
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	return top, nil
+}
+
+// peek will return the item at the given depth without removing it
+func (e *Engine) peek(depth int) ([]byte, error) {
+	if depth >= len(e.stack) {
+		return nil, ErrStackUnderflow
+	}
+
+	return e.stack[len(e.stack)-1-depth], nil
+}
+
+// fromBool will encode the boolean as a stack item
+func fromBool(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+
+	return []byte{}
+}
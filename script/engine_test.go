@@ -0,0 +1,163 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// fakeChecker accepts the signature "sig" followed by the public key, and
+// the lock times up to the ones it holds
+type fakeChecker struct {
+	lockTime int64 // represents the lock time of the transaction
+	sequence int64 // represents the relative lock of the input
+}
+
+func (c fakeChecker) CheckSig(signature, pubKey []byte) bool {
+	return bytes.Equal(signature, sign(pubKey))
+}
+
+func (c fakeChecker) CheckSchnorr(signature, pubKey []byte) bool {
+	return bytes.Equal(signature, sign(pubKey))
+}
+
+func (c fakeChecker) CheckLockTime(lockTime int64) bool {
+	return lockTime <= c.lockTime
+}
+
+func (c fakeChecker) CheckSequence(sequence int64) bool {
+	return sequence <= c.sequence
+}
+
+// sigOnly has no lock and no schnorr checks
+type sigOnly struct{}
+
+func (sigOnly) CheckSig(signature, pubKey []byte) bool {
+	return bytes.Equal(signature, sign(pubKey))
+}
+
+// sign will return the signature the fake checkers accept for the key
+func sign(pubKey []byte) []byte {
+	return append([]byte("sig"), pubKey...)
+}
+
+func hash256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func TestVerify(t *testing.T) {
+	alice, bob, carol := []byte("alice"), []byte("bob"), []byte("carol")
+	multisig, err := MultiSig(2, [][]byte{alice, bob, carol})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := bytes.Repeat([]byte{7}, SecretSize)
+	secretHash := sha256.Sum256(secret)
+	htlc := HTLC{SecretHash: secretHash[:], Recipient: bob, Sender: alice, LockTime: 100}.Script()
+	timeLock := TimeLock(50, true, alice)
+	schnorrKey := append([]byte{2}, bytes.Repeat([]byte{1}, 32)...)
+	checker := fakeChecker{lockTime: 100, sequence: 50}
+
+	tests := []struct {
+		name      string
+		unlocking []byte
+		locking   []byte
+		checker   SigChecker
+		err       error
+	}{
+		{"pay to pubkey hash", PayToPubKeyHashUnlock(sign(alice), alice), PayToPubKeyHash(hash256(alice)), checker, nil},
+		{"pay to pubkey hash bad signature", PayToPubKeyHashUnlock(sign(bob), alice), PayToPubKeyHash(hash256(alice)), checker, ErrEvalFalse},
+		{"pay to pubkey hash other key", PayToPubKeyHashUnlock(sign(bob), bob), PayToPubKeyHash(hash256(alice)), checker, ErrEqualVerify},
+		{"unlocking runs opcodes", NewBuilder().AddData(sign(alice)).AddOp(OP_DUP).Script(), PayToPubKeyHash(hash256(alice)), checker, ErrNotPushOnly},
+		{"empty stack", nil, NewBuilder().Script(), checker, ErrEmptyStack},
+		{"false result", nil, NewBuilder().AddOp(OP_0).Script(), checker, ErrEvalFalse},
+		{"negative zero is false", NewBuilder().AddData([]byte{0x80}).Script(), nil, checker, ErrEvalFalse},
+		{"op return", nil, NewBuilder().AddOp(OP_1).AddOp(OP_RETURN).Script(), checker, ErrOpReturn},
+		{"stack underflow", nil, NewBuilder().AddOp(OP_DROP).Script(), checker, ErrStackUnderflow},
+		{"disabled opcode", nil, []byte{0x93}, checker, ErrDisabledOpcode},
+		{"if branch", NewBuilder().AddOp(OP_1).Script(), NewBuilder().AddOp(OP_IF).AddOp(OP_1).AddOp(OP_ELSE).AddOp(OP_0).AddOp(OP_ENDIF).Script(), checker, nil},
+		{"else branch", NewBuilder().AddOp(OP_0).Script(), NewBuilder().AddOp(OP_IF).AddOp(OP_1).AddOp(OP_ELSE).AddOp(OP_0).AddOp(OP_ENDIF).Script(), checker, ErrEvalFalse},
+		{"skipped return", NewBuilder().AddOp(OP_0).Script(), NewBuilder().AddOp(OP_IF).AddOp(OP_RETURN).AddOp(OP_ENDIF).AddOp(OP_1).Script(), checker, nil},
+		{"unbalanced if", NewBuilder().AddOp(OP_1).Script(), NewBuilder().AddOp(OP_IF).AddOp(OP_1).Script(), checker, ErrUnbalancedIf},
+		{"unbalanced endif", nil, NewBuilder().AddOp(OP_1).AddOp(OP_ENDIF).Script(), checker, ErrUnbalancedIf},
+		{"multisig", PayToScriptHashUnlock([][]byte{sign(alice), sign(carol)}, multisig), PayToScriptHash(hash256(multisig)), checker, nil},
+		{"multisig out of order", PayToScriptHashUnlock([][]byte{sign(carol), sign(alice)}, multisig), PayToScriptHash(hash256(multisig)), checker, ErrEvalFalse},
+		{"multisig missing signature", PayToScriptHashUnlock([][]byte{sign(alice)}, multisig), PayToScriptHash(hash256(multisig)), checker, ErrStackUnderflow},
+		{"script hash other redeem", PayToScriptHashUnlock([][]byte{sign(alice)}, timeLock), PayToScriptHash(hash256(multisig)), checker, ErrEvalFalse},
+		{"relative lock reached", PayToScriptHashUnlock([][]byte{sign(alice)}, timeLock), PayToScriptHash(hash256(timeLock)), checker, nil},
+		{"relative lock not reached", PayToScriptHashUnlock([][]byte{sign(alice)}, timeLock), PayToScriptHash(hash256(timeLock)), fakeChecker{sequence: 49}, ErrUnsatisfiedLock},
+		{"lock without a lock checker", PayToScriptHashUnlock([][]byte{sign(alice)}, timeLock), PayToScriptHash(hash256(timeLock)), sigOnly{}, ErrUnsatisfiedLock},
+		{"negative lock", nil, NewBuilder().AddInt(-1).AddOp(OP_CHECKLOCKTIMEVERIFY).Script(), checker, ErrNegativeLock},
+		{"htlc secret", HTLCUnlock(sign(bob), secret, htlc), PayToScriptHash(hash256(htlc)), checker, nil},
+		{"htlc wrong secret", HTLCUnlock(sign(bob), bytes.Repeat([]byte{8}, SecretSize), htlc), PayToScriptHash(hash256(htlc)), checker, ErrEqualVerify},
+		{"htlc refund", HTLCUnlock(sign(alice), nil, htlc), PayToScriptHash(hash256(htlc)), checker, nil},
+		{"htlc early refund", HTLCUnlock(sign(alice), nil, htlc), PayToScriptHash(hash256(htlc)), fakeChecker{lockTime: 99}, ErrUnsatisfiedLock},
+		{"schnorr key", PayToSchnorrKeyUnlock(sign(schnorrKey)), PayToSchnorrKey(schnorrKey), checker, nil},
+		{"schnorr key bad signature", PayToSchnorrKeyUnlock(sign(alice)), PayToSchnorrKey(schnorrKey), checker, ErrSchnorrVerify},
+		{"schnorr without a schnorr checker", PayToSchnorrKeyUnlock(sign(schnorrKey)), PayToSchnorrKey(schnorrKey), sigOnly{}, ErrSchnorrVerify},
+		{"unknown version", PayToSchnorrKeyUnlock(sign(schnorrKey)), VersionedOutput(2, schnorrKey), checker, ErrUnknownVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Verify(test.unlocking, test.locking, test.checker); !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestTooManyOps(t *testing.T) {
+	builder := NewBuilder().AddOp(OP_1)
+	for i := 0; i < MaxOps; i++ {
+		builder.AddOp(OP_DUP).AddOp(OP_DROP)
+	}
+
+	if err := Verify(nil, builder.Script(), sigOnly{}); !errors.Is(err, ErrTooManyOps) {
+		t.Fatalf("got error %v, want %v", err, ErrTooManyOps)
+	}
+}
+
+func TestNum(t *testing.T) {
+	tests := []struct {
+		n       int64
+		encoded string
+	}{
+		{0, ""},
+		{1, "01"},
+		{-1, "81"},
+		{127, "7f"},
+		{128, "8000"},
+		{-128, "8080"},
+		{255, "ff00"},
+		{256, "0001"},
+		{-256, "0081"},
+		{2147483647, "ffffff7f"},
+		{-2147483648, "0000008080"},
+	}
+
+	for _, test := range tests {
+		encoded := EncodeNum(test.n)
+		if hex.EncodeToString(encoded) != test.encoded {
+			t.Fatalf("%d: got %x, want %s", test.n, encoded, test.encoded)
+		}
+
+		n, err := DecodeNum(encoded, maxNumSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != test.n {
+			t.Fatalf("%s: got %d, want %d", test.encoded, n, test.n)
+		}
+	}
+
+	if _, err := DecodeNum(make([]byte, maxNumSize+1), maxNumSize); !errors.Is(err, ErrNumTooLarge) {
+		t.Fatalf("got error %v, want %v", err, ErrNumTooLarge)
+	}
+}
@@ -0,0 +1,76 @@
+package script
+
+import "errors"
+
+// maxNumSize is the largest number in bytes that arithmetic opcodes accept
+const maxNumSize = 5
+
+var ErrNumTooLarge = errors.New("script: the number is too large")
+
+// EncodeNum will encode the number in the script format: little endian
+// with the highest bit of the last byte as the sign, zero is empty
+func EncodeNum(n int64) []byte {
+	if n == 0 {
+		return []byte{}
+	}
+
+	negative := n < 0
+	abs := uint64(n)
+	if negative {
+		abs = uint64(-n)
+	}
+
+	var result []byte
+	for abs > 0 {
+		result = append(result, byte(abs&0xff))
+		abs >>= 8
+	}
+
+	if result[len(result)-1]&0x80 != 0 {
+		extra := byte(0x00)
+		if negative {
+			extra = 0x80
+		}
+		result = append(result, extra)
+	} else if negative {
+		result[len(result)-1] |= 0x80
+	}
+
+	return result
+}
+
+// DecodeNum will decode a number in the script format
+func DecodeNum(data []byte, maxSize int) (int64, error) {
+	if len(data) > maxSize {
+		return 0, ErrNumTooLarge
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	var result int64
+	for i, b := range data {
+		result |= int64(b) << uint(8*i)
+	}
+
+	last := data[len(data)-1]
+	if last&0x80 != 0 {
+		result &= ^(int64(0x80) << uint(8*(len(data)-1)))
+		return -result, nil
+	}
+
+	return result, nil
+}
+
+// asBool will check if the item is true, any non zero value is true
+// except the negative zero
+func asBool(data []byte) bool {
+	for i, b := range data {
+		if b != 0 {
+			return !(i == len(data)-1 && b == 0x80)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package script
+
+const (
+	OP_0              = byte(0x00) // pushes an empty byte array
+	OP_FALSE          = OP_0
+	OP_PUSHDATA1      = byte(0x4c) // the next byte is the length of the data to push
+	OP_PUSHDATA2      = byte(0x4d) // the next two bytes are the length of the data to push
+	OP_1NEGATE        = byte(0x4f) // pushes the number -1
+	OP_1              = byte(0x51) // pushes the number 1, OP_2 to OP_16 follow
+	OP_TRUE           = OP_1
+	OP_16             = byte(0x60)
+	OP_IF             = byte(0x63) // runs the next statements if the top of the stack is true
+	OP_NOTIF          = byte(0x64) // runs the next statements if the top of the stack is false
+	OP_ELSE           = byte(0x67) // runs the next statements if the previous branch did not
+	OP_ENDIF          = byte(0x68) // ends an if block
+	OP_VERIFY         = byte(0x69) // fails unless the top of the stack is true
+	OP_RETURN         = byte(0x6a) // fails right away, marks outputs as unspendable
+	OP_DROP           = byte(0x75) // removes the top of the stack
+	OP_DUP            = byte(0x76) // duplicates the top of the stack
+	OP_SWAP           = byte(0x7c) // swaps the two top items of the stack
+	OP_SIZE           = byte(0x82) // pushes the length of the top of the stack
+	OP_EQUAL          = byte(0x87) // pushes if the two top items are equal
+	OP_EQUALVERIFY    = byte(0x88) // OP_EQUAL followed by OP_VERIFY
+	OP_SHA256         = byte(0xa8) // replaces the top of the stack with its sha256
+	OP_HASH256        = byte(0xaa) // replaces the top of the stack with its double sha256
+	OP_CHECKSIG       = byte(0xac) // pushes if the signature is valid for the public key
+	OP_CHECKSIGVERIFY = byte(0xad) // OP_CHECKSIG followed by OP_VERIFY
+
+	OP_CHECKMULTISIG       = byte(0xae) // pushes if m signatures match m of the n public keys
+	OP_CHECKMULTISIGVERIFY = byte(0xaf) // OP_CHECKMULTISIG followed by OP_VERIFY
+
+	OP_CHECKLOCKTIMEVERIFY = byte(0xb1) // fails unless the lock time of the transaction reached the top of the stack
+	OP_CHECKSEQUENCEVERIFY = byte(0xb2) // fails unless the relative lock of the input reached the top of the stack
+)
+
+// opNames holds the names used when a script is disassembled
+var opNames = map[byte]string{
+	OP_0:              "OP_0",
+	OP_PUSHDATA1:      "OP_PUSHDATA1",
+	OP_PUSHDATA2:      "OP_PUSHDATA2",
+	OP_1NEGATE:        "OP_1NEGATE",
+	OP_IF:             "OP_IF",
+	OP_NOTIF:          "OP_NOTIF",
+	OP_ELSE:           "OP_ELSE",
+	OP_ENDIF:          "OP_ENDIF",
+	OP_VERIFY:         "OP_VERIFY",
+	OP_RETURN:         "OP_RETURN",
+	OP_DROP:           "OP_DROP",
+	OP_DUP:            "OP_DUP",
+	OP_SWAP:           "OP_SWAP",
+	OP_SIZE:           "OP_SIZE",
+	OP_EQUAL:          "OP_EQUAL",
+	OP_EQUALVERIFY:    "OP_EQUALVERIFY",
+	OP_SHA256:         "OP_SHA256",
+	OP_HASH256:        "OP_HASH256",
+	OP_CHECKSIG:       "OP_CHECKSIG",
+	OP_CHECKSIGVERIFY: "OP_CHECKSIGVERIFY",
+
+	OP_CHECKMULTISIG:       "OP_CHECKMULTISIG",
+	OP_CHECKMULTISIGVERIFY: "OP_CHECKMULTISIGVERIFY",
+	OP_CHECKLOCKTIMEVERIFY: "OP_CHECKLOCKTIMEVERIFY",
+	OP_CHECKSEQUENCEVERIFY: "OP_CHECKSEQUENCEVERIFY",
+}
+
+// isSmallInt will check if the opcode pushes a number from 1 to 16
+func isSmallInt(op byte) bool {
+	return op >= OP_1 && op <= OP_16
+}
+
+// isPush will check if the opcode only pushes data to the stack
+func isPush(op byte) bool {
+	return op <= OP_PUSHDATA2 || op == OP_1NEGATE || isSmallInt(op)
+}
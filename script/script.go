@@ -0,0 +1,159 @@
+// Package script implements the small stack based language that locks
+// transaction outputs. An output holds a locking script, the input that
+// spends it provides an unlocking script that only pushes data. The
+// unlocking script runs first and the locking script runs over the stack
+// it leaves, the output is spent when the locking script finishes with a
+// true value on top of the stack.
+package script
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MaxScriptSize  = 10000 // represents the largest script in bytes
+	MaxElementSize = 520   // represents the largest item that can be pushed
+	MaxStackSize   = 1000  // represents the deepest stack allowed
+	MaxOps         = 201   // represents how many non push opcodes a script may run
+)
+
+var (
+	ErrScriptTooLarge = errors.New("script: the script is too large")
+	ErrMalformedPush  = errors.New("script: a push reads past the end of the script")
+	ErrElementTooBig  = errors.New("script: a pushed item is too large")
+)
+
+type Instruction struct {
+	Op   byte   // represents the opcode of the instruction
+	Data []byte // represents the pushed data, only for push opcodes
+}
+
+// Parse will split the given script into its instructions
+func Parse(script []byte) ([]Instruction, error) {
+	if len(script) > MaxScriptSize {
+		return nil, ErrScriptTooLarge
+	}
+
+	var instructions []Instruction
+	for pc := 0; pc < len(script); {
+		op := script[pc]
+		pc++
+
+		size := 0
+		switch {
+		case op > OP_0 && op < OP_PUSHDATA1:
+			size = int(op)
+
+		case op == OP_PUSHDATA1:
+			if pc+1 > len(script) {
+				return nil, ErrMalformedPush
+			}
+			size = int(script[pc])
+			pc++
+
+		case op == OP_PUSHDATA2:
+			if pc+2 > len(script) {
+				return nil, ErrMalformedPush
+			}
+			size = int(binary.LittleEndian.Uint16(script[pc:]))
+			pc += 2
+		}
+
+		if pc+size > len(script) {
+			return nil, ErrMalformedPush
+		}
+
+		if size > MaxElementSize {
+			return nil, ErrElementTooBig
+		}
+
+		inst := Instruction{Op: op}
+		if isPush(op) && op != OP_1NEGATE && !isSmallInt(op) {
+			inst.Data = append([]byte{}, script[pc:pc+size]...)
+		}
+
+		instructions = append(instructions, inst)
+		pc += size
+	}
+
+	return instructions, nil
+}
+
+// IsPushOnly will check if the script only pushes data
+func IsPushOnly(script []byte) bool {
+	instructions, err := Parse(script)
+	if err != nil {
+		return false
+	}
+
+	for _, inst := range instructions {
+		if !isPush(inst.Op) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PushedData will return the items pushed by a push only script
+func PushedData(script []byte) ([][]byte, error) {
+	instructions, err := Parse(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var data [][]byte
+	for _, inst := range instructions {
+		if !isPush(inst.Op) {
+			return nil, ErrNotPushOnly
+		}
+
+		data = append(data, pushValue(inst))
+	}
+
+	return data, nil
+}
+
+// Disassemble will return the human readable form of the script
+func Disassemble(script []byte) string {
+	instructions, err := Parse(script)
+	if err != nil {
+		return fmt.Sprintf("[error: %s] %x", err, script)
+	}
+
+	var parts []string
+	for _, inst := range instructions {
+		switch {
+		case inst.Data != nil && len(inst.Data) > 0:
+			parts = append(parts, hex.EncodeToString(inst.Data))
+
+		case isSmallInt(inst.Op):
+			parts = append(parts, fmt.Sprintf("OP_%d", inst.Op-OP_1+1))
+
+		case opNames[inst.Op] != "":
+			parts = append(parts, opNames[inst.Op])
+
+		default:
+			parts = append(parts, fmt.Sprintf("OP_UNKNOWN_%#x", inst.Op))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// pushValue will return the item a push instruction puts on the stack
+func pushValue(inst Instruction) []byte {
+	switch {
+	case inst.Op == OP_1NEGATE:
+		return EncodeNum(-1)
+
+	case isSmallInt(inst.Op):
+		return EncodeNum(int64(inst.Op-OP_1) + 1)
+	}
+
+	return inst.Data
+}
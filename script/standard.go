@@ -0,0 +1,383 @@
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+var (
+	ErrInvalidMultiSig = errors.New("script: a multisig needs 1 <= m <= n <= 16 public keys")
+	ErrDataTooLarge    = errors.New("script: the data of the output is too large")
+)
+
+// PayToPubKeyHash will create the locking script that can be spent by
+// the owner of the public key with the given hash:
+//
+//	OP_DUP OP_HASH256 <pubKeyHash> OP_EQUALVERIFY OP_CHECKSIG
+func PayToPubKeyHash(pubKeyHash []byte) []byte {
+	return NewBuilder().
+		AddOp(OP_DUP).
+		AddOp(OP_HASH256).
+		AddData(pubKeyHash).
+		AddOp(OP_EQUALVERIFY).
+		AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// PayToPubKeyHashUnlock will create the unlocking script of a pay to
+// public key hash output: <signature> <pubKey>
+func PayToPubKeyHashUnlock(signature, pubKey []byte) []byte {
+	return NewBuilder().AddData(signature).AddData(pubKey).Script()
+}
+
+// ExtractPubKeyHash will return the public key hash of a pay to public
+// key hash locking script
+func ExtractPubKeyHash(locking []byte) ([]byte, bool) {
+	instructions, err := Parse(locking)
+	if err != nil || len(instructions) != 5 {
+		return nil, false
+	}
+
+	if instructions[0].Op != OP_DUP ||
+		instructions[1].Op != OP_HASH256 ||
+		len(instructions[2].Data) == 0 ||
+		instructions[3].Op != OP_EQUALVERIFY ||
+		instructions[4].Op != OP_CHECKSIG {
+		return nil, false
+	}
+
+	return instructions[2].Data, true
+}
+
+// ExtractSigPubKey will return the signature and the public key of a pay
+// to public key hash unlocking script
+func ExtractSigPubKey(unlocking []byte) ([]byte, []byte, bool) {
+	data, err := PushedData(unlocking)
+	if err != nil || len(data) != 2 {
+		return nil, nil, false
+	}
+
+	return data[0], data[1], true
+}
+
+// PayToScriptHash will create the locking script that can be spent by
+// whoever reveals a redeem script with the given hash and satisfies it:
+//
+//	OP_HASH256 <scriptHash> OP_EQUAL
+func PayToScriptHash(scriptHash []byte) []byte {
+	return NewBuilder().
+		AddOp(OP_HASH256).
+		AddData(scriptHash).
+		AddOp(OP_EQUAL).
+		Script()
+}
+
+// IsPayToScriptHash will check if the locking script is a pay to script hash
+func IsPayToScriptHash(locking []byte) bool {
+	_, ok := ExtractScriptHash(locking)
+	return ok
+}
+
+// ExtractScriptHash will return the redeem script hash of a pay to
+// script hash locking script
+func ExtractScriptHash(locking []byte) ([]byte, bool) {
+	instructions, err := Parse(locking)
+	if err != nil || len(instructions) != 3 {
+		return nil, false
+	}
+
+	if instructions[0].Op != OP_HASH256 ||
+		len(instructions[1].Data) != 32 ||
+		instructions[2].Op != OP_EQUAL {
+		return nil, false
+	}
+
+	return instructions[1].Data, true
+}
+
+// MultiSig will create the redeem script that needs m signatures of the
+// given public keys:
+//
+//	<m> <pubKey 1> ... <pubKey n> <n> OP_CHECKMULTISIG
+func MultiSig(m int, pubKeys [][]byte) ([]byte, error) {
+	n := len(pubKeys)
+	if m < 1 || m > n || n > MaxMultiSigKeys {
+		return nil, ErrInvalidMultiSig
+	}
+
+	builder := NewBuilder().AddInt(int64(m))
+	for _, pubKey := range pubKeys {
+		builder.AddData(pubKey)
+	}
+
+	return builder.AddInt(int64(n)).AddOp(OP_CHECKMULTISIG).Script(), nil
+}
+
+// ExtractMultiSig will return the required signatures and the public
+// keys of a multisig redeem script
+func ExtractMultiSig(redeem []byte) (int, [][]byte, bool) {
+	instructions, err := Parse(redeem)
+	if err != nil || len(instructions) < 4 {
+		return 0, nil, false
+	}
+
+	last := len(instructions) - 1
+	if instructions[last].Op != OP_CHECKMULTISIG ||
+		!isSmallInt(instructions[0].Op) ||
+		!isSmallInt(instructions[last-1].Op) {
+		return 0, nil, false
+	}
+
+	m := int(instructions[0].Op-OP_1) + 1
+	n := int(instructions[last-1].Op-OP_1) + 1
+	if n != last-2 || m > n {
+		return 0, nil, false
+	}
+
+	var pubKeys [][]byte
+	for _, inst := range instructions[1 : last-1] {
+		if len(inst.Data) == 0 {
+			return 0, nil, false
+		}
+
+		pubKeys = append(pubKeys, inst.Data)
+	}
+
+	return m, pubKeys, true
+}
+
+// PayToScriptHashUnlock will create the unlocking script of a pay to
+// script hash output: <sig 1> ... <sig m> <redeem script>
+func PayToScriptHashUnlock(signatures [][]byte, redeem []byte) []byte {
+	builder := NewBuilder()
+	for _, signature := range signatures {
+		builder.AddData(signature)
+	}
+
+	return builder.AddData(redeem).Script()
+}
+
+// TimeLock will create the redeem script that the owner of the public key
+// can spend once the lock is reached, an absolute lock time or a relative
+// lock of the input when relative is set:
+//
+//	<lock> OP_CHECKLOCKTIMEVERIFY OP_DROP <pubKey> OP_CHECKSIG
+func TimeLock(lock int64, relative bool, pubKey []byte) []byte {
+	op := OP_CHECKLOCKTIMEVERIFY
+	if relative {
+		op = OP_CHECKSEQUENCEVERIFY
+	}
+
+	return NewBuilder().
+		AddInt(lock).
+		AddOp(op).
+		AddOp(OP_DROP).
+		AddData(pubKey).
+		AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// ExtractTimeLock will return the lock, its kind and the public key of a
+// time lock redeem script
+func ExtractTimeLock(redeem []byte) (int64, bool, []byte, bool) {
+	instructions, err := Parse(redeem)
+	if err != nil || len(instructions) != 5 {
+		return 0, false, nil, false
+	}
+
+	op := instructions[1].Op
+	if !isPush(instructions[0].Op) ||
+		(op != OP_CHECKLOCKTIMEVERIFY && op != OP_CHECKSEQUENCEVERIFY) ||
+		instructions[2].Op != OP_DROP ||
+		len(instructions[3].Data) == 0 ||
+		instructions[4].Op != OP_CHECKSIG {
+		return 0, false, nil, false
+	}
+
+	lock, err := DecodeNum(pushValue(instructions[0]), maxNumSize)
+	if err != nil || lock < 0 {
+		return 0, false, nil, false
+	}
+
+	return lock, op == OP_CHECKSEQUENCEVERIFY, instructions[3].Data, true
+}
+
+// HTLC is a hash time locked contract, the recipient spends it with the
+// preimage of the secret hash or the sender takes it back after the lock time
+type HTLC struct {
+	SecretHash []byte // represents the sha256 of the secret
+	Recipient  []byte // represents the public key that spends with the secret
+	Sender     []byte // represents the public key that spends after the lock time
+	LockTime   int64  // represents the height or unix time of the refund
+}
+
+// SecretSize is the size of the secret of a hash time locked contract
+const SecretSize = 32
+
+// Script will create the redeem script of the contract:
+//
+//	OP_IF
+//		OP_SIZE 32 OP_EQUALVERIFY OP_SHA256 <secretHash> OP_EQUALVERIFY <recipient>
+//	OP_ELSE
+//		<lockTime> OP_CHECKLOCKTIMEVERIFY OP_DROP <sender>
+//	OP_ENDIF
+//	OP_CHECKSIG
+func (h HTLC) Script() []byte {
+	return NewBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt(SecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(h.SecretHash).AddOp(OP_EQUALVERIFY).
+		AddData(h.Recipient).
+		AddOp(OP_ELSE).
+		AddInt(h.LockTime).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddData(h.Sender).
+		AddOp(OP_ENDIF).
+		AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// ExtractHTLC will return the contract of a hash time locked redeem script
+func ExtractHTLC(redeem []byte) (HTLC, bool) {
+	instructions, err := Parse(redeem)
+	if err != nil || len(instructions) != 15 {
+		return HTLC{}, false
+	}
+
+	contract := HTLC{
+		SecretHash: instructions[5].Data,
+		Recipient:  instructions[7].Data,
+		Sender:     instructions[12].Data,
+	}
+
+	lockTime, err := DecodeNum(pushValue(instructions[9]), maxNumSize)
+	if err != nil || lockTime < 0 {
+		return HTLC{}, false
+	}
+
+	contract.LockTime = lockTime
+	if !bytes.Equal(contract.Script(), redeem) ||
+		len(contract.SecretHash) != sha256.Size ||
+		len(contract.Recipient) == 0 ||
+		len(contract.Sender) == 0 {
+		return HTLC{}, false
+	}
+
+	return contract, true
+}
+
+// HTLCUnlock will create the unlocking script of a pay to script hash
+// contract, the secret picks the branch, nil refunds:
+//
+//	<signature> <secret> OP_TRUE <redeem script>
+//	<signature> OP_FALSE <redeem script>
+func HTLCUnlock(signature, secret, redeem []byte) []byte {
+	builder := NewBuilder().AddData(signature)
+	if secret != nil {
+		builder.AddData(secret).AddOp(OP_TRUE)
+	} else {
+		builder.AddOp(OP_FALSE)
+	}
+
+	return builder.AddData(redeem).Script()
+}
+
+// ExtractHTLCSecret will return the secret revealed by an unlocking
+// script that redeems the given contract
+func ExtractHTLCSecret(unlocking, redeem []byte) ([]byte, bool) {
+	data, err := PushedData(unlocking)
+	if err != nil || len(data) != 4 {
+		return nil, false
+	}
+
+	if !bytes.Equal(data[3], redeem) || !asBool(data[2]) || len(data[1]) != SecretSize {
+		return nil, false
+	}
+
+	return data[1], true
+}
+
+// MaxDataSize is the largest payload of a data output
+const MaxDataSize = 80
+
+// NullData will create the locking script of a data output, OP_RETURN
+// makes it fail right away so the output can never be spent:
+//
+//	OP_RETURN <data>
+func NullData(data []byte) ([]byte, error) {
+	if len(data) > MaxDataSize {
+		return nil, ErrDataTooLarge
+	}
+
+	return NewBuilder().AddOp(OP_RETURN).AddData(data).Script(), nil
+}
+
+// ExtractNullData will return the payload of a data output
+func ExtractNullData(locking []byte) ([]byte, bool) {
+	instructions, err := Parse(locking)
+	if err != nil || len(instructions) != 2 || instructions[0].Op != OP_RETURN || !isPush(instructions[1].Op) {
+		return nil, false
+	}
+
+	return pushValue(instructions[1]), true
+}
+
+// IsUnspendable will check if the locking script can never be satisfied,
+// such outputs are kept out of the unspent set
+func IsUnspendable(locking []byte) bool {
+	return len(locking) > 0 && locking[0] == OP_RETURN
+}
+
+// SchnorrVersion is the version of the outputs that pay to a schnorr key
+const SchnorrVersion = 1
+
+// VersionedOutput will create the locking script of a versioned output, a
+// small number with the version followed by its program:
+//
+//	OP_<version> <program>
+//
+// The engine does not run the program, the version tells how the input
+// that spends it is checked. Unknown versions can not be spent yet
+func VersionedOutput(version int, program []byte) []byte {
+	return NewBuilder().AddInt(int64(version)).AddData(program).Script()
+}
+
+// ExtractVersionedOutput will return the version and the program of a
+// versioned output, the program holds 2 to 40 bytes
+func ExtractVersionedOutput(locking []byte) (int, []byte, bool) {
+	instructions, err := Parse(locking)
+	if err != nil || len(instructions) != 2 || !isSmallInt(instructions[0].Op) {
+		return 0, nil, false
+	}
+
+	program := instructions[1].Data
+	if instructions[1].Op != byte(len(program)) || len(program) < 2 || len(program) > 40 {
+		return 0, nil, false
+	}
+
+	return int(instructions[0].Op-OP_1) + 1, program, true
+}
+
+// PayToSchnorrKey will create the version 1 output that the owner of the
+// compressed key spends with a schnorr signature:
+//
+//	OP_1 <pubKey>
+func PayToSchnorrKey(pubKey []byte) []byte {
+	return VersionedOutput(SchnorrVersion, pubKey)
+}
+
+// PayToSchnorrKeyUnlock will create the unlocking script of a schnorr
+// key output: <signature>
+func PayToSchnorrKeyUnlock(signature []byte) []byte {
+	return NewBuilder().AddData(signature).Script()
+}
+
+// ExtractSchnorrKey will return the key of a version 1 output
+func ExtractSchnorrKey(locking []byte) ([]byte, bool) {
+	version, program, ok := ExtractVersionedOutput(locking)
+	if !ok || version != SchnorrVersion || len(program) != 33 {
+		return nil, false
+	}
+
+	return program, true
+}
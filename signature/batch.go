@@ -0,0 +1,113 @@
+package signature
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+)
+
+// batchEntry is a parsed schnorr signature waiting in a batch
+type batchEntry struct {
+	px, py *big.Int // represents the public key
+	rx, ry *big.Int // represents the nonce point of the signature
+	s      *big.Int // represents the scalar of the signature
+	e      *big.Int // represents the challenge of the signature
+}
+
+// Batch verifies many schnorr signatures at once, like every signature of
+// a block. Each signature is weighted with a random coefficient a and the
+// sum is checked with one equation: (sum a*s)*G = sum a*R + sum a*e*P.
+// A forged signature can only pass if it guesses the coefficients. The
+// signatures may be added from many goroutines
+type Batch struct {
+	entries []batchEntry
+	mu      sync.Mutex
+}
+
+// NewBatch will create an empty batch
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Add will parse the signature of the hash and queue it, malformed
+// signatures and keys are rejected right away
+func (b *Batch) Add(pubKey, hash, signature []byte) error {
+	px, py, ok := parsePoint(pubKey)
+	if !ok {
+		return ErrInvalidKey
+	}
+
+	if len(signature) != SchnorrSize {
+		return ErrInvalidSignature
+	}
+
+	rx, ry, ok := parsePoint(signature[:KeySize])
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	s, ok := parseScalar(signature[KeySize:])
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	e := challenge(signature[:KeySize], pubKey, hash)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, batchEntry{px: px, py: py, rx: rx, ry: ry, s: s, e: e})
+	return nil
+}
+
+// Len will return the number of queued signatures
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.entries)
+}
+
+// Verify will report if every queued signature is valid, an empty batch is
+func (b *Batch) Verify() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) == 0 {
+		return true
+	}
+
+	c := curve()
+	n := order()
+	sum := new(big.Int)
+	var qx, qy *big.Int
+
+	for i, entry := range b.entries {
+		a := big.NewInt(1)
+		if i > 0 {
+			coef := make([]byte, 16)
+			if _, err := rand.Read(coef); err != nil {
+				return false
+			}
+			a.SetBytes(coef)
+		}
+
+		term := new(big.Int).Mul(a, entry.s)
+		sum.Add(sum, term).Mod(sum, n)
+
+		ae := new(big.Int).Mul(a, entry.e)
+		ae.Mod(ae, n)
+
+		rx, ry := c.ScalarMult(entry.rx, entry.ry, scalarBytes(a))
+		ex, ey := c.ScalarMult(entry.px, entry.py, scalarBytes(ae))
+		tx, ty := c.Add(rx, ry, ex, ey)
+
+		if qx == nil {
+			qx, qy = tx, ty
+		} else {
+			qx, qy = c.Add(qx, qy, tx, ty)
+		}
+	}
+
+	lx, ly := c.ScalarBaseMult(scalarBytes(sum))
+	return lx.Cmp(qx) == 0 && ly.Cmp(qy) == 0
+}
@@ -0,0 +1,225 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+// signed is a schnorr signature with its key and message
+type signed struct {
+	pubKey    []byte
+	hash      []byte
+	signature []byte
+}
+
+// signMessages will sign a different message with a new key for each signature
+func signMessages(t *testing.T, n int) []signed {
+	t.Helper()
+
+	var sigs []signed
+	for i := 0; i < n; i++ {
+		key, err := ecdsa.GenerateKey(curve(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash := sha256.Sum256([]byte(fmt.Sprintf("message %d", i)))
+		sig, err := Schnorr.Sign(key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sigs = append(sigs, signed{pubKey: MarshalPubKey(&key.PublicKey), hash: hash[:], signature: sig})
+	}
+
+	return sigs
+}
+
+// shiftScalar will add delta to the s of the signature
+func shiftScalar(signature []byte, delta int64) []byte {
+	s, _ := parseScalar(signature[KeySize:])
+	s.Add(s, big.NewInt(delta)).Mod(s, order())
+	return append(append([]byte{}, signature[:KeySize]...), scalarBytes(s)...)
+}
+
+func TestBatchVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(sigs []signed)
+		valid  bool
+	}{
+		{"valid", func(sigs []signed) {}, true},
+		{"other message", func(sigs []signed) { sigs[2].hash = sigs[1].hash }, false},
+		{"other key", func(sigs []signed) { sigs[0].pubKey = sigs[3].pubKey }, false},
+		{"swapped signatures", func(sigs []signed) {
+			sigs[1].signature, sigs[2].signature = sigs[2].signature, sigs[1].signature
+		}, false},
+		// the errors cancel out in a plain sum, the coefficients catch them
+		{"compensating forgeries", func(sigs []signed) {
+			sigs[0].signature = shiftScalar(sigs[0].signature, 1)
+			sigs[1].signature = shiftScalar(sigs[1].signature, -1)
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sigs := signMessages(t, 4)
+			test.change(sigs)
+
+			batch := NewBatch()
+			for _, sig := range sigs {
+				if err := batch.Add(sig.pubKey, sig.hash, sig.signature); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if batch.Len() != len(sigs) {
+				t.Fatalf("got %d signatures in the batch, want %d", batch.Len(), len(sigs))
+			}
+
+			if valid := batch.Verify(); valid != test.valid {
+				t.Fatalf("got valid %v, want %v", valid, test.valid)
+			}
+
+			// the batch must agree with the signatures one by one
+			valid := true
+			for _, sig := range sigs {
+				valid = valid && Schnorr.Verify(sig.pubKey, sig.hash, sig.signature)
+			}
+
+			if valid != test.valid {
+				t.Fatalf("got valid %v one by one, want %v", valid, test.valid)
+			}
+		})
+	}
+}
+
+func TestBatchEmpty(t *testing.T) {
+	if !NewBatch().Verify() {
+		t.Fatal("an empty batch is not valid")
+	}
+}
+
+func TestBatchAddRejects(t *testing.T) {
+	sig := signMessages(t, 1)[0]
+	high := append(append([]byte{}, sig.signature[:KeySize]...), scalarBytes(order())...)
+
+	tests := []struct {
+		name      string
+		pubKey    []byte
+		signature []byte
+		err       error
+	}{
+		{"uncompressed key", append([]byte{4}, sig.pubKey[1:]...), sig.signature, ErrInvalidKey},
+		{"short key", sig.pubKey[1:], sig.signature, ErrInvalidKey},
+		{"short signature", sig.pubKey, sig.signature[1:], ErrInvalidSignature},
+		{"nonce off the curve", sig.pubKey, append([]byte{5}, sig.signature[1:]...), ErrInvalidSignature},
+		{"scalar past the order", sig.pubKey, high, ErrInvalidSignature},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			batch := NewBatch()
+			if err := batch.Add(test.pubKey, sig.hash, test.signature); !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if batch.Len() != 0 {
+				t.Fatal("the rejected signature was queued")
+			}
+		})
+	}
+}
+
+func TestBatchConcurrentAdd(t *testing.T) {
+	sigs := signMessages(t, 16)
+	batch := NewBatch()
+
+	var wg sync.WaitGroup
+	for _, sig := range sigs {
+		wg.Add(1)
+		go func(sig signed) {
+			defer wg.Done()
+			if err := batch.Add(sig.pubKey, sig.hash, sig.signature); err != nil {
+				t.Error(err)
+			}
+		}(sig)
+	}
+
+	wg.Wait()
+	if batch.Len() != len(sigs) || !batch.Verify() {
+		t.Fatalf("got %d valid signatures, want %d", batch.Len(), len(sigs))
+	}
+}
+
+func TestMuSigInBatch(t *testing.T) {
+	hash := sha256.Sum256([]byte("musig"))
+
+	var keys []*ecdsa.PrivateKey
+	var pubKeys, secretNonces, publicNonces [][]byte
+	for i := 0; i < 3; i++ {
+		key, err := ecdsa.GenerateKey(curve(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		secret, public, err := NewNonce()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys = append(keys, key)
+		pubKeys = append(pubKeys, MarshalPubKey(&key.PublicKey))
+		secretNonces = append(secretNonces, secret)
+		publicNonces = append(publicNonces, public)
+	}
+
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggNonce, err := AggregateNonces(publicNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var partials [][]byte
+	for i, key := range keys {
+		partial, err := PartialSign(key, secretNonces[i], aggNonce, pubKeys, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partials = append(partials, partial)
+	}
+
+	sig, err := AggregateSignatures(aggNonce, pubKeys, hash[:], partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := NewBatch()
+	if err := batch.Add(aggKey, hash[:], sig); err != nil {
+		t.Fatal(err)
+	}
+
+	if !Schnorr.Verify(aggKey, hash[:], sig) || !batch.Verify() {
+		t.Fatal("the musig signature does not verify")
+	}
+
+	partial, err := AggregateSignatures(aggNonce, pubKeys, hash[:], partials[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Schnorr.Verify(aggKey, hash[:], partial) {
+		t.Fatal("the signature of two of the three holders verifies")
+	}
+}
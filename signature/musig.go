@@ -0,0 +1,251 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// MuSig lets many holders sign for one aggregated key, the result is a
+// plain schnorr signature so a multi party output looks like any other
+// single key output on chain. Signing takes two rounds: every holder
+// shares a public nonce, then every holder makes a partial signature
+// with the aggregated nonce and the partial signatures are added up.
+//
+// The aggregated key is sum a_i*P_i with a_i the tagged hash of the
+// sorted key list and P_i, so no holder can choose a key that cancels
+// the others. Two nonces per holder make the final nonce R = R1 + b*R2
+// depend on the message, which keeps concurrent sessions safe
+
+const (
+	keyListTag   = "goblock/musig/keylist"
+	keyCoefTag   = "goblock/musig/coefficient"
+	nonceCoefTag = "goblock/musig/noncecoef"
+
+	SecretNonceSize = 64 // size of the two secret nonce scalars
+	PublicNonceSize = 66 // size of the two compressed nonce points
+	PartialSize     = 32 // size of a partial signature
+)
+
+var (
+	ErrNoKeys        = errors.New("signature: musig needs at least one key")
+	ErrDuplicateKey  = errors.New("signature: the musig keys must be unique")
+	ErrNotMuSigKey   = errors.New("signature: the key is not one of the musig keys")
+	ErrInvalidResult = errors.New("signature: the aggregated point is not valid")
+)
+
+// SortKeys will return a sorted copy of the keys, the aggregated key does
+// not depend on the order the holders list them in
+func SortKeys(keys [][]byte) [][]byte {
+	sorted := append([][]byte{}, keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	return sorted
+}
+
+// keyCoefficients will return the coefficient of every sorted key
+func keyCoefficients(sorted [][]byte) []*big.Int {
+	list := taggedHash(keyListTag, sorted...)
+
+	var coefs []*big.Int
+	for _, key := range sorted {
+		coefs = append(coefs, hashScalar(keyCoefTag, list, key))
+	}
+
+	return coefs
+}
+
+// AggregateKeys will return the compressed aggregated key of the compressed keys
+func AggregateKeys(keys [][]byte) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	sorted := SortKeys(keys)
+	coefs := keyCoefficients(sorted)
+	c := curve()
+
+	var ax, ay *big.Int
+	for i, key := range sorted {
+		if i > 0 && bytes.Equal(key, sorted[i-1]) {
+			return nil, ErrDuplicateKey
+		}
+
+		px, py, ok := parsePoint(key)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		tx, ty := c.ScalarMult(px, py, scalarBytes(coefs[i]))
+		if ax == nil {
+			ax, ay = tx, ty
+		} else {
+			ax, ay = c.Add(ax, ay, tx, ty)
+		}
+	}
+
+	aggregated, ok := marshalPoint(ax, ay)
+	if !ok {
+		return nil, ErrInvalidResult
+	}
+
+	return aggregated, nil
+}
+
+// NewNonce will create the secret and public nonces of one signing
+// session, a secret nonce must never sign twice
+func NewNonce() ([]byte, []byte, error) {
+	var secret, public []byte
+	for i := 0; i < 2; i++ {
+		k, err := randomScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r, _ := marshalPoint(curve().ScalarBaseMult(scalarBytes(k)))
+		secret = append(secret, scalarBytes(k)...)
+		public = append(public, r...)
+	}
+
+	return secret, public, nil
+}
+
+// AggregateNonces will add up the public nonces of every holder
+func AggregateNonces(nonces [][]byte) ([]byte, error) {
+	if len(nonces) == 0 {
+		return nil, ErrInvalidNonce
+	}
+
+	c := curve()
+	var aggregated []byte
+	for part := 0; part < 2; part++ {
+		var sx, sy *big.Int
+		for _, nonce := range nonces {
+			if len(nonce) != PublicNonceSize {
+				return nil, ErrInvalidNonce
+			}
+
+			rx, ry, ok := parsePoint(nonce[part*KeySize : (part+1)*KeySize])
+			if !ok {
+				return nil, ErrInvalidNonce
+			}
+
+			if sx == nil {
+				sx, sy = rx, ry
+			} else {
+				sx, sy = c.Add(sx, sy, rx, ry)
+			}
+		}
+
+		r, ok := marshalPoint(sx, sy)
+		if !ok {
+			return nil, ErrInvalidResult
+		}
+		aggregated = append(aggregated, r...)
+	}
+
+	return aggregated, nil
+}
+
+// finalNonce will return b and the compressed final nonce R = R1 + b*R2
+func finalNonce(aggNonce, aggKey, hash []byte) (*big.Int, []byte, error) {
+	if len(aggNonce) != PublicNonceSize {
+		return nil, nil, ErrInvalidNonce
+	}
+
+	r1x, r1y, ok1 := parsePoint(aggNonce[:KeySize])
+	r2x, r2y, ok2 := parsePoint(aggNonce[KeySize:])
+	if !ok1 || !ok2 {
+		return nil, nil, ErrInvalidNonce
+	}
+
+	c := curve()
+	b := hashScalar(nonceCoefTag, aggNonce, aggKey, hash)
+	bx, by := c.ScalarMult(r2x, r2y, scalarBytes(b))
+
+	r, ok := marshalPoint(c.Add(r1x, r1y, bx, by))
+	if !ok {
+		return nil, nil, ErrInvalidResult
+	}
+
+	return b, r, nil
+}
+
+// PartialSign will make the partial signature of the holder of the
+// private key, s_i = k1 + b*k2 + e*a_i*x_i, with the secret nonce it
+// shared in the first round and the aggregated nonce of every holder
+func PartialSign(privKey *ecdsa.PrivateKey, secretNonce, aggNonce []byte, keys [][]byte, hash []byte) ([]byte, error) {
+	if len(secretNonce) != SecretNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	k1, ok1 := parseScalar(secretNonce[:32])
+	k2, ok2 := parseScalar(secretNonce[32:])
+	if !ok1 || !ok2 || k1.Sign() == 0 || k2.Sign() == 0 {
+		return nil, ErrInvalidNonce
+	}
+
+	aggKey, err := AggregateKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := SortKeys(keys)
+	coefs := keyCoefficients(sorted)
+	own := MarshalPubKey(&privKey.PublicKey)
+
+	var a *big.Int
+	for i, key := range sorted {
+		if bytes.Equal(key, own) {
+			a = coefs[i]
+		}
+	}
+
+	if a == nil {
+		return nil, ErrNotMuSigKey
+	}
+
+	b, r, err := finalNonce(aggNonce, aggKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	n := order()
+	e := challenge(r, aggKey, hash)
+
+	s := new(big.Int).Mul(e, a)
+	s.Mul(s, privKey.D)
+	s.Add(s, new(big.Int).Mul(b, k2))
+	s.Add(s, k1).Mod(s, n)
+	return scalarBytes(s), nil
+}
+
+// AggregateSignatures will add up the partial signatures of every holder
+// into the schnorr signature of the aggregated key
+func AggregateSignatures(aggNonce []byte, keys [][]byte, hash []byte, partials [][]byte) ([]byte, error) {
+	aggKey, err := AggregateKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	_, r, err := finalNonce(aggNonce, aggKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	n := order()
+	s := new(big.Int)
+	for _, partial := range partials {
+		si, ok := parseScalar(partial)
+		if !ok {
+			return nil, ErrInvalidSignature
+		}
+		s.Add(s, si).Mod(s, n)
+	}
+
+	return append(r, scalarBytes(s)...), nil
+}
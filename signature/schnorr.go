@@ -0,0 +1,68 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+)
+
+const (
+	challengeTag = "goblock/schnorr/challenge"
+	nonceTag     = "goblock/schnorr/nonce"
+)
+
+// schnorrScheme signs with schnorr over P-256
+type schnorrScheme struct{}
+
+// challenge will return e, the hash of the nonce point, the key and the message
+func challenge(r, pubKey, hash []byte) *big.Int {
+	return hashScalar(challengeTag, r, pubKey, hash)
+}
+
+// Sign will sign the hash with schnorr, the nonce is derived from the key,
+// the message and fresh randomness so a broken random source alone does
+// not leak the key
+func (schnorrScheme) Sign(privKey *ecdsa.PrivateKey, hash []byte) ([]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return nil, err
+	}
+
+	k := hashScalar(nonceTag, scalarBytes(privKey.D), hash, aux)
+	if k.Sign() == 0 {
+		return nil, ErrInvalidNonce
+	}
+
+	r, _ := marshalPoint(curve().ScalarBaseMult(scalarBytes(k)))
+	e := challenge(r, MarshalPubKey(&privKey.PublicKey), hash)
+
+	s := new(big.Int).Mul(e, privKey.D)
+	s.Add(s, k).Mod(s, order())
+	return append(r, scalarBytes(s)...), nil
+}
+
+// Verify will check that s*G = R + e*P, the key must be compressed
+func (schnorrScheme) Verify(pubKey, hash, signature []byte) bool {
+	px, py, ok := parsePoint(pubKey)
+	if !ok || len(signature) != SchnorrSize {
+		return false
+	}
+
+	rx, ry, ok := parsePoint(signature[:KeySize])
+	if !ok {
+		return false
+	}
+
+	s, ok := parseScalar(signature[KeySize:])
+	if !ok {
+		return false
+	}
+
+	e := challenge(signature[:KeySize], pubKey, hash)
+	c := curve()
+
+	lx, ly := c.ScalarBaseMult(scalarBytes(s))
+	ex, ey := c.ScalarMult(px, py, scalarBytes(e))
+	qx, qy := c.Add(rx, ry, ex, ey)
+	return lx.Cmp(qx) == 0 && ly.Cmp(qy) == 0
+}
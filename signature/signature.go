@@ -0,0 +1,192 @@
+// Package signature implements the signature schemes of the locking
+// scripts over P-256: ECDSA for the original outputs and Schnorr for the
+// version 1 outputs, with batch verification and MuSig key aggregation.
+//
+// Signatures are fixed width and carry no hash type, the transaction code
+// appends it. An ECDSA signature is r and s padded to 32 bytes, big endian,
+// with s in the lower half of the curve order. A Schnorr signature is the
+// compressed nonce point R followed by the padded scalar s, it is valid
+// when s*G = R + e*P where e is the tagged hash of R, the compressed key P
+// and the message, taken modulo the curve order.
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const (
+	ECDSASize   = 64 // size of an ecdsa signature
+	SchnorrSize = 65 // size of a schnorr signature
+	KeySize     = 33 // size of a compressed public key
+)
+
+var (
+	ErrInvalidKey       = errors.New("signature: the public key is not a valid SEC1 key")
+	ErrInvalidSignature = errors.New("signature: the signature is not valid")
+	ErrInvalidNonce     = errors.New("signature: the nonce is not valid")
+)
+
+// Scheme signs and verifies the hash of a message
+type Scheme interface {
+	// Sign will sign the hash with the private key
+	Sign(privKey *ecdsa.PrivateKey, hash []byte) ([]byte, error)
+	// Verify will report if the signature of the hash is valid for the encoded public key
+	Verify(pubKey, hash, signature []byte) bool
+}
+
+var (
+	ECDSA   Scheme = ecdsaScheme{}   // signs the pay to public key hash and script outputs
+	Schnorr Scheme = schnorrScheme{} // signs the version 1 outputs
+)
+
+// curve will return the curve of every scheme
+func curve() elliptic.Curve {
+	return elliptic.P256()
+}
+
+// order will return the order of the curve
+func order() *big.Int {
+	return curve().Params().N
+}
+
+// MarshalPubKey will encode the public key in the 33 bytes compressed
+// SEC1 form, the parity of y followed by the padded x coordinate
+func MarshalPubKey(pub *ecdsa.PublicKey) []byte {
+	return elliptic.MarshalCompressed(curve(), pub.X, pub.Y)
+}
+
+// ParsePubKey will decode a compressed or uncompressed SEC1 public key,
+// the point must be on the curve
+func ParsePubKey(data []byte) (*ecdsa.PublicKey, error) {
+	var x, y *big.Int
+	switch {
+	case len(data) == KeySize && (data[0] == 0x02 || data[0] == 0x03):
+		x, y = elliptic.UnmarshalCompressed(curve(), data)
+	case len(data) == 65 && data[0] == 0x04:
+		x, y = elliptic.Unmarshal(curve(), data)
+	}
+
+	if x == nil {
+		return nil, ErrInvalidKey
+	}
+
+	return &ecdsa.PublicKey{Curve: curve(), X: x, Y: y}, nil
+}
+
+// parsePoint will decode a compressed point, schnorr keys and nonces are
+// always compressed so their encoding is unique
+func parsePoint(data []byte) (*big.Int, *big.Int, bool) {
+	if len(data) != KeySize {
+		return nil, nil, false
+	}
+
+	x, y := elliptic.UnmarshalCompressed(curve(), data)
+	return x, y, x != nil
+}
+
+// marshalPoint will encode the point compressed, the point at infinity has no encoding
+func marshalPoint(x, y *big.Int) ([]byte, bool) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, false
+	}
+
+	return elliptic.MarshalCompressed(curve(), x, y), true
+}
+
+// scalarBytes will pad the scalar to 32 bytes
+func scalarBytes(n *big.Int) []byte {
+	b := make([]byte, 32)
+	n.FillBytes(b)
+	return b
+}
+
+// parseScalar will decode a padded scalar, it must be below the curve order
+func parseScalar(data []byte) (*big.Int, bool) {
+	if len(data) != 32 {
+		return nil, false
+	}
+
+	n := new(big.Int).SetBytes(data)
+	return n, n.Cmp(order()) < 0
+}
+
+// randomScalar will return a random scalar in [1, n)
+func randomScalar() (*big.Int, error) {
+	for {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		k := new(big.Int).SetBytes(b)
+		if k.Sign() > 0 && k.Cmp(order()) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// taggedHash will hash the parts under the tag, so hashes made for one
+// purpose can not be reused for another
+func taggedHash(tag string, parts ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, part := range parts {
+		h.Write(part)
+	}
+
+	return h.Sum(nil)
+}
+
+// hashScalar will return the tagged hash as a scalar
+func hashScalar(tag string, parts ...[]byte) *big.Int {
+	e := new(big.Int).SetBytes(taggedHash(tag, parts...))
+	return e.Mod(e, order())
+}
+
+// ecdsaScheme signs with ecdsa, s is normalised to the lower half of the
+// curve order so the signature can not be changed into another valid one
+type ecdsaScheme struct{}
+
+// Sign will sign the hash with ecdsa
+func (ecdsaScheme) Sign(privKey *ecdsa.PrivateKey, hash []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, privKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	n := order()
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+
+	return append(scalarBytes(r), scalarBytes(s)...), nil
+}
+
+// Verify will check the ecdsa signature, high s values are rejected
+func (ecdsaScheme) Verify(pubKey, hash, signature []byte) bool {
+	if len(signature) != ECDSASize {
+		return false
+	}
+
+	n := order()
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Sign() <= 0 || r.Cmp(n) >= 0 || s.Sign() <= 0 || s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		return false
+	}
+
+	pub, err := ParsePubKey(pubKey)
+	if err != nil {
+		return false
+	}
+
+	return ecdsa.Verify(pub, hash, r, s)
+}
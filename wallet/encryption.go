@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+/*
+wallet encryption
+
+the seed and the mnemonic are sealed with AES-256-GCM, the key comes
+from the passphrase through scrypt with a random salt. The public keys
+stay in clear so a locked wallet can still list its addresses.
+*/
+
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLength   = 16
+)
+
+var (
+	ErrWrongPassphrase = errors.New("the wallet passphrase is not correct")
+	ErrWalletLocked    = errors.New("the wallet is locked, unlock it with the passphrase first")
+	ErrNotEncrypted    = errors.New("the wallet is not encrypted")
+	ErrEncrypted       = errors.New("the wallet is already encrypted")
+)
+
+type KDFParams struct {
+	N    int    // represents the scrypt cpu/memory cost
+	R    int    // represents the scrypt block size
+	P    int    // represents the scrypt parallelization
+	Salt []byte // represents the random salt of the key derivation
+}
+
+type sealedSeed struct {
+	KDF        KDFParams // represents the parameters to derive the key again
+	Nonce      []byte    // represents the nonce used by the cipher
+	Ciphertext []byte    // represents the encrypted secrets of the wallet
+}
+
+// secrets is the plain content that gets encrypted
+type secrets struct {
+	Seed        []byte
+	Mnemonic    string
+	MuSigNonces map[string][]byte
+}
+
+// newKDFParams will generate scrypt params with a fresh salt
+func newKDFParams() (KDFParams, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return KDFParams{}, err
+	}
+
+	return KDFParams{N: scryptN, R: scryptR, P: scryptP, Salt: salt}, nil
+}
+
+// deriveKey will stretch the passphrase into an encryption key
+func (p KDFParams) deriveKey(passphrase string) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, scryptKeyLen)
+}
+
+// seal will encrypt the given secrets with the given key
+func seal(key []byte, kdf KDFParams, plain secrets) (*sealedSeed, error) {
+	var content bytes.Buffer
+	if err := gob.NewEncoder(&content).Encode(plain); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := aead.Seal(nil, nonce, content.Bytes(), nil)
+	wipe(content.Bytes())
+	return &sealedSeed{KDF: kdf, Nonce: nonce, Ciphertext: ciphertext}, nil
+}
+
+// open will decrypt the sealed secrets with the given key
+func (s *sealedSeed) open(key []byte) (secrets, error) {
+	var plain secrets
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return plain, err
+	}
+
+	content, err := aead.Open(nil, s.Nonce, s.Ciphertext, nil)
+	if err != nil {
+		return plain, ErrWrongPassphrase
+	}
+
+	defer wipe(content)
+	err = gob.NewDecoder(bytes.NewReader(content)).Decode(&plain)
+	return plain, err
+}
+
+// newAEAD will create the authenticated cipher for the given key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// wipe will overwrite the given secret with zeros
+func wipe(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/*
+hierarchical deterministic keys (BIP32 style)
+
+every address of the wallet is derived from a single seed following
+SLIP-0010 for the NIST P-256 curve, so a backup of the seed recovers
+every address ever created. Addresses live under the path:
+
+	m / 44' / 1' / account' / 0 / index
+*/
+
+const (
+	HardenedOffset = uint32(0x80000000)
+	purpose        = 44
+	coinType       = 1
+	masterKeySalt  = "Nist256p1 seed"
+)
+
+var ErrInvalidSeed = errors.New("the seed can not produce a valid master key")
+
+type ExtendedKey struct {
+	Key       []byte // represents the private scalar of the key
+	ChainCode []byte // represents the chain code used to derive children
+	Depth     uint8  // represents how many derivations led to this key
+	Index     uint32 // represents the child index of this key
+}
+
+// NewMasterKey will derive the root extended key from the given seed
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+
+	n := elliptic.P256().Params().N
+	data := seed
+
+	for i := 0; i < 256; i++ {
+		mac := hmac.New(sha512.New, []byte(masterKeySalt))
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		key := new(big.Int).SetBytes(sum[:32])
+		if key.Sign() != 0 && key.Cmp(n) < 0 {
+			return &ExtendedKey{Key: sum[:32], ChainCode: sum[32:]}, nil
+		}
+
+		data = sum
+	}
+
+	return nil, ErrInvalidSeed
+}
+
+// Child will derive the child key at the given index, indexes greater
+// or equal than HardenedOffset produce hardened children
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	parent := new(big.Int).SetBytes(k.Key)
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, padScalar(k.Key)...)
+	} else {
+		x, y := curve.ScalarBaseMult(padScalar(k.Key))
+		data = elliptic.MarshalCompressed(curve, x, y)
+	}
+	data = appendIndex(data, index)
+
+	for i := 0; i < 256; i++ {
+		mac := hmac.New(sha512.New, k.ChainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		child := new(big.Int).Add(il, parent)
+		child.Mod(child, n)
+
+		if il.Cmp(n) < 0 && child.Sign() != 0 {
+			return &ExtendedKey{
+				Key:       padScalar(child.Bytes()),
+				ChainCode: sum[32:],
+				Depth:     k.Depth + 1,
+				Index:     index,
+			}, nil
+		}
+
+		data = appendIndex(append([]byte{0x01}, sum[32:]...), index)
+	}
+
+	return nil, fmt.Errorf("can not derive child %d", index)
+}
+
+// DerivePath will derive the descendant key following the given indexes
+func (k *ExtendedKey) DerivePath(path ...uint32) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		child, err := key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+
+		key = child
+	}
+
+	return key, nil
+}
+
+// PrivateKey will return the ecdsa private key of the extended key
+func (k *ExtendedKey) PrivateKey() ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	private := ecdsa.PrivateKey{D: new(big.Int).SetBytes(k.Key)}
+	private.PublicKey.Curve = curve
+	private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(padScalar(k.Key))
+	return private
+}
+
+// AddressPath will return the derivation path of the address at the
+// given account and index
+func AddressPath(account, index uint32) []uint32 {
+	return []uint32{
+		purpose + HardenedOffset,
+		coinType + HardenedOffset,
+		account + HardenedOffset,
+		0,
+		index,
+	}
+}
+
+// FormatPath will return the human readable form of the given path
+func FormatPath(path []uint32) string {
+	str := "m"
+	for _, index := range path {
+		if index >= HardenedOffset {
+			str += fmt.Sprintf("/%d'", index-HardenedOffset)
+		} else {
+			str += fmt.Sprintf("/%d", index)
+		}
+	}
+
+	return str
+}
+
+// padScalar will left pad the given scalar to 32 bytes
+func padScalar(scalar []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded[32-len(scalar):], scalar)
+	return padded
+}
+
+// appendIndex will append the big endian form of the index to data
+func appendIndex(data []byte, index uint32) []byte {
+	var buff [4]byte
+	binary.BigEndian.PutUint32(buff[:], index)
+	return append(data, buff[:]...)
+}
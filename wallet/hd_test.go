@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// the SLIP-0010 vectors of the nist256p1 curve
+func TestDerivePathVectors(t *testing.T) {
+	const seed1 = "000102030405060708090a0b0c0d0e0f"
+	const h = HardenedOffset
+
+	tests := []struct {
+		seed      string
+		path      []uint32
+		chainCode string
+		key       string
+	}{
+		{seed1, nil, "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+		{seed1, []uint32{h}, "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+		{seed1, []uint32{h, 1}, "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129"},
+		{seed1, []uint32{h, 1, h + 2}, "98c7514f562e64e74170cc3cf304ee1ce54d6b6da4f880f313e8204c2a185318", "694596e8a54f252c960eb771a3c41e7e32496d03b954aeb90f61635b8e092aa7"},
+		{seed1, []uint32{h, 1, h + 2, 2}, "ba96f776a5c3907d7fd48bde5620ee374d4acfd540378476019eab70790c63a0", "5996c37fd3dd2679039b23ed6f70b506c6b56b3cb5e424681fb0fa64caf82aaa"},
+		{seed1, []uint32{h, 1, h + 2, 2, 1000000000}, "b9b7b82d326bb9cb5b5b121066feea4eb93d5241103c9e7a18aad40f1dde8059", "21c4f269ef0a5fd1badf47eeacebeeaa3de22eb8e5b0adcd0f27dd99d34d0119"},
+		// the first try of these children is not a valid key
+		{seed1, []uint32{h + 28578}, "e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2", "06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669"},
+		{seed1, []uint32{h + 28578, 33941}, "9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071", "092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a"},
+		// the first try of this master key is not a valid key
+		{"a7305bc8df8d0951f0cb224c0e95d7707cbdf2c6ce7e8d481fec69c7ff5e9446", nil, "7762f9729fed06121fd13f326884c82f59aa95c57ac492ce8c9654e60efd130c", "3b8c18469a4634517d6d0b65448f8e6c62091b45540a1743c5846be55d47d88f"},
+	}
+
+	for _, test := range tests {
+		t.Run(FormatPath(test.path), func(t *testing.T) {
+			seed, err := hex.DecodeString(test.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			master, err := NewMasterKey(seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := master.DerivePath(test.path...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hex.EncodeToString(key.ChainCode); got != test.chainCode {
+				t.Errorf("got chain code %s, want %s", got, test.chainCode)
+			}
+
+			if got := hex.EncodeToString(key.Key); got != test.key {
+				t.Errorf("got key %s, want %s", got, test.key)
+			}
+
+			if int(key.Depth) != len(test.path) {
+				t.Errorf("got depth %d, want %d", key.Depth, len(test.path))
+			}
+		})
+	}
+}
+
+func TestNewMasterKeySeedLength(t *testing.T) {
+	for _, size := range []int{0, 15, 65} {
+		if _, err := NewMasterKey(make([]byte, size)); !errors.Is(err, ErrInvalidSeed) {
+			t.Errorf("got error %v for a seed of %d bytes, want %v", err, size, ErrInvalidSeed)
+		}
+	}
+}
+
+// the BIP39 vectors with the TREZOR passphrase
+func TestSeedFromMnemonic(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		seed     string
+		err      error
+	}{
+		{strings.Repeat("abandon ", 11) + "about", "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", nil},
+		{strings.Repeat("abandon ", 23) + "art", "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8", nil},
+		{strings.Repeat("zoo ", 23) + "vote", "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad", nil},
+		{"  " + strings.Repeat("abandon  ", 11) + "about\n", "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", nil},
+		{strings.Repeat("abandon ", 12), "", ErrInvalidMnemonic},
+		{strings.Repeat("abandon ", 11) + "notaword", "", ErrInvalidMnemonic},
+	}
+
+	for _, test := range tests {
+		seed, err := SeedFromMnemonic(test.mnemonic, "TREZOR")
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%q: got error %v, want %v", test.mnemonic, err, test.err)
+		}
+
+		if got := hex.EncodeToString(seed); got != test.seed {
+			t.Fatalf("%q: got seed %s, want %s", test.mnemonic, got, test.seed)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// entropyBits gives a 24 words mnemonic
+const entropyBits = 256
+
+var ErrInvalidMnemonic = errors.New("the mnemonic is not valid")
+
+// NewMnemonic will generate a new random BIP39 mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic will validate the given mnemonic and turn it into
+// a seed, the passphrase is the optional BIP39 extension word
+func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
@@ -6,44 +6,140 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"log"
+
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/signature"
+	"github.com/mr-tron/base58"
 )
 
+// the versions name the kind of an address, the byte written in the address
+// is the one the active network uses for that kind
 const (
-	checksumLength = 4
-	version        = byte(0x00)
+	checksumLength    = 4
+	PubKeyHashVersion = byte(0x00) // version of the addresses that pay to a public key hash
+	ScriptHashVersion = byte(0x05) // version of the addresses that pay to a script hash
+	SchnorrKeyVersion = byte(0x0b) // version of the addresses that pay to a schnorr key
+)
+
+var (
+	ErrInvalidAddress = errors.New("the address is not valid")
+	ErrInvalidPubKey  = signature.ErrInvalidKey
 )
 
 // Wallet represents users Wallet in the blockchain
 type Wallet struct {
 	PrivateKey ecdsa.PrivateKey // represents the private key of the wallet
 	PublicKey  []byte           // represents the plubic key of the wallet
+	Index      uint32           // represents the derivation index of the address
 }
 
 // Address will generate the wallet address according to the
 // specification wich a hash with checksum hash, the version hash,
 // and the public key hash
 func (w Wallet) Address() []byte {
-	pubHash := PublicKeyHash(w.PublicKey)
-	versionedHash := append([]byte{version}, pubHash...)
+	return EncodeAddress(PubKeyHashVersion, PublicKeyHash(w.PublicKey))
+}
+
+// SchnorrAddress will generate the address that pays to the public key
+// with a version 1 output, spent with a schnorr signature
+func (w Wallet) SchnorrAddress() []byte {
+	return SchnorrKeyAddress(w.PublicKey)
+}
+
+// SchnorrKeyAddress will generate the address of a compressed schnorr key,
+// like a musig aggregated key. The address holds the key, not its hash
+func SchnorrKeyAddress(pubKey []byte) []byte {
+	return EncodeAddress(SchnorrKeyVersion, pubKey)
+}
+
+// ScriptAddress will generate the pay to script hash address of the
+// given redeem script
+func ScriptAddress(redeem []byte) []byte {
+	return EncodeAddress(ScriptHashVersion, PublicKeyHash(redeem))
+}
+
+// networkVersion will return the byte of the kind of address in the active network
+func networkVersion(version byte) byte {
+	switch version {
+	case ScriptHashVersion:
+		return params.Active.ScriptHashAddrID
+	case SchnorrKeyVersion:
+		return params.Active.SchnorrKeyAddrID
+	}
+
+	return params.Active.PubKeyHashAddrID
+}
+
+// addressVersion will return the kind of address of the byte, false when
+// the active network does not use it
+func addressVersion(id byte) (byte, bool) {
+	switch id {
+	case params.Active.PubKeyHashAddrID:
+		return PubKeyHashVersion, true
+	case params.Active.ScriptHashAddrID:
+		return ScriptHashVersion, true
+	case params.Active.SchnorrKeyAddrID:
+		return SchnorrKeyVersion, true
+	}
+
+	return 0, false
+}
+
+// EncodeAddress will encode the hash with the byte the active network uses
+// for its version and a checksum in base58
+func EncodeAddress(version byte, hash []byte) []byte {
+	versionedHash := append([]byte{networkVersion(version)}, hash...)
 	checksum := Checksum(versionedHash)
 
 	fullHash := append(versionedHash, checksum...)
-	address := Base58Encode(fullHash)
-	return address
+	return Base58Encode(fullHash)
+}
+
+// DecodeAddress will decode the address and validate its checksum,
+// returning the version and the hash. The addresses of other networks
+// are not valid
+// address -> FullHash -> version -> hash -> checksum
+func DecodeAddress(address string) (byte, []byte, error) {
+	fullHash, err := base58.Decode(address)
+	if err != nil || len(fullHash) <= checksumLength+1 {
+		return 0, nil, ErrInvalidAddress
+	}
+
+	actualCheckSum := fullHash[len(fullHash)-checksumLength:]
+	hash := fullHash[1 : len(fullHash)-checksumLength]
+	targetCheckSum := Checksum(fullHash[:len(fullHash)-checksumLength])
+
+	if !bytes.Equal(actualCheckSum, targetCheckSum) {
+		return 0, nil, ErrInvalidAddress
+	}
+
+	version, ok := addressVersion(fullHash[0])
+	if !ok {
+		return 0, nil, ErrInvalidAddress
+	}
+
+	switch version {
+	case PubKeyHashVersion, ScriptHashVersion:
+	case SchnorrKeyVersion:
+		if len(hash) != signature.KeySize {
+			return 0, nil, ErrInvalidAddress
+		}
+
+		if _, err := signature.ParsePubKey(hash); err != nil {
+			return 0, nil, ErrInvalidAddress
+		}
+	}
+
+	return version, hash, nil
 }
 
 // Validate address will check if the given address is valid
 // decoding the address with base58 algorithm and validate all the parts of the hash
-// address -> FullHash -> version -> pubkey -> checksum
 func ValidateAddress(address string) bool {
-	pubKeyHash := Base58Decode([]byte(address))
-	actualCheckSum := pubKeyHash[len(pubKeyHash)-checksumLength:]
-	version := pubKeyHash[0]
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-checksumLength]
-	targetCheckSum := Checksum(append([]byte{version}, pubKeyHash...))
-
-	return bytes.Equal(actualCheckSum, targetCheckSum)
+	_, _, err := DecodeAddress(address)
+	return err == nil
 }
 
 // NewKeyPair will create a new public and private key for the user
@@ -53,8 +149,19 @@ func NewKeyPair() (ecdsa.PrivateKey, []byte) {
 	private, err := ecdsa.GenerateKey(curve, rand.Reader)
 	handle(err)
 
-	pub := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
-	return *private, pub
+	return *private, MarshalPubKey(&private.PublicKey)
+}
+
+// MarshalPubKey will encode the public key in the 33 bytes compressed
+// SEC1 form, the parity of y followed by the padded x coordinate
+func MarshalPubKey(pub *ecdsa.PublicKey) []byte {
+	return signature.MarshalPubKey(pub)
+}
+
+// ParsePubKey will decode a compressed or uncompressed SEC1 public key,
+// the point must be on the curve
+func ParsePubKey(data []byte) (*ecdsa.PublicKey, error) {
+	return signature.ParsePubKey(data)
 }
 
 // MakeWalllet will create a new wallet instance
@@ -63,6 +170,23 @@ func MakeWallet() *Wallet {
 	return &Wallet{PrivateKey: private, PublicKey: public}
 }
 
+// DeriveWallet will create the wallet of the address at the given
+// account and index from the hd seed
+func DeriveWallet(seed []byte, account, index uint32) (*Wallet, error) {
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := master.DerivePath(AddressPath(account, index)...)
+	if err != nil {
+		return nil, err
+	}
+
+	private := key.PrivateKey()
+	return &Wallet{PrivateKey: private, PublicKey: MarshalPubKey(&private.PublicKey), Index: index}, nil
+}
+
 // publicKeyHash will generate a hash of the given publickey
 func PublicKeyHash(pubKey []byte) []byte {
 	pubHash := sha256.Sum256(pubKey)
@@ -2,17 +2,79 @@ package wallet
 
 import (
 	"bytes"
-	"crypto/elliptic"
+	"crypto/ecdsa"
 	"encoding/gob"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Haizza1/go-block/params"
+	"github.com/Haizza1/go-block/signature"
+)
+
+const (
+	walletFile = "Wallets_%s.data"
+	GapLimit   = 20 // how many unused addresses in a row end a rescan
 )
 
-const walletFile = "./tmp/Wallets_%s.data"
+var (
+	ErrSeedExists   = errors.New("the wallet already has a seed")
+	ErrNotInWallet  = errors.New("the address is not in the wallet")
+	ErrLegacyWallet = errors.New("the file holds the random keys of a wallet made before the hd seeds, move it away to keep them before creating a new wallet")
+)
 
 type Wallets struct {
-	Wallets map[string]*Wallet
+	Seed      []byte              // represents the hd seed every address is derived from
+	Mnemonic  string              // represents the backup phrase of the seed
+	Account   uint32              // represents the account used to derive the addresses
+	NextIndex uint32              // represents the index of the next address to derive
+	Wallets   map[string]*Wallet  // represents the derived addresses, never saved
+	Locked    map[string]bool     // represents the outpoints that coin selection must skip
+	Scripts   map[string][]byte   // represents the redeem scripts of the script hash addresses
+	MuSig     map[string][][]byte // represents the keys aggregated into the musig addresses
+
+	// MuSigNonces holds the secret musig nonces by hex public nonce, they
+	// are secrets like the seed and every one signs only once
+	MuSigNonces map[string][]byte
+
+	sealed  *sealedSeed // represents the encrypted secrets, nil when not encrypted
+	key     []byte      // represents the encryption key while the wallet is unlocked
+	relock  *time.Timer // represents the pending lock of a timed unlock
+	loadErr error       // represents why the existing file could not be read, it is never overwritten then
+	mu      sync.Mutex
+}
+
+// walletData is the content of the wallets file, the private keys are
+// not stored and get derived again from the seed. When the wallet is
+// encrypted the seed lives only inside Sealed and the public keys are
+// kept in clear so the addresses are known while locked
+type walletData struct {
+	Seed        []byte
+	Mnemonic    string
+	Account     uint32
+	NextIndex   uint32
+	Sealed      *sealedSeed
+	PublicKeys  [][]byte
+	Locked      map[string]bool
+	Scripts     map[string][]byte
+	MuSig       map[string][][]byte
+	MuSigNonces map[string][]byte
+
+	// Wallets holds the random keys of the files written before the hd
+	// seeds, it is only read to recognize those files and never written
+	Wallets map[string]*legacyWallet
+}
+
+// legacyWallet is a key of a wallets file written before the hd seeds
+type legacyWallet struct {
+	PublicKey []byte
 }
 
 // CreateWallters will generate a new Wallets instance
@@ -41,51 +103,533 @@ func (ws *Wallets) GetAllAddress() []string {
 	return addresses
 }
 
-// Add wallet will save a wallet into the map and the wallets file
+// HasSeed will check if the wallet already has an hd seed
+func (ws *Wallets) HasSeed() bool {
+	return len(ws.Seed) > 0
+}
+
+// NewSeed will generate a new mnemonic and set its seed as the seed of
+// the wallet, the mnemonic is returned so it can be backed up
+func (ws *Wallets) NewSeed() (string, error) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		return "", err
+	}
+
+	return mnemonic, ws.SetMnemonic(mnemonic, "")
+}
+
+// SetMnemonic will set the seed of the given mnemonic as the seed of
+// the wallet, a wallet seed can not be replaced once it is set
+func (ws *Wallets) SetMnemonic(mnemonic, passphrase string) error {
+	if ws.HasSeed() || ws.IsEncrypted() {
+		return ErrSeedExists
+	}
+
+	seed, err := SeedFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if _, err := NewMasterKey(seed); err != nil {
+		return err
+	}
+
+	ws.Seed = seed
+	ws.Mnemonic = mnemonic
+	ws.NextIndex = 0
+	ws.Wallets = make(map[string]*Wallet)
+	return nil
+}
+
+// Add wallet will derive the next address of the seed and save it into the map
 func (ws *Wallets) AddWallet() string {
-	wallet := MakeWallet()
-	address := fmt.Sprintf("%s", wallet.Address())
+	if ws.IsLocked() {
+		handle(ErrWalletLocked)
+	}
 
+	if !ws.HasSeed() {
+		_, err := ws.NewSeed()
+		handle(err)
+	}
+
+	wallet, err := DeriveWallet(ws.Seed, ws.Account, ws.NextIndex)
+	handle(err)
+
+	address := fmt.Sprintf("%s", wallet.Address())
 	ws.Wallets[address] = wallet
+	ws.NextIndex++
+	return address
+}
+
+// Rescan will walk the derivation indexes from zero and add every address
+// for wich isUsed reports activity, the walk stops after gapLimit unused
+// addresses in a row. Returns the number of used addresses found, or
+// ErrWalletLocked when the seed is locked
+func (ws *Wallets) Rescan(isUsed func(pubKeyHash []byte) bool, gapLimit int) (int, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.IsLocked() {
+		return 0, ErrWalletLocked
+	}
+
+	found := 0
+	gap := 0
+
+	for index := uint32(0); gap < gapLimit; index++ {
+		wallet, err := DeriveWallet(ws.Seed, ws.Account, index)
+		if err != nil {
+			return found, err
+		}
+
+		if !isUsed(PublicKeyHash(wallet.PublicKey)) {
+			gap++
+			continue
+		}
+
+		address := fmt.Sprintf("%s", wallet.Address())
+		ws.Wallets[address] = wallet
+		found++
+		gap = 0
+
+		if index >= ws.NextIndex {
+			ws.NextIndex = index + 1
+		}
+	}
+
+	return found, nil
+}
+
+// deriveAll will derive every address up to the next index
+func (ws *Wallets) deriveAll() error {
+	for index := uint32(0); index < ws.NextIndex; index++ {
+		wallet, err := DeriveWallet(ws.Seed, ws.Account, index)
+		if err != nil {
+			return err
+		}
+
+		ws.Wallets[fmt.Sprintf("%s", wallet.Address())] = wallet
+	}
+
+	return nil
+}
+
+// LockUnspent will mark the given outpoint so it is never picked by coin selection
+func (ws *Wallets) LockUnspent(outPoint string) {
+	if ws.Locked == nil {
+		ws.Locked = make(map[string]bool)
+	}
+
+	ws.Locked[outPoint] = true
+}
+
+// UnlockUnspent will make the given outpoint available to coin selection
+// again, returns false if the outpoint was not locked
+func (ws *Wallets) UnlockUnspent(outPoint string) bool {
+	if !ws.Locked[outPoint] {
+		return false
+	}
+
+	delete(ws.Locked, outPoint)
+	return true
+}
+
+// ListLockUnspent will return the locked outpoints in order
+func (ws *Wallets) ListLockUnspent() []string {
+	var outPoints []string
+	for outPoint := range ws.Locked {
+		outPoints = append(outPoints, outPoint)
+	}
+
+	sort.Strings(outPoints)
+	return outPoints
+}
+
+// AddScript will watch the pay to script hash address of the redeem
+// script and return it, the script is needed to spend from the address
+func (ws *Wallets) AddScript(redeem []byte) string {
+	if ws.Scripts == nil {
+		ws.Scripts = make(map[string][]byte)
+	}
+
+	address := fmt.Sprintf("%s", ScriptAddress(redeem))
+	ws.Scripts[address] = redeem
 	return address
 }
 
+// GetScript will return the redeem script of the given address, nil when
+// the wallet does not know it
+func (ws *Wallets) GetScript(address string) []byte {
+	return ws.Scripts[address]
+}
+
+// FindWallet will return the wallet of a pay to public key hash address
+// or of the schnorr address of one of its keys
+func (ws *Wallets) FindWallet(address string) (*Wallet, bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.findWallet(address)
+}
+
+// findWallet will find the wallet of the address, the caller holds the mutex
+func (ws *Wallets) findWallet(address string) (*Wallet, bool) {
+	if wallet, ok := ws.Wallets[address]; ok {
+		return wallet, true
+	}
+
+	version, pubKey, err := DecodeAddress(address)
+	if err != nil || version != SchnorrKeyVersion {
+		return nil, false
+	}
+
+	return ws.GetWalletByKey(pubKey)
+}
+
+// SigningWallet will return a copy of the wallet of the address with its
+// own copy of the private key, taken under the mutex so a timed lock can
+// not wipe the key while the copy signs
+func (ws *Wallets) SigningWallet(address string) (Wallet, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.IsLocked() {
+		return Wallet{}, ErrWalletLocked
+	}
+
+	wallet, ok := ws.findWallet(address)
+	if !ok {
+		return Wallet{}, fmt.Errorf("%s: %w", address, ErrNotInWallet)
+	}
+
+	signing := *wallet
+	if wallet.PrivateKey.D != nil {
+		signing.PrivateKey.D = new(big.Int).Set(wallet.PrivateKey.D)
+	}
+
+	return signing, nil
+}
+
+// AddMuSig will watch the address of the key aggregated from the given
+// keys and return it, the keys are needed to sign for the address
+func (ws *Wallets) AddMuSig(pubKeys [][]byte) (string, error) {
+	aggregated, err := signature.AggregateKeys(pubKeys)
+	if err != nil {
+		return "", err
+	}
+
+	if ws.MuSig == nil {
+		ws.MuSig = make(map[string][][]byte)
+	}
+
+	address := fmt.Sprintf("%s", SchnorrKeyAddress(aggregated))
+	ws.MuSig[address] = signature.SortKeys(pubKeys)
+	return address, nil
+}
+
+// GetMuSig will return the keys aggregated into the given address, nil
+// when the wallet does not know it
+func (ws *Wallets) GetMuSig(address string) [][]byte {
+	return ws.MuSig[address]
+}
+
+// AddMuSigNonce will keep the secret nonce of a signing session until it signs
+func (ws *Wallets) AddMuSigNonce(public, secret []byte) {
+	if ws.MuSigNonces == nil {
+		ws.MuSigNonces = make(map[string][]byte)
+	}
+
+	ws.MuSigNonces[hex.EncodeToString(public)] = secret
+}
+
+// TakeMuSigNonce will return the secret nonce of the public one and forget
+// it, a nonce that signed twice leaks the private key
+func (ws *Wallets) TakeMuSigNonce(public []byte) ([]byte, bool) {
+	key := hex.EncodeToString(public)
+	secret, ok := ws.MuSigNonces[key]
+	delete(ws.MuSigNonces, key)
+	return secret, ok
+}
+
+// GetWalletByKey will return the wallet of the given public key
+func (ws *Wallets) GetWalletByKey(pubKey []byte) (*Wallet, bool) {
+	for _, wallet := range ws.Wallets {
+		if bytes.Equal(wallet.PublicKey, pubKey) {
+			return wallet, true
+		}
+	}
+
+	return nil, false
+}
+
+// IsEncrypted will check if the secrets of the wallet are encrypted
+func (ws *Wallets) IsEncrypted() bool {
+	return ws.sealed != nil
+}
+
+// IsLocked will check if the wallet is encrypted and its seed is not
+// available in memory, a locked wallet can not sign nor derive addresses
+func (ws *Wallets) IsLocked() bool {
+	return ws.IsEncrypted() && !ws.HasSeed()
+}
+
+// Encrypt will encrypt the seed with the given passphrase and lock the wallet
+func (ws *Wallets) Encrypt(passphrase string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.IsEncrypted() {
+		return ErrEncrypted
+	}
+
+	if !ws.HasSeed() {
+		if _, err := ws.NewSeed(); err != nil {
+			return err
+		}
+	}
+
+	kdf, err := newKDFParams()
+	if err != nil {
+		return err
+	}
+
+	key, err := kdf.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(key, kdf, ws.secrets())
+	if err != nil {
+		return err
+	}
+
+	ws.sealed = sealed
+	ws.lock()
+	return nil
+}
+
+// Unlock will decrypt the seed with the given passphrase and derive the
+// private keys again, the wallet stays unlocked until Lock is called
+func (ws *Wallets) Unlock(passphrase string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if !ws.IsEncrypted() {
+		return ErrNotEncrypted
+	}
+
+	key, err := ws.sealed.KDF.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	plain, err := ws.sealed.open(key)
+	if err != nil {
+		return err
+	}
+
+	ws.Seed = plain.Seed
+	ws.Mnemonic = plain.Mnemonic
+	ws.MuSigNonces = plain.MuSigNonces
+	ws.key = key
+	return ws.deriveAll()
+}
+
+// UnlockFor will unlock the wallet and lock it again once the given
+// timeout expires, a new call replaces the pending timeout
+func (ws *Wallets) UnlockFor(passphrase string, timeout time.Duration) error {
+	if err := ws.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.relock != nil {
+		ws.relock.Stop()
+	}
+
+	ws.relock = time.AfterFunc(timeout, ws.Lock)
+	return nil
+}
+
+// Lock will remove the seed, the encryption key and the private keys
+// from memory, only the public keys are kept
+func (ws *Wallets) Lock() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.IsEncrypted() {
+		ws.lock()
+	}
+}
+
+// lock will wipe the secrets of the wallet, the caller holds the mutex
+func (ws *Wallets) lock() {
+	if ws.relock != nil {
+		ws.relock.Stop()
+		ws.relock = nil
+	}
+
+	wipe(ws.Seed)
+	wipe(ws.key)
+	for _, nonce := range ws.MuSigNonces {
+		wipe(nonce)
+	}
+
+	ws.Seed = nil
+	ws.key = nil
+	ws.Mnemonic = ""
+	ws.MuSigNonces = nil
+
+	for _, wallet := range ws.Wallets {
+		if wallet.PrivateKey.D != nil {
+			wallet.PrivateKey.D.SetInt64(0)
+		}
+		wallet.PrivateKey = ecdsa.PrivateKey{}
+	}
+}
+
+// secrets will return the secrets that get sealed when the wallet is encrypted
+func (ws *Wallets) secrets() secrets {
+	return secrets{Seed: ws.Seed, Mnemonic: ws.Mnemonic, MuSigNonces: ws.MuSigNonces}
+}
+
+// publicKeys will return the public keys of the addresses in index order
+func (ws *Wallets) publicKeys() [][]byte {
+	keys := make([][]byte, ws.NextIndex)
+	for _, wallet := range ws.Wallets {
+		if wallet.Index < ws.NextIndex {
+			keys[wallet.Index] = wallet.PublicKey
+		}
+	}
+
+	return keys
+}
+
 // loadfile will check if the wallets file exists, if exists
-// will decode the data into the wallets struct
+// will decode the data and derive the addresses again
 func (ws *Wallets) LoadFile(nodeId string) error {
-	walletFile := fmt.Sprintf(walletFile, nodeId)
+	walletFile := params.Path(fmt.Sprintf(walletFile, nodeId))
 	if _, err := os.Stat(walletFile); os.IsNotExist(err) {
 		return err
 	}
 
-	var wallets Wallets
+	var data walletData
 	fileContent, err := ioutil.ReadFile(walletFile)
 	if err != nil {
+		ws.loadErr = err
 		return err
 	}
 
-	gob.Register(elliptic.P256())
 	decoder := gob.NewDecoder(bytes.NewReader(fileContent))
-	err = decoder.Decode(&wallets)
+	err = decoder.Decode(&data)
 	if err != nil {
-		return err
+		ws.loadErr = fmt.Errorf("%s: %w", walletFile, err)
+		return ws.loadErr
 	}
 
-	ws.Wallets = wallets.Wallets
-	return nil
+	if len(data.Wallets) > 0 {
+		ws.loadErr = fmt.Errorf("%s: %w", walletFile, ErrLegacyWallet)
+		return ws.loadErr
+	}
+
+	ws.Seed = data.Seed
+	ws.Mnemonic = data.Mnemonic
+	ws.Account = data.Account
+	ws.NextIndex = data.NextIndex
+	ws.sealed = data.Sealed
+	ws.Locked = data.Locked
+	ws.Scripts = data.Scripts
+	ws.MuSig = data.MuSig
+	ws.MuSigNonces = data.MuSigNonces
+	ws.Wallets = make(map[string]*Wallet)
+
+	if ws.IsLocked() {
+		for index, pub := range data.PublicKeys {
+			wallet := &Wallet{PublicKey: pub, Index: uint32(index)}
+			ws.Wallets[fmt.Sprintf("%s", wallet.Address())] = wallet
+		}
+
+		return nil
+	}
+
+	return ws.deriveAll()
 }
 
-// save file will save all data in the wallets map into the
-// wallets.data file
+// save file will save the seed and the derivation metadata into the
+// wallets.data file, the seed is sealed first when the wallet is encrypted.
+// The file is readable only by its owner, a file that could not be read
+// is never replaced
 func (ws *Wallets) SaveFile(nodeId string) {
 	var content bytes.Buffer
-	walletFile := fmt.Sprintf(walletFile, nodeId)
-	gob.Register(elliptic.P256())
+	walletFile := params.Path(fmt.Sprintf(walletFile, nodeId))
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	handle(ws.loadErr)
+
+	data := walletData{
+		Account:   ws.Account,
+		NextIndex: ws.NextIndex,
+		Locked:    ws.Locked,
+		Scripts:   ws.Scripts,
+		MuSig:     ws.MuSig,
+	}
+
+	if ws.IsEncrypted() {
+		if !ws.IsLocked() {
+			sealed, err := seal(ws.key, ws.sealed.KDF, ws.secrets())
+			handle(err)
+			ws.sealed = sealed
+		}
+
+		data.Sealed = ws.sealed
+		data.PublicKeys = ws.publicKeys()
+	} else {
+		data.Seed = ws.Seed
+		data.Mnemonic = ws.Mnemonic
+		data.MuSigNonces = ws.MuSigNonces
+	}
 
 	encoder := gob.NewEncoder(&content)
-	err := encoder.Encode(ws)
+	err := encoder.Encode(data)
 	handle(err)
 
-	err = ioutil.WriteFile(walletFile, content.Bytes(), 0644)
+	err = writeFileAtomic(walletFile, content.Bytes())
+	wipe(content.Bytes())
 	handle(err)
 }
+
+// writeFileAtomic will write the data into a private temporary file and
+// move it over the given file, so the file is never left half written
+func writeFileAtomic(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,145 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/Haizza1/go-block/params"
+)
+
+// p256Curve mirrors the curve type the old wallets files were encoded with
+type p256Curve struct {
+	*elliptic.CurveParams
+}
+
+// oldWallet and oldWallets mirror the wallets file before the hd seeds
+type oldWallet struct {
+	PrivateKey ecdsa.PrivateKey
+	PublicKey  []byte
+}
+
+type oldWallets struct {
+	Wallets map[string]*oldWallet
+}
+
+func TestLegacyWalletIsNotOverwritten(t *testing.T) {
+	dataDir := params.DataDir
+	params.DataDir = t.TempDir()
+	defer func() { params.DataDir = dataDir }()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key.Curve = p256Curve{elliptic.P256().Params()}
+	gob.RegisterName("crypto/elliptic.p256Curve", p256Curve{})
+
+	var content bytes.Buffer
+	old := oldWallets{Wallets: map[string]*oldWallet{
+		"address": {PrivateKey: *key, PublicKey: append(key.X.Bytes(), key.Y.Bytes()...)},
+	}}
+	if err := gob.NewEncoder(&content).Encode(old); err != nil {
+		t.Fatal(err)
+	}
+
+	file := params.Path(fmt.Sprintf(walletFile, "3000"))
+	if err := ioutil.WriteFile(file, content.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wallets, err := CreateWallets("3000")
+	if !errors.Is(err, ErrLegacyWallet) {
+		t.Fatalf("got error %v, want %v", err, ErrLegacyWallet)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the legacy wallet was saved over")
+			}
+		}()
+
+		wallets.AddWallet()
+		wallets.SaveFile("3000")
+	}()
+
+	saved, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(saved, content.Bytes()) {
+		t.Fatal("the legacy wallet file changed")
+	}
+}
+
+func TestSigningWalletOutlivesLock(t *testing.T) {
+	wallets := &Wallets{Wallets: make(map[string]*Wallet)}
+	address := wallets.AddWallet()
+	if err := wallets.Encrypt("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wallets.SigningWallet(address); !errors.Is(err, ErrWalletLocked) {
+		t.Fatalf("got error %v for a locked wallet, want %v", err, ErrWalletLocked)
+	}
+
+	if err := wallets.Unlock("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wallets.SigningWallet("unknown"); !errors.Is(err, ErrNotInWallet) {
+		t.Fatalf("got error %v for an unknown address, want %v", err, ErrNotInWallet)
+	}
+
+	signing, err := wallets.SigningWallet(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := new(big.Int).Set(signing.PrivateKey.D)
+	wallets.Lock()
+
+	if signing.PrivateKey.D.Cmp(key) != 0 || key.Sign() == 0 {
+		t.Fatal("locking the wallet wiped the key of the signing copy")
+	}
+}
+
+func TestRescanLocked(t *testing.T) {
+	wallets := &Wallets{Wallets: make(map[string]*Wallet)}
+	address := wallets.AddWallet()
+	if err := wallets.Encrypt("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	used := func(pubKeyHash []byte) bool { return true }
+	if _, err := wallets.Rescan(used, GapLimit); !errors.Is(err, ErrWalletLocked) {
+		t.Fatalf("got error %v for a locked wallet, want %v", err, ErrWalletLocked)
+	}
+
+	if err := wallets.Unlock("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	used = func(pubKeyHash []byte) bool {
+		return bytes.Equal(pubKeyHash, PublicKeyHash(wallets.Wallets[address].PublicKey))
+	}
+
+	found, err := wallets.Rescan(used, GapLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found != 1 {
+		t.Fatalf("got %d used addresses, want 1", found)
+	}
+}
@@ -0,0 +1,299 @@
+// Package wire implements the canonical binary encoding of blocks,
+// transactions, unspent outputs and network messages.
+//
+// The encoding is fully specified so txids and block hashes can be
+// reproduced outside of this code base. It is built from these
+// primitives, every integer is little endian:
+//
+//	uint32    4 bytes
+//	uint64    8 bytes
+//	int64     8 bytes, two's complement
+//	varint    compact size: n < 0xfd is one byte, otherwise a 0xfd, 0xfe
+//	          or 0xff marker followed by a uint16, uint32 or uint64. The
+//	          shortest form must be used
+//	varbytes  varint length followed by the bytes
+//	string    varbytes holding utf-8 text
+//
+// A transaction is encoded as:
+//
+//	uint32    version, TxVersion
+//	varint    number of inputs, then for every input:
+//	          varbytes  id of the spent transaction, empty for the coinbase
+//	          uint32    index of the spent output, 0xffffffff for the coinbase
+//	          varbytes  unlocking script
+//	          uint32    sequence
+//	varint    number of outputs, then for every output:
+//	          int64     value
+//	          varbytes  locking script
+//	int64     lock time
+//
+// The transaction id is sha256 of that encoding with the unlocking script
+// of every input written empty, the coinbase keeps its script. Signatures
+// can not change it. The witness id is sha256 of the whole encoding.
+// Neither of them is encoded.
+//
+// A block header is encoded as:
+//
+//	uint32    version, BlockVersion
+//	varbytes  hash of the previous block, empty for the genesis
+//	varbytes  merkle root of the transaction ids
+//	varbytes  merkle root of the witness ids
+//	int64     unix time stamp
+//	uint32    height
+//	uint32    difficulty, the number of leading zero bits of the hash
+//	uint64    nonce
+//
+// The block hash is sha256 of the header. A block is its header followed
+// by a varint number of transactions and every encoded transaction.
+//
+// The unspent outputs of a transaction are stored as a varint number of
+// outputs, each one a uint32 index in the transaction, an int64 value
+// and the varbytes locking script.
+//
+// A network message is the command padded with zeros to 12 bytes, the
+// uint32 MessageVersion and the payload of the command.
+//
+// Decoders reject unknown versions, non canonical varints and trailing data.
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	TxVersion      = uint32(1) // version of the transaction encoding
+	BlockVersion   = uint32(2) // version of the block encoding
+	MessageVersion = uint32(1) // version of the network message encoding
+
+	// MaxVarBytes limits the size of a single length prefixed field
+	MaxVarBytes = 32 << 20
+)
+
+var (
+	ErrUnexpectedEOF = errors.New("wire: unexpected end of data")
+	ErrNonCanonical  = errors.New("wire: non canonical varint")
+	ErrTooLarge      = errors.New("wire: length prefix is too large")
+	ErrTrailingData  = errors.New("wire: trailing data after the encoding")
+	ErrVersion       = errors.New("wire: unsupported encoding version")
+)
+
+// Writer builds a canonical encoding, writes never fail
+type Writer struct {
+	buff bytes.Buffer
+}
+
+// NewWriter will create an empty writer
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Uint32 will write the number as 4 little endian bytes
+func (w *Writer) Uint32(n uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], n)
+	w.buff.Write(b[:])
+}
+
+// Uint64 will write the number as 8 little endian bytes
+func (w *Writer) Uint64(n uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], n)
+	w.buff.Write(b[:])
+}
+
+// Int64 will write the number as 8 little endian bytes
+func (w *Writer) Int64(n int64) {
+	w.Uint64(uint64(n))
+}
+
+// VarInt will write the number in its shortest compact size form
+func (w *Writer) VarInt(n uint64) {
+	switch {
+	case n < 0xfd:
+		w.buff.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.buff.WriteByte(0xfd)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(n))
+		w.buff.Write(b[:])
+	case n <= math.MaxUint32:
+		w.buff.WriteByte(0xfe)
+		w.Uint32(uint32(n))
+	default:
+		w.buff.WriteByte(0xff)
+		w.Uint64(n)
+	}
+}
+
+// VarBytes will write the data prefixed with its length
+func (w *Writer) VarBytes(data []byte) {
+	w.VarInt(uint64(len(data)))
+	w.buff.Write(data)
+}
+
+// String will write the text prefixed with its length
+func (w *Writer) String(text string) {
+	w.VarInt(uint64(len(text)))
+	w.buff.WriteString(text)
+}
+
+// Raw will write the data as it is, the reader must know its length
+func (w *Writer) Raw(data []byte) {
+	w.buff.Write(data)
+}
+
+// Bytes will return the encoding written so far
+func (w *Writer) Bytes() []byte {
+	return w.buff.Bytes()
+}
+
+// Reader decodes a canonical encoding. The first error is kept and every
+// later read returns zero values, so it is checked once with Err
+type Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// NewReader will create a reader over the given data
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// next will return the next n bytes or nil when there are not enough
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = ErrUnexpectedEOF
+		return nil
+	}
+
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// Uint32 will read 4 little endian bytes
+func (r *Reader) Uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(b)
+}
+
+// Uint64 will read 8 little endian bytes
+func (r *Reader) Uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(b)
+}
+
+// Int64 will read 8 little endian bytes
+func (r *Reader) Int64() int64 {
+	return int64(r.Uint64())
+}
+
+// VarInt will read a compact size number, longer forms than needed are rejected
+func (r *Reader) VarInt() uint64 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+
+	var n, least uint64
+	switch b[0] {
+	case 0xfd:
+		if b = r.next(2); b == nil {
+			return 0
+		}
+		n, least = uint64(binary.LittleEndian.Uint16(b)), 0xfd
+	case 0xfe:
+		n, least = uint64(r.Uint32()), math.MaxUint16+1
+	case 0xff:
+		n, least = r.Uint64(), math.MaxUint32+1
+	default:
+		return uint64(b[0])
+	}
+
+	if r.err == nil && n < least {
+		r.err = ErrNonCanonical
+		return 0
+	}
+
+	return n
+}
+
+// Count will read the number of items that follow, every item takes at
+// least one byte so counts larger than the remaining data are rejected
+func (r *Reader) Count() int {
+	n := r.VarInt()
+	if r.err == nil && n > uint64(len(r.data)-r.pos) {
+		r.err = ErrUnexpectedEOF
+		return 0
+	}
+
+	return int(n)
+}
+
+// VarBytes will read length prefixed data, empty data is returned as nil
+func (r *Reader) VarBytes() []byte {
+	n := r.VarInt()
+	if r.err == nil && n > MaxVarBytes {
+		r.err = ErrTooLarge
+	}
+
+	b := r.next(int(n))
+	if len(b) == 0 {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
+
+// String will read length prefixed text
+func (r *Reader) String() string {
+	return string(r.VarBytes())
+}
+
+// Raw will read the next n bytes as they are
+func (r *Reader) Raw(n int) []byte {
+	return append([]byte{}, r.next(n)...)
+}
+
+// Version will read a version number and reject it when it is not the expected one
+func (r *Reader) Version(expected uint32) {
+	if version := r.Uint32(); r.err == nil && version != expected {
+		r.err = ErrVersion
+	}
+}
+
+// Pos will return how many bytes were read so far
+func (r *Reader) Pos() int {
+	return r.pos
+}
+
+// Err will return the first error found while reading
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Done will return the first error found while reading, or an error when
+// the data was not fully read
+func (r *Reader) Done() error {
+	if r.err == nil && r.pos != len(r.data) {
+		r.err = ErrTrailingData
+	}
+
+	return r.err
+}